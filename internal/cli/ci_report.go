@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/fetcher"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+)
+
+// summaryTitle is the heading of the job summary written by the root command.
+const summaryTitle = "nix-prefetch-pnpm-deps"
+
+// hashMismatchAnnotation returns the CI error annotation of a hash mismatch. If annotateFile
+// is set, the annotation points at the line of the file that contains the expected hash;
+// the annotation is returned without the line if it cannot be found.
+func hashMismatchAnnotation(
+	osFs afero.Fs,
+	annotateFile string,
+	expected string,
+	got string,
+) (logger.Annotation, error) {
+	a := logger.Annotation{
+		Title:   "hash mismatch",
+		Message: fmt.Sprintf("hash mismatch:\n  expected %s\n  got %s", expected, got),
+		File:    annotateFile,
+	}
+
+	if annotateFile == "" {
+		return a, nil
+	}
+
+	line, err := findLine(osFs, annotateFile, expected)
+	a.Line = line
+
+	return a, err
+}
+
+// findLine returns the 1-based number of the first line of path containing s, or 0 if none does.
+func findLine(osFs afero.Fs, path string, s string) (int, error) {
+	data, err := afero.ReadFile(osFs, path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		if bytes.Contains(scanner.Bytes(), []byte(s)) {
+			return line, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return 0, nil
+}
+
+// resultSummary returns the CI job summary of a run of the root command.
+// hash and expectedHash are omitted if empty.
+func resultSummary(srcPath string, f fetcher.Fetcher, hash string, expectedHash string, result string) logger.Summary {
+	rows := []logger.SummaryRow{
+		{Name: "Result", Value: result},
+		{Name: "Source", Value: "`" + srcPath + "`"},
+		{Name: "Fetcher version", Value: strconv.Itoa(f.Version())},
+	}
+
+	if hash != "" {
+		rows = append(rows, logger.SummaryRow{Name: "Hash", Value: "`" + hash + "`"})
+	}
+
+	if expectedHash != "" {
+		rows = append(rows, logger.SummaryRow{Name: "Expected hash", Value: "`" + expectedHash + "`"})
+	}
+
+	return logger.Summary{Title: summaryTitle, Rows: rows}
+}
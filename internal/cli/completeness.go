@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+// expectedPackages returns the packages pnpm install is expected to fetch into the store:
+// the dependency closure of the projects selected by workspaces, with optional packages
+// limited to the platform given by --os/--cpu/--libc in pnpmFlags.
+func expectedPackages(
+	osFs afero.Fs,
+	srcPath string,
+	lf *lockfile.Lockfile,
+	workspaces []string,
+	pnpmFlags []string,
+) (map[string]string, error) {
+	ids := lf.ImporterIDs()
+
+	names := make(map[string]string, len(ids))
+	for _, id := range ids {
+		// Projects without a readable package.json can still be selected by path.
+		if pkg, err := packagejson.Load(osFs, filepath.Join(srcPath, id, packagejson.FileName)); err == nil {
+			names[id] = pkg.Name
+		}
+	}
+
+	selected, selectErr := lf.SelectImporters(names, workspaces)
+	if selectErr != nil {
+		return nil, selectErr
+	}
+
+	closure, closureErr := lf.DependencyClosure(selected, platformFromFlags(osFs, pnpmFlags))
+	if closureErr != nil {
+		return nil, closureErr
+	}
+
+	return closure, nil
+}
+
+// platformFromFlags returns the platform selected by the --os, --cpu and --libc pnpm flags.
+// Like pnpm, the current platform is used for anything not given and for "current".
+func platformFromFlags(osFs afero.Fs, pnpmFlags []string) lockfile.Platform {
+	current := lockfile.CurrentPlatform(osFs)
+	values := map[string][]string{}
+
+	for i := 0; i < len(pnpmFlags); i++ {
+		name, value, hasValue := strings.Cut(pnpmFlags[i], "=")
+
+		key := strings.TrimPrefix(name, "--")
+		if key != "os" && key != "cpu" && key != "libc" {
+			continue
+		}
+
+		if !hasValue && i+1 < len(pnpmFlags) {
+			i++
+			value = pnpmFlags[i]
+		}
+
+		values[key] = append(values[key], value)
+	}
+
+	resolve := func(key string, fallback []string) []string {
+		if len(values[key]) == 0 {
+			return fallback
+		}
+
+		var result []string
+		for _, v := range values[key] {
+			if v == "current" {
+				result = append(result, fallback...)
+			} else {
+				result = append(result, v)
+			}
+		}
+
+		return result
+	}
+
+	return lockfile.Platform{
+		OS:   resolve("os", current.OS),
+		CPU:  resolve("cpu", current.CPU),
+		Libc: resolve("libc", current.Libc),
+	}
+}
+
+// checkStoreCompleteness compares the packages indexed in the store with the expected ones.
+// Unexpected packages are reported as warnings; missing packages are an error if strict is set.
+func checkStoreCompleteness(
+	osFs afero.Fs,
+	logger logger.Logger,
+	storePath string,
+	expected map[string]string,
+	strict bool,
+) error {
+	indexed, listErr := store.ListIndexedPackages(osFs, storePath)
+	if listErr != nil {
+		return listErr
+	}
+
+	report := store.CheckCompleteness(indexed, expected)
+
+	if len(report.Unexpected) > 0 {
+		logger.Warnf(
+			"%d packages in the pnpm store are not required by the selected projects in pnpm-lock.yaml:\n  %s",
+			len(report.Unexpected),
+			strings.Join(report.Unexpected, "\n  "),
+		)
+	}
+
+	if len(report.Missing) == 0 {
+		logger.Infof("pnpm store contains all %d packages required by pnpm-lock.yaml", len(expected))
+		return nil
+	}
+
+	msg := fmt.Sprintf(
+		"%d packages required by pnpm-lock.yaml are missing from the pnpm store:\n  %s",
+		len(report.Missing),
+		strings.Join(report.Missing, "\n  "),
+	)
+	if strict {
+		return store_err.NewStoreError(&store_err.IncompleteStoreError{}, msg, nil)
+	}
+
+	logger.Warn(msg)
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-extras/cobraflags"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/fetcher"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+// maxReportedMismatches limits how many permission mismatches are listed in errors.
+const maxReportedMismatches = 10
+
+var convertCmd = &cobra.Command{
+	Use:   "convert [output-dir] [dest-dir]",
+	Short: "convert a fetcher output to another fetcher version without reinstalling",
+	Long: `convert a fetcher output to another fetcher version without reinstalling
+the input may be a fetcher v1/v2/v3 output directory (or a raw pnpm store).
+the output for --fetcher-version is written to dest-dir, which must not exist, and its hash is printed.
+conversions that cannot be lossless are refused`,
+	Args: cobra.ExactArgs(2), //nolint:mnd // input and destination
+	RunE: runConvert,
+}
+
+var convertFetcherVersionFlag = &cobraflags.IntFlag{
+	Name:         fetcherVersionFlagName,
+	ViperKey:     "convert." + fetcherVersionFlagName,
+	Usage:        "fetcher version to convert to\n" + fetcherVersionUsage,
+	Value:        0,
+	Required:     true,
+	ValidateFunc: validateFetcherVersion,
+}
+
+func init() {
+	convertFetcherVersionFlag.Register(convertCmd)
+}
+
+func runConvert(_ *cobra.Command, args []string) error {
+	fetcherVersion, err := convertFetcherVersionFlag.GetIntE()
+	if err != nil {
+		return err
+	}
+
+	f, err := fetcher.Get(fetcherVersion)
+	if err != nil {
+		return err
+	}
+
+	input, destDir := args[0], args[1]
+	osFs := afero.NewOsFs()
+
+	if _, statErr := osFs.Stat(destDir); !errors.Is(statErr, fs.ErrNotExist) {
+		return fmt.Errorf("destination %s already exists", destDir)
+	}
+
+	logger, err := newLogger(osFs, false)
+	if err != nil {
+		return err
+	}
+	defer logger.Close()
+
+	if checkErr := checkLosslessConversion(osFs, input, fetcherVersion); checkErr != nil {
+		return checkErr
+	}
+
+	workDir, err := afero.TempDir(osFs, "", "nix-prefetch-pnpm-convert-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = store.RemoveAll(osFs, workDir) }()
+
+	// The output is built next to destDir and renamed into place once it is complete,
+	// so a failed conversion does not leave a partial output behind.
+	stagingDir, err := afero.TempDir(osFs, filepath.Dir(destDir), "."+filepath.Base(destDir)+".tmp-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory next to %s: %w", destDir, err)
+	}
+	defer func() { _ = store.RemoveAll(osFs, stagingDir) }()
+
+	outDir := filepath.Join(stagingDir, "out")
+
+	// Outputs that are the normalized store itself are built directly in the output directory.
+	storePath := outDir
+	if !f.StoreIsOutput() {
+		storePath = filepath.Join(workDir, "store")
+	}
+
+	if prepareErr := prepareStoreCopy(osFs, logger, input, storePath); prepareErr != nil {
+		return prepareErr
+	}
+
+	stepLogger := logger.StepLogger(slog.LevelInfo, fmt.Sprintf("convert to fetcher v%d", fetcherVersion))
+	hash, convertErr := writeConvertedOutput(osFs, logger, storePath, outDir, f, stepProgress(stepLogger))
+	if convertErr != nil {
+		stepLogger.Fail(convertErr)
+		return convertErr
+	}
+	stepLogger.Done()
+
+	if renameErr := osFs.Rename(outDir, destDir); renameErr != nil {
+		return fmt.Errorf("failed to move the output to %s: %w", destDir, renameErr)
+	}
+	logger.Info(fmt.Sprintf("wrote fetcher v%d output", fetcherVersion), "path", destDir)
+
+	// Close logger (stop TUI) before printing hash directly to stdout.
+	_ = logger.Close()
+
+	fmt.Fprintln(os.Stdout, hash)
+
+	return nil
+}
+
+// writeConvertedOutput normalizes the store copy, writes the output to outDir and hashes it.
+func writeConvertedOutput(
+	osFs afero.Fs,
+	logger logger.Logger,
+	storePath string,
+	outDir string,
+	f fetcher.Fetcher,
+	progress store.ProgressFunc,
+) (string, error) {
+	if err := normalizeStore(osFs, logger, storePath, f, progress); err != nil {
+		return "", err
+	}
+
+	if !f.StoreIsOutput() {
+		//nolint:mnd // output directory permissions
+		if err := osFs.MkdirAll(outDir, 0o755); err != nil {
+			return "", fmt.Errorf("failed to create %s: %w", outDir, err)
+		}
+
+		if _, err := f.WriteOutput(osFs, storePath, outDir, progress); err != nil {
+			return "", err
+		}
+	}
+
+	hash, _, err := f.Hash(osFs, outDir, store.HashOptions{Progress: progress})
+	if err != nil {
+		return "", err
+	}
+
+	return hash, nil
+}
+
+// checkLosslessConversion refuses conversions whose result would not match a fresh fetch.
+// Fetcher v1 outputs keep the permissions pnpm wrote, where the executable bit always
+// matches the "-exec" file name suffix. If that does not hold, the permissions were
+// not preserved when the output was copied, and the v1 content cannot be trusted to
+// reproduce a v2+ output (or another v1 output) of the same dependencies.
+func checkLosslessConversion(osFs afero.Fs, input string, fetcherVersion int) error {
+	layout, layoutErr := store.DetectLayout(osFs, input)
+	if layoutErr != nil {
+		return layoutErr
+	}
+
+	if layout != store.LayoutOutputV1 && layout != store.LayoutRawStore {
+		return nil
+	}
+
+	mismatches, err := store.FindPermissionMismatches(osFs, input)
+	if err != nil {
+		return err
+	}
+
+	if len(mismatches) == 0 {
+		return nil
+	}
+
+	shown := mismatches
+	if len(shown) > maxReportedMismatches {
+		shown = shown[:maxReportedMismatches]
+	}
+
+	return fmt.Errorf(
+		"refusing lossy conversion of %s to fetcher v%d: permissions were not preserved "+
+			"(%d files have an executable bit that does not match their name), e.g.\n  %s",
+		input,
+		fetcherVersion,
+		len(mismatches),
+		strings.Join(shown, "\n  "),
+	)
+}
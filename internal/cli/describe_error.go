@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+// describeError renders err prefixed with the code of the outermost error with a
+// catalog entry, followed by its hint and where to find the long-form explanation.
+func describeError(err error) string {
+	var coded errcode.Coded
+	if !errors.As(err, &coded) {
+		return err.Error()
+	}
+
+	code := coded.Code()
+	msg := fmt.Sprintf("%s: %s", code, err.Error())
+
+	// Errors with their own hint already include it in the message.
+	if _, ok := coded.(interface{ Hint() string }); !ok {
+		if hint := code.Hint(); hint != "" {
+			msg += "\nhint: " + hint
+		}
+	}
+
+	return fmt.Sprintf("%s\nsee %s or run `%s explain %s`", msg, code.DocsURL(), programName, code)
+}
+
+// loggedError marks an error that was already logged by the command.
+type loggedError struct {
+	err error
+}
+
+func (e *loggedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *loggedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+	fetcher_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/fetcher/errors"
+	lockfile_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile/errors"
+	packagejson_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson/errors"
+	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+// Exit codes of the command per failure class. They are documented in README.md
+// and must not be renumbered.
+const (
+	ExitOK = 0
+	// ExitFailure is used for failures not covered below, e.g. invalid flags or arguments.
+	ExitFailure = 1
+	// ExitLockfileError is used if pnpm-lock.yaml or a package.json cannot be loaded or parsed.
+	ExitLockfileError = 2
+	// ExitPnpmError is used if pnpm cannot be found, its version cannot be determined or
+	// it does not support the lockfile version.
+	ExitPnpmError = 3
+	// ExitInstallError is used if pnpm install or a pre-install command fails.
+	ExitInstallError = 4
+	// ExitStoreError is used if the store cannot be verified, normalized, written or hashed.
+	ExitStoreError = 5
+	// ExitHashMismatch is used if the hash differs from --hash or between the runs of
+	// --check-reproducible.
+	ExitHashMismatch = 6
+)
+
+// ExitCode returns the exit code for an error returned by Execute. The outermost
+// error of a known failure class in the chain decides, so that e.g. a failed
+// pnpm install is not reported as a pnpm discovery error.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	if code, ok := exitCodeOf(err); ok {
+		return code
+	}
+
+	return ExitFailure
+}
+
+func exitCodeOf(err error) (int, bool) {
+	switch err.(type) {
+	case *hashMismatchError, *notReproducibleError:
+		return ExitHashMismatch, true
+	case *installError:
+		return ExitInstallError, true
+	case *fetcher_err.FailedToWriteOutputError:
+		return ExitStoreError, true
+	// The domain errors are told apart by the marker methods of their interfaces.
+	case lockfile_err.LockfileErrorIF, packagejson_err.PackageJSONErrorIF:
+		return ExitLockfileError, true
+	case pnpm_err.PnpmErrorIF:
+		return ExitPnpmError, true
+	case store_err.StoreErrorIF:
+		return ExitStoreError, true
+	}
+
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := e.Unwrap(); cause != nil {
+			return exitCodeOf(cause)
+		}
+	case interface{ Unwrap() []error }:
+		for _, cause := range e.Unwrap() {
+			if code, ok := exitCodeOf(cause); ok {
+				return code, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// hashMismatchError is returned if the computed hash differs from --hash.
+type hashMismatchError struct {
+	expected string
+	got      string
+}
+
+func (e *hashMismatchError) Error() string {
+	return fmt.Sprintf("hash mismatch:\n  expected %s\n  got %s", e.expected, e.got)
+}
+
+func (e *hashMismatchError) Code() errcode.Code {
+	return errcode.HashMismatch
+}
+
+// notReproducibleError is returned if the hashes of the runs of --check-reproducible differ.
+type notReproducibleError struct {
+	report string
+}
+
+func (e *notReproducibleError) Error() string {
+	return "hashes differ between runs\n" + e.report
+}
+
+func (e *notReproducibleError) Code() errcode.Code {
+	return errcode.NotReproducible
+}
+
+// installError marks a failure of pnpm install, which is otherwise indistinguishable
+// from a failure to run pnpm --version by its PnpmErrorIF type.
+type installError struct {
+	err error
+}
+
+func (e *installError) Error() string {
+	return "failed to install dependencies: " + e.err.Error()
+}
+
+func (e *installError) Unwrap() error {
+	return e.err
+}
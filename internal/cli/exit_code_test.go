@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	fetcher_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/fetcher/errors"
+	lockfile_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile/errors"
+	packagejson_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson/errors"
+	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+func Test_ExitCode(t *testing.T) {
+	t.Parallel()
+
+	pnpmErr := pnpm_err.NewPnpmError(&pnpm_err.PnpmNotFoundError{}, "pnpm not found", nil)
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "[正常系] エラーなし",
+			err:  nil,
+			want: ExitOK,
+		},
+		{
+			name: "[異常系] 分類されないエラー",
+			err:  errors.New("invalid argument"),
+			want: ExitFailure,
+		},
+		{
+			name: "[異常系] 分類されないドメインエラー",
+			err:  fetcher_err.NewFetcherError(&fetcher_err.UnsupportedVersionError{}, "", nil),
+			want: ExitFailure,
+		},
+		{
+			name: "[異常系] lockfileのエラー",
+			err:  lockfile_err.NewLockfileError(&lockfile_err.FailedToParseError{}, "", nil),
+			want: ExitLockfileError,
+		},
+		{
+			name: "[異常系] package.jsonのエラー",
+			err:  packagejson_err.NewPackageJSONError(&packagejson_err.InvalidPackageManagerError{}, "", nil),
+			want: ExitLockfileError,
+		},
+		{
+			name: "[異常系] pnpmのエラー",
+			err:  pnpmErr,
+			want: ExitPnpmError,
+		},
+		{
+			name: "[異常系] pnpm installの失敗",
+			err:  &installError{err: pnpm_err.NewPnpmError(&pnpm_err.FailedToExecuteError{}, "", nil)},
+			want: ExitInstallError,
+		},
+		{
+			name: "[異常系] ストアのエラー",
+			err:  store_err.NewStoreError(&store_err.IncompleteStoreError{}, "", nil),
+			want: ExitStoreError,
+		},
+		{
+			name: "[異常系] 出力の書き込みの失敗",
+			err:  fetcher_err.NewFetcherError(&fetcher_err.FailedToWriteOutputError{}, "", nil),
+			want: ExitStoreError,
+		},
+		{
+			name: "[異常系] ハッシュの不一致",
+			err:  &hashMismatchError{expected: "sha256-a", got: "sha256-b"},
+			want: ExitHashMismatch,
+		},
+		{
+			name: "[異常系] 実行間でハッシュが異なる",
+			err:  &notReproducibleError{},
+			want: ExitHashMismatch,
+		},
+		{
+			name: "[異常系] ラップされたドメインエラー",
+			err:  &loggedError{err: fmt.Errorf("failed to select pnpm: %w", pnpmErr)},
+			want: ExitPnpmError,
+		},
+		{
+			name: "[異常系] 別の分類のエラーを原因に持つエラーは外側で決まる",
+			err: fmt.Errorf("failed to fetch: %w", &installError{
+				err: store_err.NewStoreError(&store_err.FailedToHashError{}, "", pnpmErr),
+			}),
+			want: ExitInstallError,
+		},
+		{
+			name: "[異常系] 結合されたエラーは最初に分類できたもので決まる",
+			err:  errors.Join(errors.New("cleanup failed"), &hashMismatchError{}, pnpmErr),
+			want: ExitHashMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [code]",
+	Short: "explain an error code",
+	Long: `explain an error code
+prints the long-form explanation and the hint of an error code such as NPPD-E020; the NPPD- prefix may be omitted.
+all error codes are listed if no code is given`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExplain,
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0) //nolint:mnd // column padding
+		for _, e := range errcode.All() {
+			fmt.Fprintf(w, "%s\t%s\n", e.Code, e.Title)
+		}
+		return w.Flush()
+	}
+
+	entry, ok := errcode.Lookup(args[0])
+	if !ok {
+		return fmt.Errorf("unknown error code %s; run %s without arguments to list all codes", args[0], cmd.CommandPath())
+	}
+
+	fmt.Fprint(cmd.OutOrStdout(), entry.Text())
+
+	return nil
+}
@@ -2,49 +2,195 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/go-extras/cobraflags"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/fetcher"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
 )
 
 const (
-	fetcherVersionFlagName    = "fetcher-version"
-	pnpmPathFlagName          = "pnpm-path"
-	workspaceFlagName         = "workspace"
-	pnpmFlagFlagName          = "pnpm-flag"
-	preInstallCommandFlagName = "pre-install-command"
-	hashFlagName              = "hash"
-	quietFlagName             = "quiet"
+	fetcherVersionFlagName       = "fetcher-version"
+	pnpmPathFlagName             = "pnpm-path"
+	strictPackageManagerFlagName = "strict-package-manager"
+	nodePathFlagName             = "node-path"
+	sandboxFlagName              = "sandbox"
+	passEnvFlagName              = "pass-env"
+	workspaceFlagName            = "workspace"
+	pnpmFlagFlagName             = "pnpm-flag"
+	preInstallCommandFlagName    = "pre-install-command"
+	hashFlagName                 = "hash"
+	quietFlagName                = "quiet"
+	checkReproducibleFlagName    = "check-reproducible"
+	varyEnvironmentFlagName      = "vary-environment"
+	manifestFlagName             = "manifest"
+	noVerifyFlagName             = "no-verify"
+	strictFlagName               = "strict"
+	logFileFlagName              = "log-file"
+	logLevelFlagName             = "log-level"
+	logFormatFlagName            = "log-format"
+	verboseFlagName              = "verbose"
+	annotateFileFlagName         = "annotate-file"
+)
+
+// defaultReproducibleRuns is the number of runs of --check-reproducible without a value.
+const defaultReproducibleRuns = 2
+
+var fetcherVersionUsage = buildFetcherVersionUsage()
+
+const manifestUsage = `write a JSON manifest of the hashed tree to the given file
+lists every path with its type, size, executable bit, sha256 and symlink target
+in the format of Nix's .ls NAR listings`
+
+// buildFetcherVersionUsage lists the registered fetcher versions for --help.
+func buildFetcherVersionUsage() string {
+	var b strings.Builder
+
+	b.WriteString("pnpm fetcher version\nAvailable versions:")
+	for _, f := range fetcher.All() {
+		fmt.Fprintf(&b, "\n\t%d: %s", f.Version(), f.Description())
+	}
+
+	return b.String()
+}
+
+// verbosity is the number of -v flags. cobraflags has no counting flags, so
+// --verbose is registered on the persistent flag set directly in init.
+var verbosity int
+
+const verboseUsage = "show more messages; repeat to lower the level further (-v shows debug messages)"
+
+func validateLogLevel(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	if _, err := logger.ParseLevel(value); err != nil {
+		return fmt.Errorf("invalid --%s: %w", logLevelFlagName, err)
+	}
+
+	return nil
+}
+
+func validateLogFormat(value string) error {
+	if _, err := logger.ParseFormat(value); err != nil {
+		return fmt.Errorf("invalid --%s: %w", logFormatFlagName, err)
+	}
+
+	return nil
+}
+
+// Values of --sandbox.
+const (
+	sandboxNone  = "none"
+	sandboxBwrap = "bwrap"
 )
 
+func validateSandbox(value string) error {
+	switch value {
+	case sandboxNone, sandboxBwrap:
+		return nil
+	}
+
+	return fmt.Errorf(
+		`"%s" is invalid value for --%s flag. (expected: %s or %s)`,
+		value,
+		sandboxFlagName,
+		sandboxNone,
+		sandboxBwrap,
+	)
+}
+
+func validatePassEnv(names []string) error {
+	for _, name := range names {
+		if name == "" || strings.Contains(name, "=") {
+			return fmt.Errorf(`"%s" is invalid value for --%s flag. (expected: a variable name)`, name, passEnvFlagName)
+		}
+	}
+	return nil
+}
+
+func validateFetcherVersion(value int) error {
+	if _, err := fetcher.Get(value); err != nil {
+		return fmt.Errorf(
+			`"%d" is invalid value for --%s flag. (expected: %s)`,
+			value,
+			fetcherVersionFlagName,
+			fetcher.VersionList(),
+		)
+	}
+	return nil
+}
+
+func validateReproducibleRuns(value int) error {
+	if value != 0 && value < defaultReproducibleRuns {
+		return fmt.Errorf(
+			`"%d" is invalid value for --%s flag. (expected: %d or more)`,
+			value,
+			checkReproducibleFlagName,
+			defaultReproducibleRuns,
+		)
+	}
+	return nil
+}
+
 var (
 	fetcherVersionFlag = &cobraflags.IntFlag{
-		Name: fetcherVersionFlagName,
-		Usage: `pnpm fetcher version
-Aviailable versions:
-	1: First version. Here to preserve backwards compatibility
-	2: Ensure consistent permissions. See https://github.com/NixOS/nixpkgs/pull/422975
-	3: Build a reproducible tarball. See https://github.com/NixOS/nixpkgs/pull/469950`,
-		Value:    0,
-		Required: true,
-		ValidateFunc: func(value int) error {
-			if value < 1 || value > 3 {
-				return fmt.Errorf(
-					`"%d" is invalid value for --%s flag. (expected: 1, 2, or 3)`,
-					value,
-					fetcherVersionFlagName,
-				)
-			}
-			return nil
-		},
-	}
-
-	pnpmPathFlag = &cobraflags.StringFlag{
-		Name:     pnpmPathFlagName,
-		Usage:    "path to the pnpm executable",
+		Name:         fetcherVersionFlagName,
+		Usage:        fetcherVersionUsage,
+		Value:        0,
+		Required:     true,
+		ValidateFunc: validateFetcherVersion,
+	}
+
+	pnpmPathFlag = &cobraflags.StringSliceFlag{
+		Name: pnpmPathFlagName,
+		Usage: `path to the pnpm executable, or a directory to search for pnpm (can be specified multiple times)
+a directory is searched for pnpm, bin/pnpm and */bin/pnpm; without this flag, every pnpm on PATH is considered
+among them, the pnpm of the version pinned by the packageManager field of package.json is preferred,
+then one writing the lockfile version of pnpm-lock.yaml, then the earlier one`,
+		Value:    []string{},
+		Required: false,
+	}
+
+	strictPackageManagerFlag = &cobraflags.BoolFlag{
+		Name: strictPackageManagerFlagName,
+		Usage: `fail if the major or minor version of pnpm differs from the packageManager field
+of package.json, or if the field is invalid (by default, this is a warning)`,
+		Value:    false,
+		Required: false,
+	}
+
+	nodePathFlag = &cobraflags.StringFlag{
+		Name: nodePathFlagName,
+		Usage: `path to the Node.js executable to run pnpm with (default: node on PATH)
+it is put first on PATH of pnpm and the pre-install commands`,
 		Value:    "",
 		Required: false,
 	}
 
+	sandboxFlag = &cobraflags.StringFlag{
+		Name: sandboxFlagName,
+		Usage: `run pnpm and the pre-install commands in a sandbox: none or bwrap
+bwrap uses bubblewrap to make the filesystem read-only except for the source directory,
+the temporary pnpm store and temporary directories, and to hide HOME`,
+		Value:        sandboxNone,
+		Required:     false,
+		ValidateFunc: validateSandbox,
+	}
+
+	passEnvFlag = &cobraflags.StringSliceFlag{
+		Name: passEnvFlagName,
+		Usage: `pass the environment variable to pnpm and the pre-install commands (can be specified multiple times)
+by default they run with a cleared environment: PATH with pnpm, node and the standard tools,
+a temporary HOME, NIX_NPM_REGISTRY as the registry, SSL certificate variables and TMPDIR
+PATH is appended to that PATH; other variables override the defaults`,
+		Value:        []string{},
+		Required:     false,
+		ValidateFunc: validatePassEnv,
+	}
+
 	workspaceFlag = &cobraflags.StringSliceFlag{
 		Name: workspaceFlagName,
 		Usage: `filter to restrict to specific workspaces (can be specified multiple times)
@@ -91,4 +237,81 @@ equivalent to nixpkgs' prePnpmInstall, runs after pnpm config is set
 		Value:    false,
 		Required: false,
 	}
+
+	checkReproducibleFlag = &cobraflags.IntFlag{
+		Name: checkReproducibleFlagName,
+		Usage: `run the install, normalize and hash steps N times in independent temporary stores
+and fail with a file-level report if the hashes differ (N defaults to 2)
+  e.g. nix-prefetch-pnpm-deps --fetcher-version 3 --check-reproducible=3 ./source-dir`,
+		Value:        0,
+		Required:     false,
+		ValidateFunc: validateReproducibleRuns,
+	}
+
+	varyEnvironmentFlag = &cobraflags.BoolFlag{
+		Name:     varyEnvironmentFlagName,
+		Usage:    "with --check-reproducible, shuffle the order of pnpm config settings and use a separate TMPDIR per run",
+		Value:    false,
+		Required: false,
+	}
+
+	manifestFlag = &cobraflags.StringFlag{
+		Name:     manifestFlagName,
+		Usage:    manifestUsage,
+		Value:    "",
+		Required: false,
+	}
+
+	noVerifyFlag = &cobraflags.BoolFlag{
+		Name: noVerifyFlagName,
+		Usage: `skip verifying the pnpm store before hashing
+by default, every content file is re-hashed against the store index files
+and the index files are cross-checked with the integrities in pnpm-lock.yaml`,
+		Value:    false,
+		Required: false,
+	}
+
+	logFileFlag = &cobraflags.StringFlag{
+		Name: logFileFlagName,
+		Usage: `also write all messages at debug level and the complete output of pnpm
+with timestamps to the given file, regardless of --quiet and the terminal`,
+		Value:      "",
+		Required:   false,
+		Persistent: true,
+	}
+
+	annotateFileFlag = &cobraflags.StringFlag{
+		Name: annotateFileFlagName,
+		Usage: `file that declares the expected --hash, e.g. package.nix
+on a hash mismatch in CI, the error annotation points at the line containing the hash`,
+		Value:    "",
+		Required: false,
+	}
+
+	logLevelFlag = &cobraflags.StringFlag{
+		Name:         logLevelFlagName,
+		Usage:        "minimum level of the messages to show: debug, info, warn or error (default info, error with --quiet)",
+		Value:        "",
+		Required:     false,
+		Persistent:   true,
+		ValidateFunc: validateLogLevel,
+	}
+
+	logFormatFlag = &cobraflags.StringFlag{
+		Name: logFormatFlagName,
+		Usage: `format of the messages: text, json or logfmt
+json and logfmt write one record per line with the phase, path, exit code and duration as attributes`,
+		Value:        string(logger.FormatText),
+		Required:     false,
+		Persistent:   true,
+		ValidateFunc: validateLogFormat,
+	}
+
+	strictFlag = &cobraflags.BoolFlag{
+		Name: strictFlagName,
+		Usage: `fail if packages required by pnpm-lock.yaml are missing from the pnpm store
+the required packages respect --workspace selectors and the --os/--cpu/--libc pnpm flags`,
+		Value:    false,
+		Required: false,
+	}
 )
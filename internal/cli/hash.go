@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/go-extras/cobraflags"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/fetcher"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+var hashCmd = &cobra.Command{
+	Use:   "hash [store-dir|output-dir|tarball]",
+	Short: "compute the hash of an existing pnpm store or fetcher output",
+	Long: `compute the hash of an existing pnpm store or fetcher output
+the input may be a raw pnpm store, a fetcher v1/v2/v3 output directory or a pnpm-store.tar.zst file.
+the input is copied to a temporary directory before normalization and is never modified`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHash,
+}
+
+var hashFetcherVersionFlag = &cobraflags.IntFlag{
+	Name:         fetcherVersionFlagName,
+	ViperKey:     "hash." + fetcherVersionFlagName,
+	Usage:        fetcherVersionUsage,
+	Value:        0,
+	Required:     true,
+	ValidateFunc: validateFetcherVersion,
+}
+
+var hashManifestFlag = &cobraflags.StringFlag{
+	Name:     manifestFlagName,
+	ViperKey: "hash." + manifestFlagName,
+	Usage:    manifestUsage,
+	Value:    "",
+	Required: false,
+}
+
+func init() {
+	hashFetcherVersionFlag.Register(hashCmd)
+	hashManifestFlag.Register(hashCmd)
+}
+
+func runHash(_ *cobra.Command, args []string) error {
+	fetcherVersion, err := hashFetcherVersionFlag.GetIntE()
+	if err != nil {
+		return err
+	}
+
+	f, err := fetcher.Get(fetcherVersion)
+	if err != nil {
+		return err
+	}
+
+	osFs := afero.NewOsFs()
+
+	logger, err := newLogger(osFs, false)
+	if err != nil {
+		return err
+	}
+	defer logger.Close()
+
+	workDir, err := afero.TempDir(osFs, "", "nix-prefetch-pnpm-hash-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = store.RemoveAll(osFs, workDir) }()
+
+	storePath := filepath.Join(workDir, "store")
+	if prepareErr := prepareStoreCopy(osFs, logger, args[0], storePath); prepareErr != nil {
+		return prepareErr
+	}
+
+	hashStepLogger := logger.StepLogger(slog.LevelInfo, "compute NAR hash")
+	hash, hashErr := computeStoreHash(
+		osFs,
+		logger,
+		storePath,
+		f,
+		hashManifestFlag.GetString(),
+		stepProgress(hashStepLogger),
+	)
+	if hashErr != nil {
+		hashStepLogger.Fail(hashErr)
+		return hashErr
+	}
+	hashStepLogger.Done()
+
+	// Close logger (stop TUI) before printing hash directly to stdout.
+	_ = logger.Close()
+
+	fmt.Fprintln(os.Stdout, hash)
+
+	return nil
+}
+
+// prepareStoreCopy detects the layout of input and writes a writable copy of
+// the pnpm store it contains to storePath. Tarballs are extracted to a temporary
+// directory first, and the .fetcher-version of v2 outputs is dropped so that it can be rewritten.
+func prepareStoreCopy(osFs afero.Fs, logger logger.Logger, input string, storePath string) error {
+	layout, layoutErr := store.DetectLayout(osFs, input)
+	if layoutErr != nil {
+		return layoutErr
+	}
+	logger.Info(fmt.Sprintf("detected %s", layout), "path", input)
+
+	src := input
+	if layout == store.LayoutTarball || layout == store.LayoutOutputV3 {
+		tarballPath, err := resolveTarballPath(osFs, input)
+		if err != nil {
+			return err
+		}
+
+		// Unpacked stores keep their read-only permissions, so copy them once more below.
+		unpackDir, tmpErr := afero.TempDir(osFs, "", "nix-prefetch-pnpm-unpack-")
+		if tmpErr != nil {
+			return fmt.Errorf("failed to create temp directory: %w", tmpErr)
+		}
+		defer func() { _ = store.RemoveAll(osFs, unpackDir) }()
+
+		src = filepath.Join(unpackDir, "store")
+		if unpackErr := store.Unpack(osFs, tarballPath, src); unpackErr != nil {
+			return unpackErr
+		}
+	}
+
+	if copyErr := store.Copy(osFs, src, storePath); copyErr != nil {
+		return copyErr
+	}
+
+	fetcherVersionPath := filepath.Join(storePath, store.FetcherVersionFileName)
+	if exists, _ := afero.Exists(osFs, fetcherVersionPath); exists {
+		if err := osFs.Remove(fetcherVersionPath); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", store.FetcherVersionFileName, err)
+		}
+	}
+	logger.Debugf("copied pnpm store to %s", storePath)
+
+	return nil
+}
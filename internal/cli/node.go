@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm"
+)
+
+// initNode returns the Node.js at nodePath, or the first one on PATH if it is empty.
+func initNode(osFs afero.Fs, logger logger.Logger, nodePath string) (*pnpm.Node, error) {
+	if nodePath != "" {
+		n, nodeErr := pnpm.NewNode(osFs, logger, nodePath)
+		return n, nodeErr
+	}
+
+	n, nodeErr := pnpm.NodeWithPathEnvVar(osFs, logger)
+	return n, nodeErr
+}
+
+// interpreterNode returns the node of the shebang line of the pnpm script p, which
+// pnpm is run with if no Node.js was found.
+func interpreterNode(osFs afero.Fs, logger logger.Logger, p *pnpm.Pnpm) (*pnpm.Node, error) {
+	interpreter := p.Interpreter()
+	if interpreter == "" {
+		return nil, errors.New("its shebang does not name a node executable")
+	}
+
+	n, err := pnpm.NewNode(osFs, logger, interpreter)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debugf("pnpm at %s runs with the Node.js %s of its shebang at %s", p.Path(), n.Version(), n.Path())
+
+	return n, nil
+}
+
+// checkNode checks node against the engines.node requirement of pnpm, which is an
+// error if not satisfied, and against the engines.node field of project, which is
+// only warned about as pnpm itself only warns about it unless engine-strict is set.
+func checkNode(logger logger.Logger, node *pnpm.Node, p *pnpm.Pnpm, project *packagejson.PackageJSON) error {
+	if p.BundlesNode() {
+		logger.Debugf("skipping Node.js checks, as pnpm at %s bundles Node.js", p.Path())
+		return nil
+	}
+
+	if err := pnpm.CheckNode(node, p); err != nil {
+		return err
+	}
+
+	if project == nil || project.Engines["node"] == "" {
+		return nil
+	}
+
+	required, parseErr := common.ParseRange(project.Engines["node"])
+	if parseErr != nil {
+		logger.Warnf("skipping engines.node check of package.json: %v", parseErr)
+		return nil
+	}
+
+	if !required.Contains(node.Version()) {
+		logger.Warnf(
+			"package.json requires Node.js %s in its engines field, but Node.js %s at %s is provided; pass --%s",
+			required,
+			node.Version(),
+			node.Path(),
+			nodePathFlagName,
+		)
+		return nil
+	}
+
+	logger.Debugf("Node.js %s satisfies engines.node %s of package.json", node.Version(), required)
+	return nil
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm"
+	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
+)
+
+// loadProjectPackageJSON returns the package.json in srcPath, or nil if there is none.
+func loadProjectPackageJSON(osFs afero.Fs, srcPath string) (*packagejson.PackageJSON, error) {
+	pkg, loadErr := packagejson.Load(osFs, filepath.Join(srcPath, packagejson.FileName))
+	if errors.Is(loadErr, fs.ErrNotExist) {
+		return nil, nil //nolint:nilnil // package.json is optional for the checks of its fields
+	}
+	if loadErr != nil {
+		return nil, loadErr
+	}
+
+	return pkg, nil
+}
+
+// readPackageManager returns the packageManager field of project, or nil if project
+// is nil or has no packageManager field.
+func readPackageManager(project *packagejson.PackageJSON) (*packagejson.PackageManager, error) {
+	if project == nil || project.PackageManager == "" {
+		return nil, nil //nolint:nilnil // the packageManager field is optional
+	}
+
+	pm, parseErr := packagejson.ParsePackageManager(project.PackageManager)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	return pm, nil
+}
+
+// checkPackageManager compares the version of pnpm with the packageManager field.
+// A mismatch of the major or minor version is an error if strict, and a warning otherwise.
+func checkPackageManager(logger logger.Logger, p *pnpm.Pnpm, pm *packagejson.PackageManager, strict bool) error {
+	if pm == nil {
+		logger.Debug("package.json has no packageManager field")
+		return nil
+	}
+
+	v, err := p.SemVer()
+	if err != nil {
+		return err
+	}
+
+	msg := pnpm.PackageManagerMismatch(v, pm)
+	if msg == "" {
+		logger.Debugf("pnpm %s matches the packageManager field %s", v, pm)
+		return nil
+	}
+
+	if strict {
+		return pnpm_err.NewPnpmError(&pnpm_err.PackageManagerMismatchError{}, msg, nil)
+	}
+
+	logger.Warnf("%s; pass --%s to make this an error", msg, strictPackageManagerFlagName)
+	return nil
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+// checkReproducible runs the install-normalize-hash pipeline runs times in independent
+// temporary stores and returns the hash if all runs agree.
+// If varyEnvironment is set, each run applies the pnpm config in a different order
+// and uses its own TMPDIR. If the hashes differ, the returned error contains a
+// file-level report of the differences to the first run.
+func checkReproducible(
+	osFs afero.Fs,
+	logger logger.Logger,
+	p *pnpm.Pnpm,
+	fetchOpts fetchOptions,
+	runs int,
+	varyEnvironment bool,
+) (string, error) {
+	storePaths := make([]string, 0, runs)
+	hashes := make([]string, 0, runs)
+
+	defer func() {
+		for _, storePath := range storePaths {
+			_ = store.RemoveAll(osFs, storePath)
+		}
+	}()
+
+	for i := range runs {
+		logger.Infof("reproducibility check: run %d of %d", i+1, runs)
+
+		opts := fetchOpts
+		if varyEnvironment {
+			tmpDir, err := afero.TempDir(osFs, "", "nix-prefetch-pnpm-tmp-")
+			if err != nil {
+				return "", fmt.Errorf("failed to create temp directory: %w", err)
+			}
+			defer func() { _ = store.RemoveAll(osFs, tmpDir) }()
+
+			opts.installOpts.ShuffleConfig = true
+			opts.installOpts.TempDir = tmpDir
+		}
+
+		storePath, hash, err := fetchAndHash(osFs, logger, p, opts)
+		if storePath != "" {
+			storePaths = append(storePaths, storePath)
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("run %d: %w", i+1, err)
+		}
+
+		logger.Infof("run %d: %s", i+1, hash)
+		hashes = append(hashes, hash)
+	}
+
+	return compareRuns(osFs, storePaths, hashes)
+}
+
+// compareRuns returns the hash of the runs if they all agree, or a notReproducibleError
+// with the report of the differences otherwise.
+func compareRuns(osFs afero.Fs, storePaths []string, hashes []string) (string, error) {
+	for _, hash := range hashes[1:] {
+		if hash != hashes[0] {
+			return "", &notReproducibleError{report: reproducibilityReport(osFs, storePaths, hashes)}
+		}
+	}
+
+	return hashes[0], nil
+}
+
+// reproducibilityReport describes the hashes of all runs and the file-level differences
+// between the normalized store of the first run and every run with a different hash.
+func reproducibilityReport(osFs afero.Fs, storePaths []string, hashes []string) string {
+	var b strings.Builder
+
+	for i, hash := range hashes {
+		fmt.Fprintf(&b, "  run %d: %s\n", i+1, hash)
+	}
+
+	base, baseErr := store.List(osFs, storePaths[0])
+
+	for i := 1; i < len(hashes); i++ {
+		if hashes[i] == hashes[0] {
+			continue
+		}
+
+		fmt.Fprintln(&b)
+
+		entries, listErr := store.List(osFs, storePaths[i])
+		if baseErr != nil || listErr != nil {
+			fmt.Fprintf(&b, "failed to list stores of run 1 and run %d: %v\n", i+1, errors.Join(baseErr, listErr))
+			continue
+		}
+
+		printStoreDiff(&b, "run 1", fmt.Sprintf("run %d", i+1), base, entries)
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
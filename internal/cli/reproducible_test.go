@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+func Test_compareRuns(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"v10/files/00/aaaa":      "module.exports = 1",
+		"v10/files/11/bbbb-exec": "#!/bin/sh",
+	}
+
+	tests := []struct {
+		name        string
+		changes     []map[string]string // files changed in each run after the first
+		wantErr     bool
+		wantReports []string
+	}{
+		{
+			name:    "[正常系] 全ての実行で同一のストア",
+			changes: []map[string]string{{}, {}},
+		},
+		{
+			name:        "[異常系] 1ファイルだけ異なるストア",
+			changes:     []map[string]string{{}, {"v10/files/00/aaaa": "module.exports = 2"}},
+			wantErr:     true,
+			wantReports: []string{"run 3", "v10/files/00/aaaa"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			afs := afero.NewMemMapFs()
+
+			var storePaths, hashes []string
+
+			for i, changes := range append([]map[string]string{{}}, tt.changes...) {
+				storePath := fmt.Sprintf("/run%d", i+1)
+				for name, content := range files {
+					if changed, ok := changes[name]; ok {
+						content = changed
+					}
+					afero.WriteFile(afs, storePath+"/"+name, []byte(content), 0o644)
+				}
+
+				hash, hashErr := store.Hash(afs, storePath)
+				if hashErr != nil {
+					t.Fatalf("Hash() error = %v", hashErr)
+				}
+
+				storePaths = append(storePaths, storePath)
+				hashes = append(hashes, hash)
+			}
+
+			got, gotErr := compareRuns(afs, storePaths, hashes)
+
+			var notReproducible *notReproducibleError
+			if errors.As(gotErr, &notReproducible) != tt.wantErr {
+				t.Fatalf("compareRuns() error = %v, wantErr %t", gotErr, tt.wantErr)
+			}
+
+			if !tt.wantErr {
+				if got != hashes[0] {
+					t.Errorf("compareRuns() = %q, want %q", got, hashes[0])
+				}
+
+				return
+			}
+
+			for _, want := range tt.wantReports {
+				if !strings.Contains(notReproducible.report, want) {
+					t.Errorf("compareRuns() report does not contain %q:\n%s", want, notReproducible.report)
+				}
+			}
+
+			if strings.Contains(notReproducible.report, "bbbb-exec") {
+				t.Errorf("compareRuns() report contains an unchanged file:\n%s", notReproducible.report)
+			}
+		})
+	}
+}
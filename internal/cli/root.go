@@ -1,111 +1,283 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/fetcher"
 	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile"
 	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson"
 	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm"
+	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
 	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
 )
 
+// programName is the name of the executable.
+const programName = "nix-prefetch-pnpm-deps"
+
 var rootCmd = &cobra.Command{
-	Use:   "nix-prefetch-pnpm-deps [source-dir]",
+	Use:   programName + " [source-dir]",
 	Short: "prefetch dependencies for pnpm",
 	Args:  cobra.ExactArgs(1),
 	RunE:  run,
+	// Errors are printed by Execute, with their error code and hint.
+	SilenceErrors: true,
 }
 
 func init() {
 	fetcherVersionFlag.Register(rootCmd)
 	pnpmPathFlag.Register(rootCmd)
+	strictPackageManagerFlag.Register(rootCmd)
+	nodePathFlag.Register(rootCmd)
+	sandboxFlag.Register(rootCmd)
+	passEnvFlag.Register(rootCmd)
 	workspaceFlag.Register(rootCmd)
 	pnpmFlagFlag.Register(rootCmd)
 	preInstallCommandFlag.Register(rootCmd)
 	hashFlag.Register(rootCmd)
 	quietFlag.Register(rootCmd)
+	manifestFlag.Register(rootCmd)
+	noVerifyFlag.Register(rootCmd)
+	strictFlag.Register(rootCmd)
+	checkReproducibleFlag.Register(rootCmd)
+	varyEnvironmentFlag.Register(rootCmd)
+	logFileFlag.Register(rootCmd)
+	annotateFileFlag.Register(rootCmd)
+	logLevelFlag.Register(rootCmd)
+	logFormatFlag.Register(rootCmd)
+	rootCmd.PersistentFlags().CountVarP(&verbosity, verboseFlagName, "v", verboseUsage)
+	rootCmd.Flags().Lookup(checkReproducibleFlagName).NoOptDefVal = strconv.Itoa(defaultReproducibleRuns)
+
+	rootCmd.AddCommand(unpackCmd)
+	rootCmd.AddCommand(verifyTarballCmd)
+	rootCmd.AddCommand(hashCmd)
+	rootCmd.AddCommand(convertCmd)
+	rootCmd.AddCommand(storeDiffCmd)
+	rootCmd.AddCommand(explainCmd)
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	err := rootCmd.Execute()
+
+	var logged *loggedError
+	if err != nil && !errors.As(err, &logged) {
+		fmt.Fprintln(os.Stderr, "Error: "+describeError(err))
+	}
+
+	return err
 }
 
-func initPnpm(osFs afero.Fs, logger logger.Logger, pnpmPath string) (*pnpm.Pnpm, error) {
-	if pnpmPath != "" {
-		p, pnpmErr := pnpm.New(osFs, logger, pnpmPath)
-		return p, pnpmErr
+// newLogger creates the logger of a command from --log-level, -v and --log-format,
+// also writing to --log-file if it is set. quiet is the default level of --quiet.
+func newLogger(osFs afero.Fs, quiet bool) (logger.Logger, error) {
+	level, err := resolveLogLevel(quiet)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := logger.ParseFormat(logFormatFlag.GetString())
+	if err != nil {
+		return nil, err
+	}
+
+	opts := logger.Options{Format: format}
+
+	if path := logFileFlag.GetString(); path != "" {
+		//nolint:mnd // log file permissions
+		f, openErr := osFs.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+		if openErr != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", openErr)
+		}
+		opts.LogFile = f
+	}
+
+	return logger.New(level, opts), nil
+}
+
+// resolveLogLevel returns the --log-level, or info (error if quiet) if it is not set,
+// lowered by one level for every -v.
+func resolveLogLevel(quiet bool) (logger.LogLevel, error) {
+	level := slog.LevelInfo
+	if quiet {
+		level = slog.LevelError
+	}
+
+	if name := logLevelFlag.GetString(); name != "" {
+		parsed, err := logger.ParseLevel(name)
+		if err != nil {
+			return 0, err
+		}
+		level = parsed
+	}
+
+	// slog levels are 4 apart; debug is the lowest level used.
+	const levelStep = slog.LevelInfo - slog.LevelDebug
+	level = max(level-slog.Level(verbosity)*levelStep, slog.LevelDebug)
+
+	return level, nil
+}
+
+// initPnpm selects the pnpm installation for the lockfile and the packageManager
+// field pm among the pnpm executables and directories of --pnpm-path, or among
+// every pnpm on PATH if it is not set. Corepack shims are resolved to the pnpm they
+// run; unresolved shims are an error if strict. pnpm is run with node. If Node.js
+// was not found (nodeErr), only pnpm binaries bundling Node.js and pnpm scripts whose
+// shebang names an existing node are considered; the latter run with that node.
+//
+//nolint:cyclop // discovery, shim resolution and selection are kept together
+func initPnpm(
+	osFs afero.Fs,
+	logger logger.Logger,
+	pnpmPaths []string,
+	node *pnpm.Node,
+	nodeErr error,
+	l *lockfile.Lockfile,
+	pm *packagejson.PackageManager,
+	strict bool,
+) (*pnpm.Pnpm, error) {
+	discovered, discoverErr := pnpm.Discover(osFs, logger, pnpmPaths)
+	if discoverErr != nil {
+		return nil, discoverErr
+	}
+
+	pnpms := make([]*pnpm.Pnpm, 0, len(discovered))
+	for _, p := range discovered {
+		resolved, shimErr := pnpm.ResolveShim(osFs, logger, p, pm)
+		if shimErr != nil {
+			if strict {
+				return nil, shimErr
+			}
+			logger.Warnf("%v; it may download pnpm, pass --%s to make this an error", shimErr, strictPackageManagerFlagName)
+			resolved = p
+		}
+
+		n := node
+		if nodeErr != nil && !resolved.BundlesNode() {
+			own, ownErr := interpreterNode(osFs, logger, resolved)
+			if ownErr != nil {
+				logger.Debugf("skipping pnpm at %s, as it needs Node.js: %v", resolved.Path(), ownErr)
+				continue
+			}
+			n = own
+		}
+
+		resolved.SetNode(n)
+		pnpms = append(pnpms, resolved)
+	}
+
+	if len(pnpms) == 0 {
+		return nil, nodeErr
+	}
+
+	lockfileVer, lockfileVerErr := l.Version()
+	if lockfileVerErr != nil {
+		return nil, lockfileVerErr
+	}
+
+	p, selectErr := pnpm.Select(logger, pnpms, lockfileVer, pm)
+	if selectErr != nil {
+		return nil, selectErr
 	}
 
-	p, pnpmErr := pnpm.WithPathEnvVar(osFs, logger)
-	return p, pnpmErr
+	return p, nil
+}
+
+// logError logs err with its error code and hint, and returns it marked as logged,
+// so that Execute only has to derive the exit code.
+func logError(logger logger.Logger, err error) error {
+	logger.Error(describeError(err))
+	return &loggedError{err: err}
 }
 
-// validateLockfileVersion verifies lockfile version is compatible with pnpm version.
-func validateLockfileVersion(l *lockfile.Lockfile, p *pnpm.Pnpm) error {
-	lockfileVer, lockfileVerErr := l.MajorVersion()
+// checkLockfileCompatibility checks pnpm-lock.yaml against the lockfile versions and
+// settings supported by pnpm. Lockfiles pnpm cannot install from are an error, while
+// lockfiles pnpm would convert and settings pnpm would refuse are warned about.
+func checkLockfileCompatibility(
+	osFs afero.Fs,
+	logger logger.Logger,
+	srcPath string,
+	l *lockfile.Lockfile,
+	p *pnpm.Pnpm,
+) error {
+	lockfileVer, lockfileVerErr := l.Version()
 	if lockfileVerErr != nil {
 		return lockfileVerErr
 	}
 
-	pnpmVer, pnpmVerErr := p.MajorVersion()
+	pnpmVer, pnpmVerErr := p.SemVer()
 	if pnpmVerErr != nil {
 		return pnpmVerErr
 	}
-	if lockfileVer > pnpmVer {
-		return fmt.Errorf(
-			"lockfileVersion %s in pnpm-lock.yaml is too new for the provided pnpm version %d",
-			l.LockfileVersion,
-			pnpmVer,
-		)
+
+	switch support, msg := pnpm.LockfileCompatibility(pnpmVer, lockfileVer); support {
+	case pnpm.LockfileRejected:
+		return pnpm_err.NewPnpmError(&pnpm_err.UnsupportedLockfileVersionError{}, msg, nil)
+	case pnpm.LockfileConverted, pnpm.LockfileUnknown:
+		logger.Warn(msg)
+	case pnpm.LockfileNative:
 	}
 
+	npmrc, npmrcErr := pnpm.ReadNpmrc(osFs, srcPath)
+	if npmrcErr != nil {
+		logger.Warnf("failed to read .npmrc, assuming the pnpm defaults: %v", npmrcErr)
+	}
+
+	for _, mismatch := range pnpm.SettingsMismatches(pnpmVer, l.Settings, npmrc) {
+		logger.Warn(mismatch)
+	}
+
+	logger.Debugf("checked lockfile version %s against pnpm version %s", l.LockfileVersion, pnpmVer)
+
 	return nil
 }
 
+// computeStoreHash normalizes the store and returns the hash of the fetcher output.
+// If manifestPath is not empty, the manifest of the hashed tree is written to it.
 func computeStoreHash(
 	osFs afero.Fs,
 	logger logger.Logger,
 	storePath string,
-	fetcherVersion int,
+	f fetcher.Fetcher,
+	manifestPath string,
+	progress store.ProgressFunc,
 ) (string, error) {
-	logger.Debugf("use fetcher version %d", fetcherVersion)
-
-	// Write .fetcher-version to the store directory before Normalize,
-	// because Normalize sets dirs to 0o555 (read-only).
-	// For v3+, .fetcher-version is written to a separate output directory in computeHashWithTarball.
-	//nolint:mnd // fetcherVersion 2 is the only version that writes .fetcher-version to the store
-	if fetcherVersion == 2 {
-		fetcherVersionPath := filepath.Join(storePath, ".fetcher-version")
-		versionContent := fmt.Sprintf("%d\n", fetcherVersion)
-		writeErr := afero.WriteFile(osFs, fetcherVersionPath, []byte(versionContent), 0o444)
-		if writeErr != nil {
-			return "", fmt.Errorf("failed to write .fetcher-version: %w", writeErr)
-		}
-	}
+	logger.Debugf("use fetcher version %d", f.Version())
 
-	// Normalize the pnpm store (remove tmp/projects, normalize JSON, set permissions)
-	logger.Debug("normalize pnpm store for reproducible hashing")
-	normalizeErr := store.Normalize(osFs, store.NormalizeOptions{
-		StorePath:      storePath,
-		FetcherVersion: fetcherVersion,
-	})
-	if normalizeErr != nil {
-		return "", normalizeErr
+	if err := normalizeStore(osFs, logger, storePath, f, progress); err != nil {
+		return "", err
 	}
 
-	//nolint:mnd // fetcherVersion 3+ uses tarball-based output
-	if fetcherVersion >= 3 {
-		return computeHashWithTarball(osFs, logger, storePath, fetcherVersion)
+	outPath := storePath
+	if !f.StoreIsOutput() {
+		// Create temporary output directory for outputs that are built from the store
+		outDir, err := afero.TempDir(osFs, "", "nix-prefetch-pnpm-out-")
+		if err != nil {
+			return "", fmt.Errorf("failed to create output directory: %w", err)
+		}
+		defer func() { _ = osFs.RemoveAll(outDir) }()
+		logger.Debugf("created temporary output directory at %s", outDir)
+
+		outPath, err = f.WriteOutput(osFs, storePath, outDir, progress)
+		if err != nil {
+			return "", err
+		}
+		logger.Debugf("wrote fetcher v%d output to %s", f.Version(), outDir)
+		if f.Layout() == store.LayoutOutputV3 {
+			logger.Debugf("compressed the store tarball with libzstd %s", store.ZstdVersion())
+		}
 	}
 
-	logger.Debugf("compute hash of pnpm store at %s", storePath)
-	hash, hashErr := store.Hash(osFs, storePath)
+	logger.Debugf("compute hash of fetcher output at %s", outPath)
+	hash, hashErr := hashOutput(osFs, logger, f, outPath, manifestPath, progress)
 	if hashErr != nil {
 		return "", hashErr
 	}
@@ -114,150 +286,340 @@ func computeStoreHash(
 	return hash, nil
 }
 
-func computeHashWithTarball(
+// normalizeStore prepares the store in place for the given fetcher version.
+func normalizeStore(
 	osFs afero.Fs,
 	logger logger.Logger,
 	storePath string,
-	fetcherVersion int,
+	f fetcher.Fetcher,
+	progress store.ProgressFunc,
+) error {
+	logger.Debug("normalize pnpm store for reproducible hashing")
+	if err := f.Normalize(osFs, storePath, progress); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// hashOutput computes the hash of a fetcher output. If manifestPath is not empty,
+// the manifest of the hashed tree is written to it as JSON.
+func hashOutput(
+	osFs afero.Fs,
+	logger logger.Logger,
+	f fetcher.Fetcher,
+	outPath string,
+	manifestPath string,
+	progress store.ProgressFunc,
 ) (string, error) {
-	logger.Debug("creating tarball of pnpm store for hashing")
+	hash, manifest, hashErr := f.Hash(osFs, outPath, store.HashOptions{
+		Manifest: manifestPath != "",
+		Progress: progress,
+	})
+	if hashErr != nil {
+		return "", hashErr
+	}
+
+	if manifestPath == "" {
+		return hash, nil
+	}
 
-	// Create temporary output directory for .fetcher-version and tarball
-	outDir, err := afero.TempDir(osFs, "", "nix-prefetch-pnpm-out-")
+	data, err := json.MarshalIndent(manifest, "", "  ")
 	if err != nil {
-		return "", fmt.Errorf("failed to create output directory: %w", err)
+		return "", fmt.Errorf("failed to encode manifest: %w", err)
 	}
-	defer func() { _ = osFs.RemoveAll(outDir) }()
-	logger.Debugf("created temporary output directory at %s", outDir)
 
-	// Write .fetcher-version file
-	fetcherVersionPath := filepath.Join(outDir, ".fetcher-version")
-	versionContent := fmt.Sprintf("%d\n", fetcherVersion)
-	//nolint:mnd // read-only file permissions
-	writeErr := afero.WriteFile(
-		osFs,
-		fetcherVersionPath,
-		[]byte(versionContent),
-		0o444,
-	)
-	if writeErr != nil {
-		return "", fmt.Errorf("failed to write .fetcher-version: %w", writeErr)
+	//nolint:mnd // manifest file permissions
+	if err := afero.WriteFile(osFs, manifestPath, append(data, '\n'), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write manifest: %w", err)
 	}
+	logger.Info("wrote manifest", "path", manifestPath)
 
-	// Create reproducible tarball
-	tarballPath := filepath.Join(outDir, "pnpm-store.tar.zst")
-	if tarballErr := store.CreateTarball(osFs, storePath, tarballPath); tarballErr != nil {
-		return "", tarballErr
+	return hash, nil
+}
+
+// stepProgress returns a store.ProgressFunc that reports the progress of the
+// store operations to stepLogger.
+func stepProgress(stepLogger logger.StepLogger) store.ProgressFunc {
+	return func(p store.Progress) {
+		stepLogger.Progress(logger.StepProgress{
+			Phase:      p.Phase,
+			Current:    p.Files,
+			Total:      p.TotalFiles,
+			Bytes:      p.Bytes,
+			TotalBytes: p.TotalBytes,
+		})
 	}
-	logger.Debugf("created tarball of pnpm store at %s", tarballPath)
+}
 
-	// Hash the output directory (containing .fetcher-version and tarball)
-	hash, hashErr := store.Hash(osFs, outDir)
+// fetchOptions contains the options of a single install-normalize-hash run.
+type fetchOptions struct {
+	installOpts  pnpm.InstallOptions
+	fetcher      fetcher.Fetcher
+	manifestPath string             // write the manifest of the hashed tree here if not empty
+	verify       bool               // verify the store against its index files and the lockfile
+	lockfile     *lockfile.Lockfile // used to cross-check package integrities
+	expected     map[string]string  // packages expected in the store; completeness is not checked if nil
+	strict       bool               // fail if expected packages are missing from the store
+}
+
+// fetchAndHash installs the dependencies into a new temporary pnpm store, normalizes it
+// and computes the NAR hash. The store path is returned even on failure, and the
+// caller is responsible for removing it with store.RemoveAll.
+func fetchAndHash(
+	osFs afero.Fs,
+	logger logger.Logger,
+	p *pnpm.Pnpm,
+	opts fetchOptions,
+) (string, string, error) {
+	// Create temp directory for pnpm store
+	storePath, err := afero.TempDir(osFs, "", "nix-prefetch-pnpm-deps-")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	logger.Debugf("created temporary directory for pnpm store at %s", storePath)
+
+	// Run pnpm install to fetch dependencies into the store
+	installOpts := opts.installOpts
+	installOpts.StorePath = storePath
+	installErr := p.Install(osFs, installOpts)
+	if installErr != nil {
+		return storePath, "", &installError{err: installErr}
+	}
+	logger.Info("successfully installed dependencies to pnpm store", "path", storePath)
+
+	// Verify the files pnpm wrote before they are normalized and hashed
+	if opts.verify {
+		if verifyErr := verifyStore(osFs, logger, storePath, opts.lockfile, opts.expected, opts.strict); verifyErr != nil {
+			return storePath, "", verifyErr
+		}
+	}
+
+	if opts.expected != nil {
+		if checkErr := checkStoreCompleteness(osFs, logger, storePath, opts.expected, opts.strict); checkErr != nil {
+			return storePath, "", checkErr
+		}
+	}
+
+	// Normalize store and compute NAR hash
+	hashStepLogger := logger.StepLogger(slog.LevelInfo, "compute NAR hash")
+	hash, hashErr := computeStoreHash(
+		osFs,
+		logger,
+		storePath,
+		opts.fetcher,
+		opts.manifestPath,
+		stepProgress(hashStepLogger),
+	)
 	if hashErr != nil {
-		return "", hashErr
+		hashStepLogger.Fail(hashErr)
+		return storePath, "", fmt.Errorf("failed to compute NAR hash: %w", hashErr)
 	}
+	hashStepLogger.Done()
 
-	logger.Debugf("computed hash with tarball: %s", hash)
-	return hash, nil
+	return storePath, hash, nil
 }
 
 //nolint:cyclop,funlen // run function is the main command logic
-func run(_ *cobra.Command, args []string) error {
+func run(cmd *cobra.Command, args []string) error {
 	fetcherVersion, err := fetcherVersionFlag.GetIntE()
 	if err != nil {
 		return err
 	}
 
-	pnpmPath := pnpmPathFlag.GetString()
+	f, err := fetcher.Get(fetcherVersion)
+	if err != nil {
+		return err
+	}
+
+	pnpmPaths := pnpmPathFlag.GetStringSlice()
+	strictPackageManager := strictPackageManagerFlag.GetBool()
+	nodePath := nodePathFlag.GetString()
+	sandbox, err := sandboxFlag.GetStringE()
+	if err != nil {
+		return err
+	}
+	passEnv, err := passEnvFlag.GetStringSliceE()
+	if err != nil {
+		return err
+	}
 	workspaces := workspaceFlag.GetStringSlice()
 	pnpmFlags := pnpmFlagFlag.GetStringSlice()
 	preInstallCommands := preInstallCommandFlag.GetStringSlice()
 	expectedHash := hashFlag.GetString()
 	quiet := quietFlag.GetBool()
+	manifestPath := manifestFlag.GetString()
+	annotateFile := annotateFileFlag.GetString()
+	noVerify := noVerifyFlag.GetBool()
+	strict := strictFlag.GetBool()
+	varyEnvironment := varyEnvironmentFlag.GetBool()
+	checkRuns, err := checkReproducibleFlag.GetIntE()
+	if err != nil {
+		return err
+	}
 
-	level := slog.LevelInfo
-	if quiet {
-		level = slog.LevelError
+	osFs := afero.NewOsFs()
+
+	logger, err := newLogger(osFs, quiet)
+	if err != nil {
+		return err
 	}
-	logger := logger.New(level)
 	defer logger.Close()
 
+	// From here on, failures are logged and only their exit code is left to Execute.
+	cmd.SilenceUsage = true
+
 	logger.Debugf("fetcher version: %d", fetcherVersion)
-	logger.Debugf("pnpm path: %s", pnpmPath)
+	logger.Debugf("pnpm paths: %v", pnpmPaths)
+	logger.Debugf("strict package manager: %t", strictPackageManager)
+	logger.Debugf("node path: %s", nodePath)
+	logger.Debugf("sandbox: %s", sandbox)
+	logger.Debugf("passed environment variables: %v", passEnv)
 	logger.Debugf("workspaces: %v", workspaces)
 	logger.Debugf("extra pnpm flags: %v", pnpmFlags)
 	logger.Debugf("pre-install commands: %v", preInstallCommands)
 	logger.Debugf("expected hash: %s", expectedHash)
+	logger.Debugf("manifest path: %s", manifestPath)
+	logger.Debugf("verify store: %t", !noVerify)
+	logger.Debugf("strict: %t", strict)
+	logger.Debugf("reproducibility check runs: %d (vary environment: %t)", checkRuns, varyEnvironment)
+
+	// pnpm and the sandbox run in other working directories, so the source
+	// directory is made absolute once.
+	srcPath, absErr := filepath.Abs(args[0])
+	if absErr != nil {
+		return logError(logger, fmt.Errorf("failed to resolve the source directory: %w", absErr))
+	}
 
-	srcPath := args[0]
-	osFs := afero.NewOsFs()
+	// Set up the sandbox first, so that a missing bwrap fails before anything runs
+	executor, executorErr := initExecutor(logger, sandbox)
+	if executorErr != nil {
+		return logError(logger, fmt.Errorf("failed to set up the sandbox: %w", executorErr))
+	}
 
 	// Verify lockfile exists and is valid
 	lockfilePath := filepath.Join(srcPath, "pnpm-lock.yaml")
 	lf, loadErr := lockfile.Load(osFs, lockfilePath)
 	if loadErr != nil {
-		logger.Fatalf("failed to load pnpm-lock.yaml: %w", loadErr)
+		return logError(logger, fmt.Errorf("failed to load pnpm-lock.yaml: %w", loadErr))
+	}
+	logger.Info("loaded pnpm-lock.yaml", "path", lockfilePath)
+
+	// Read the package.json of the project for its packageManager and engines fields
+	project, projectErr := loadProjectPackageJSON(osFs, srcPath)
+	if projectErr != nil {
+		if strictPackageManager {
+			return logError(logger, fmt.Errorf("failed to load package.json: %w", projectErr))
+		}
+		logger.Warnf("skipping packageManager and engines checks: %v", projectErr)
+	}
+
+	pm, pmErr := readPackageManager(project)
+	if pmErr != nil {
+		if strictPackageManager {
+			return logError(logger, fmt.Errorf("failed to read the packageManager field: %w", pmErr))
+		}
+		logger.Warnf("skipping packageManager check: %v", pmErr)
 	}
-	logger.Infof("loaded pnpm-lock.yaml from %s", lockfilePath)
 
-	// Create pnpm instance from explicit path or PATH env var
-	p, pnpmErr := initPnpm(osFs, logger, pnpmPath)
+	// Find Node.js to run pnpm with from explicit path or PATH env var
+	node, nodeErr := initNode(osFs, logger, nodePath)
+	if nodeErr != nil {
+		nodeErr = fmt.Errorf("failed to find Node.js: %w", nodeErr)
+		logger.Debugf("only pnpm binaries bundling Node.js can be used: %v", nodeErr)
+	} else {
+		logger.Debugf("using Node.js %s at %s", node.Version(), node.Path())
+	}
+
+	// Select pnpm among the explicit paths or PATH env var
+	p, pnpmErr := initPnpm(osFs, logger, pnpmPaths, node, nodeErr, lf, pm, strictPackageManager)
 	if pnpmErr != nil {
-		logger.Fatalf("failed to initialize pnpm: %w", pnpmErr)
+		return logError(logger, fmt.Errorf("failed to select pnpm: %w", pnpmErr))
 	}
-	logger.Debugf("initialized pnpm with path: %s", p.Path())
+	logger.Debugf("initialized pnpm with path: %s (%s)", p.Path(), p.Kind())
+	p.SetExecutor(executor)
+	p.SetPassEnv(passEnv)
 
-	// Validate lockfile version against pnpm version
-	verErr := validateLockfileVersion(lf, p)
-	if verErr != nil {
-		logger.Fatalf("invalid lockfile version: %w", verErr)
+	if pmErr := checkPackageManager(logger, p, pm, strictPackageManager); pmErr != nil {
+		return logError(logger, fmt.Errorf("pnpm does not match the packageManager field: %w", pmErr))
 	}
-	logger.Debugf(
-		"validated lockfile version %s is compatible with pnpm version %s",
-		lf.LockfileVersion,
-		func() string { v, _ := p.Version(); return v }(),
-	)
 
-	// Create temp directory for pnpm store
-	storePath, err := afero.TempDir(osFs, "", "nix-prefetch-pnpm-deps-")
-	if err != nil {
-		logger.Fatalf("failed to create temp directory: %w", err)
+	// Check the Node.js version against pnpm and the engines field of the project
+	if nodeErr := checkNode(logger, p.Node(), p, project); nodeErr != nil {
+		return logError(logger, fmt.Errorf("unsupported Node.js version: %w", nodeErr))
 	}
-	defer func() { _ = osFs.RemoveAll(storePath) }()
-	logger.Debugf("created temporary directory for pnpm store at %s", storePath)
 
-	// Run pnpm install to fetch dependencies into the store
-	installOpts := pnpm.InstallOptions{
-		StorePath:          storePath,
-		Workspaces:         workspaces,
-		Registry:           os.Getenv("NIX_NPM_REGISTRY"),
-		ExtraFlags:         pnpmFlags,
-		PreInstallCommands: preInstallCommands,
-		WorkingDir:         srcPath,
+	// Check the lockfile version and settings against the pnpm version
+	if compatErr := checkLockfileCompatibility(osFs, logger, srcPath, lf, p); compatErr != nil {
+		return logError(logger, fmt.Errorf("unsupported lockfile version: %w", compatErr))
 	}
-	installErr := p.Install(osFs, installOpts)
-	if installErr != nil {
-		logger.Fatalf("failed to install dependencies: %w", installErr)
+
+	// Compute the packages pnpm install is expected to fetch for the completeness check
+	expected, expectedErr := expectedPackages(osFs, srcPath, lf, workspaces, pnpmFlags)
+	if expectedErr != nil {
+		if strict {
+			return logError(
+				logger,
+				fmt.Errorf("failed to compute expected packages from pnpm-lock.yaml: %w", expectedErr),
+			)
+		}
+		logger.Warnf("skipping store completeness check: %v", expectedErr)
 	}
-	logger.Infof("successfully installed dependencies to pnpm store at %s", storePath)
 
-	// Normalize store and compute NAR hash
-	hashStepLogger := logger.StepLogger(slog.LevelInfo, "compute NAR hash")
-	hash, hashErr := computeStoreHash(osFs, logger, storePath, fetcherVersion)
-	if hashErr != nil {
-		hashStepLogger.Fail(hashErr)
-		logger.Fatalf("failed to compute NAR hash: %w", hashErr)
+	fetchOpts := fetchOptions{
+		installOpts: pnpm.InstallOptions{
+			Workspaces:         workspaces,
+			Registry:           os.Getenv("NIX_NPM_REGISTRY"),
+			ExtraFlags:         pnpmFlags,
+			PreInstallCommands: preInstallCommands,
+			WorkingDir:         srcPath,
+		},
+		fetcher:      f,
+		manifestPath: manifestPath,
+		verify:       !noVerify,
+		lockfile:     lf,
+		expected:     expected,
+		strict:       strict,
+	}
+
+	var hash string
+	if checkRuns > 0 {
+		// Every run is fetched and hashed independently; the hash is only used if all runs agree.
+		checkedHash, checkErr := checkReproducible(osFs, logger, p, fetchOpts, checkRuns, varyEnvironment)
+		if checkErr != nil {
+			logger.WriteSummary(resultSummary(srcPath, f, "", expectedHash, "❌ reproducibility check failed"))
+			return logError(logger, fmt.Errorf("reproducibility check failed: %w", checkErr))
+		}
+		hash = checkedHash
+	} else {
+		storePath, fetchedHash, fetchErr := fetchAndHash(osFs, logger, p, fetchOpts)
+		if storePath != "" {
+			defer func() { _ = store.RemoveAll(osFs, storePath) }()
+		}
+		if fetchErr != nil {
+			logger.WriteSummary(resultSummary(srcPath, f, "", expectedHash, "❌ failed to fetch dependencies"))
+			return logError(logger, fetchErr)
+		}
+		hash = fetchedHash
 	}
-	hashStepLogger.Done()
 
 	// Verify against expected hash if provided
 	if expectedHash != "" {
 		if expectedHash != hash {
-			logger.Fatalf("hash mismatch:\n  expected %s\n  got %s", expectedHash, hash)
+			annotation, annotateErr := hashMismatchAnnotation(osFs, annotateFile, expectedHash, hash)
+			if annotateErr != nil {
+				logger.Warnf("failed to locate the expected hash in --%s: %w", annotateFileFlagName, annotateErr)
+			}
+			logger.Annotate(annotation)
+			logger.WriteSummary(resultSummary(srcPath, f, hash, expectedHash, "❌ hash mismatch"))
+			return logError(logger, &hashMismatchError{expected: expectedHash, got: hash})
 		}
+		logger.WriteSummary(resultSummary(srcPath, f, hash, expectedHash, "✅ hash matches"))
 		return nil
 	}
 
+	logger.WriteSummary(resultSummary(srcPath, f, hash, "", "✅ hash computed"))
+
 	// Close logger (stop TUI) before printing hash directly to stdout.
 	_ = logger.Close()
 
@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm"
+)
+
+// initExecutor returns the executor of pnpm and the pre-install commands for the
+// --sandbox value sandbox.
+func initExecutor(logger logger.Logger, sandbox string) (pnpm.Executor, error) {
+	if sandbox != sandboxBwrap {
+		return pnpm.DirectExecutor{}, nil
+	}
+
+	e, err := pnpm.NewBwrapExecutor()
+	if err != nil {
+		return nil, err
+	}
+	logger.Debugf("running pnpm and the pre-install commands in a bubblewrap sandbox")
+
+	return e, nil
+}
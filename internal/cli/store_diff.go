@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+// unownedGroup is the group of paths that no store index file refers to.
+const unownedGroup = "(not owned by a package)"
+
+var storeDiffCmd = &cobra.Command{
+	Use:   "store-diff [old] [new]",
+	Short: "show file-level differences between two stores or fetcher outputs",
+	Long: `show file-level differences between two stores or fetcher outputs
+each input may be a store directory, a fetcher output directory,
+a fetcher v3 tarball (pnpm-store.tar.zst) or a NAR file.
+added, removed and modified files are listed with their mode, size and content digest changes,
+grouped by the package (name@version) that owns them according to the store index files.
+modified index files are compared semantically as JSON`,
+	Args: cobra.ExactArgs(2), //nolint:mnd // old and new
+	RunE: runStoreDiff,
+}
+
+func runStoreDiff(cmd *cobra.Command, args []string) error {
+	osFs := afero.NewOsFs()
+
+	oldEntries, err := store.List(osFs, args[0])
+	if err != nil {
+		return err
+	}
+
+	newEntries, err := store.List(osFs, args[1])
+	if err != nil {
+		return err
+	}
+
+	printStoreDiff(cmd.OutOrStdout(), args[0], args[1], oldEntries, newEntries)
+
+	return nil
+}
+
+// printStoreDiff writes the differences between two store trees grouped by package.
+func printStoreDiff(out io.Writer, oldName, newName string, oldEntries, newEntries []store.TreeEntry) {
+	diffs := store.Diff(oldEntries, newEntries)
+	if len(diffs) == 0 {
+		fmt.Fprintln(out, "no differences")
+		return
+	}
+
+	oldOwners := store.PackageOwners(oldEntries)
+	newOwners := store.PackageOwners(newEntries)
+
+	groups := make(map[string][]store.DiffEntry)
+	counts := make(map[store.DiffKind]int)
+
+	for _, d := range diffs {
+		owner, ok := newOwners[d.Path]
+		if !ok {
+			owner, ok = oldOwners[d.Path]
+		}
+
+		if !ok {
+			owner = unownedGroup
+		}
+
+		groups[owner] = append(groups[owner], d)
+		counts[d.Kind]++
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		if name != unownedGroup {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	if _, ok := groups[unownedGroup]; ok {
+		names = append(names, unownedGroup)
+	}
+
+	fmt.Fprintf(out, "--- %s\n+++ %s\n", oldName, newName)
+
+	for _, name := range names {
+		fmt.Fprintf(out, "\n%s\n", name)
+
+		for _, d := range groups[name] {
+			printDiffEntry(out, d)
+		}
+	}
+
+	fmt.Fprintf(
+		out,
+		"\n%d added, %d removed, %d modified\n",
+		counts[store.DiffAdded],
+		counts[store.DiffRemoved],
+		counts[store.DiffModified],
+	)
+}
+
+func printDiffEntry(out io.Writer, d store.DiffEntry) {
+	switch d.Kind {
+	case store.DiffAdded:
+		fmt.Fprintf(out, "  + %s (%s)\n", d.Path, describeTreeEntry(d.New))
+	case store.DiffRemoved:
+		fmt.Fprintf(out, "  - %s (%s)\n", d.Path, describeTreeEntry(d.Old))
+	case store.DiffModified:
+		fmt.Fprintf(out, "  ~ %s\n", d.Path)
+
+		for _, c := range d.Changes {
+			fmt.Fprintf(out, "      %s\n", c)
+		}
+
+		for _, c := range d.JSONChanges {
+			fmt.Fprintf(out, "      json %s\n", c)
+		}
+	}
+}
+
+// describeTreeEntry summarizes the type, mode, size and digest of an entry.
+func describeTreeEntry(e *store.TreeEntry) string {
+	switch {
+	case e.Target != "":
+		return "symlink -> " + e.Target
+	case e.SHA256 != "":
+		mode := "regular"
+		if e.Executable {
+			mode = "executable"
+		}
+
+		return strings.Join([]string{mode, fmt.Sprintf("%d bytes", e.Size), "sha256:" + e.SHA256}, ", ")
+	default:
+		return string(e.Type)
+	}
+}
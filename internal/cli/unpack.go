@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+var unpackCmd = &cobra.Command{
+	Use:   "unpack [tarball|output-dir] [dest-dir]",
+	Short: "extract the pnpm store from a fetcher v3 tarball",
+	Long: `extract the pnpm store from a fetcher v3 tarball
+the first argument is either a pnpm-store.tar.zst file or a fetcher v3 output directory containing one`,
+	Args: cobra.ExactArgs(2), //nolint:mnd // tarball and destination
+	RunE: runUnpack,
+}
+
+func runUnpack(cmd *cobra.Command, args []string) error {
+	osFs := afero.NewOsFs()
+
+	tarballPath, err := resolveTarballPath(osFs, args[0])
+	if err != nil {
+		return err
+	}
+
+	if unpackErr := store.Unpack(osFs, tarballPath, args[1]); unpackErr != nil {
+		return unpackErr
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "unpacked %s to %s\n", tarballPath, args[1])
+
+	return nil
+}
+
+// resolveTarballPath returns the tarball inside path if path is a fetcher v3 output directory.
+func resolveTarballPath(osFs afero.Fs, path string) (string, error) {
+	isDir, err := afero.IsDir(osFs, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	if isDir {
+		return filepath.Join(path, store.TarballFileName), nil
+	}
+
+	return path, nil
+}
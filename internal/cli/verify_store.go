@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+// verifyStore checks the files pnpm wrote against the store index files and the
+// index files against the package integrities in the lockfile.
+func verifyStore(
+	osFs afero.Fs,
+	logger logger.Logger,
+	storePath string,
+	lf *lockfile.Lockfile,
+	expected map[string]string,
+	strict bool,
+) error {
+	stepLogger := logger.StepLogger(slog.LevelInfo, "verify pnpm store integrity")
+
+	err := findIntegrityProblems(osFs, storePath, lf, expected, strict)
+	if err != nil {
+		stepLogger.Fail(err)
+		return err
+	}
+	stepLogger.Done()
+
+	return nil
+}
+
+// findIntegrityProblems cross-checks the store with the expected packages, or with every
+// package in the lockfile if they are unknown. The lockfile also lists optional packages of
+// other platforms, so packages without an index file only count with the expected packages
+// and strict; otherwise the completeness check reports them.
+func findIntegrityProblems(
+	osFs afero.Fs,
+	storePath string,
+	lf *lockfile.Lockfile,
+	expected map[string]string,
+	strict bool,
+) error {
+	integrities := expected
+	if integrities == nil && lf != nil {
+		lockfileIntegrities, lockfileErr := lf.PackageIntegrities()
+		if lockfileErr != nil {
+			return lockfileErr
+		}
+		integrities = lockfileIntegrities
+	}
+
+	problems, verifyErr := store.Verify(osFs, storePath, integrities)
+	if verifyErr != nil {
+		return verifyErr
+	}
+
+	if expected == nil || !strict {
+		problems = slices.DeleteFunc(problems, func(p store.IntegrityProblem) bool { return p.Missing })
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	lines := make([]string, 0, len(problems))
+	packages := make(map[string]struct{})
+	for _, problem := range problems {
+		lines = append(lines, problem.String())
+		packages[problem.Package] = struct{}{}
+	}
+
+	return store_err.NewStoreError(
+		&store_err.IntegrityMismatchError{},
+		fmt.Sprintf(
+			"store integrity verification failed: %d problems in %d packages\n  %s",
+			len(problems),
+			len(packages),
+			strings.Join(lines, "\n  "),
+		),
+		nil,
+	)
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+var verifyTarballCmd = &cobra.Command{
+	Use:   "verify-tarball [tarball|output-dir]",
+	Short: "verify that a fetcher v3 tarball is reproducible",
+	Long: `verify that a fetcher v3 tarball is reproducible
+checks that entries are sorted by name, mtimes equal SOURCE_DATE_EPOCH,
+owners are numeric 0:0 and permissions are normalized,
+then prints the NAR hash of the extracted store.
+if a fetcher v3 output directory is given, the hash of the whole output is printed as well`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVerifyTarball,
+}
+
+func runVerifyTarball(cmd *cobra.Command, args []string) error {
+	osFs := afero.NewOsFs()
+	out := cmd.OutOrStdout()
+
+	tarballPath, err := resolveTarballPath(osFs, args[0])
+	if err != nil {
+		return err
+	}
+
+	report, verifyErr := store.VerifyTarball(osFs, tarballPath)
+	if verifyErr != nil {
+		return verifyErr
+	}
+
+	fmt.Fprintf(out, "entries: %d\n", report.Entries)
+	fmt.Fprintf(out, "store hash: %s\n", report.Hash)
+
+	if tarballPath != args[0] {
+		outputHash, hashErr := verifyOutputDir(osFs, args[0])
+		if hashErr != nil {
+			return hashErr
+		}
+
+		fmt.Fprintf(out, "output hash: %s\n", outputHash)
+	}
+
+	if len(report.Violations) > 0 {
+		for _, v := range report.Violations {
+			fmt.Fprintln(cmd.ErrOrStderr(), v)
+		}
+
+		return store_err.NewStoreError(
+			&store_err.InvalidTarballError{},
+			fmt.Sprintf("%d reproducibility violations found in %s", len(report.Violations), tarballPath),
+			nil,
+		)
+	}
+
+	return nil
+}
+
+// verifyOutputDir checks the .fetcher-version of a v3 output directory and returns its hash.
+func verifyOutputDir(osFs afero.Fs, outDir string) (string, error) {
+	data, err := afero.ReadFile(osFs, filepath.Join(outDir, store.FetcherVersionFileName))
+	if err != nil {
+		return "", fmt.Errorf("failed to read .fetcher-version: %w", err)
+	}
+
+	if v := strings.TrimSpace(string(data)); v != "3" {
+		return "", fmt.Errorf("unexpected .fetcher-version %q in %s (expected 3)", v, outDir)
+	}
+
+	hash, hashErr := store.Hash(osFs, outDir)
+	if hashErr != nil {
+		return "", hashErr
+	}
+
+	return hash, nil
+}
@@ -1,22 +1,138 @@
 package common
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 )
 
-func MajorVersion(v string) (int, error) {
-	parts := strings.Split(v, ".")
-	if len(parts) == 0 {
-		return 0, errors.New("invalid version format")
+// Version is a semantic version (https://semver.org). Lockfile versions such as
+// "9.0" have no patch version; missing minor and patch versions are parsed as 0.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string // dot-separated identifiers after "-"
+	Build      string   // metadata after "+", ignored for precedence
+}
+
+// ParseVersion parses a semantic version. Surrounding whitespace and a leading "v"
+// are ignored, and the minor and patch versions may be omitted.
+func ParseVersion(s string) (Version, error) {
+	var v Version
+
+	rest := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if rest == "" {
+		return v, errors.New("invalid version format: empty version")
+	}
+
+	rest, v.Build, _ = strings.Cut(rest, "+")
+
+	core, prerelease, hasPrerelease := strings.Cut(rest, "-")
+	if hasPrerelease {
+		if prerelease == "" {
+			return v, fmt.Errorf("invalid version format %q: empty prerelease", s)
+		}
+		v.Prerelease = strings.Split(prerelease, ".")
+		for _, id := range v.Prerelease {
+			if id == "" {
+				return v, fmt.Errorf("invalid version format %q: empty prerelease identifier", s)
+			}
+		}
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 { //nolint:mnd // major.minor.patch
+		return v, fmt.Errorf("invalid version format %q: too many components", s)
+	}
+
+	fields := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version format %q: %q is not a number", s, part)
+		}
+		*fields[i] = n
+	}
+
+	return v, nil
+}
+
+// MustParseVersion is like ParseVersion but panics on invalid versions.
+// It is intended for versions known at compile time.
+func MustParseVersion(s string) Version {
+	v, err := ParseVersion(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// String returns the version as major.minor.patch with its prerelease and build metadata.
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
 	}
+	return s
+}
 
-	major, err := strconv.Atoi(parts[0])
+// Compare returns -1, 0 or +1 if v has a lower, equal or higher precedence than o.
+// Build metadata is ignored.
+func (v Version) Compare(o Version) int {
+	for _, c := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if c[0] != c[1] {
+			return cmp.Compare(c[0], c[1])
+		}
+	}
+
+	// A version without prerelease has a higher precedence than one with.
+	switch {
+	case len(v.Prerelease) == 0 && len(o.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(o.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(o.Prerelease); i++ {
+		if c := comparePrerelease(v.Prerelease[i], o.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(v.Prerelease), len(o.Prerelease))
+}
+
+// comparePrerelease compares prerelease identifiers: numeric identifiers compare
+// numerically and have a lower precedence than alphanumeric ones.
+func comparePrerelease(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+
+	switch {
+	case aErr == nil && bErr == nil:
+		return cmp.Compare(an, bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func MajorVersion(v string) (int, error) {
+	parsed, err := ParseVersion(v)
 	if err != nil {
-		return 0, fmt.Errorf("invalid major version format: %w", err)
+		return 0, err
 	}
 
-	return major, nil
+	return parsed.Major, nil
 }
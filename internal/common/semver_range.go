@@ -0,0 +1,246 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Range is a set of versions described by a node-semver range as used in the engines
+// field of package.json, e.g. ">=18.12 <21 || ^22". Prereleases are compared by
+// precedence like other versions.
+type Range struct {
+	raw  string
+	sets [][]comparator // satisfied if all comparators of any set are satisfied
+}
+
+type comparator struct {
+	op      string // "=", ">", ">=", "<" or "<="
+	version Version
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// operatorSpace matches whitespace between an operator and its version, e.g. ">= 18".
+var operatorSpace = regexp.MustCompile(`([<>=~^])\s+`)
+
+// ParseRange parses a node-semver range. Comparators (<, <=, >, >=, =), tilde and caret
+// ranges, X-ranges (18, 18.x, *), hyphen ranges and || are supported.
+func ParseRange(s string) (Range, error) {
+	r := Range{raw: strings.TrimSpace(s)}
+
+	for set := range strings.SplitSeq(r.raw, "||") {
+		comparators, err := parseComparatorSet(strings.TrimSpace(set))
+		if err != nil {
+			return Range{}, fmt.Errorf("invalid range %q: %w", s, err)
+		}
+		r.sets = append(r.sets, comparators)
+	}
+
+	return r, nil
+}
+
+// MustParseRange is like ParseRange but panics on invalid ranges.
+// It is intended for ranges known at compile time.
+func MustParseRange(s string) Range {
+	r, err := ParseRange(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Contains reports whether v satisfies the range.
+func (r Range) Contains(v Version) bool {
+	for _, set := range r.sets {
+		matches := true
+		for _, c := range set {
+			matches = matches && c.matches(v)
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the range as it was parsed.
+func (r Range) String() string {
+	return r.raw
+}
+
+func parseComparatorSet(set string) ([]comparator, error) {
+	if lo, hi, ok := strings.Cut(set, " - "); ok {
+		return parseHyphenRange(strings.TrimSpace(lo), strings.TrimSpace(hi))
+	}
+
+	var comparators []comparator
+	for token := range strings.FieldsSeq(operatorSpace.ReplaceAllString(set, "$1")) {
+		parsed, err := parseComparator(token)
+		if err != nil {
+			return nil, err
+		}
+		comparators = append(comparators, parsed...)
+	}
+
+	return comparators, nil
+}
+
+func parseHyphenRange(lo, hi string) ([]comparator, error) {
+	from, err := parsePartial(lo)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parsePartial(hi)
+	if err != nil {
+		return nil, err
+	}
+
+	var comparators []comparator
+	if from.parts > 0 {
+		comparators = append(comparators, comparator{">=", from.version})
+	}
+	switch {
+	case to.parts == 0:
+	case to.parts < fullParts:
+		comparators = append(comparators, comparator{"<", to.next()})
+	default:
+		comparators = append(comparators, comparator{"<=", to.version})
+	}
+
+	return comparators, nil
+}
+
+// fullParts is the number of parts of a full version (major.minor.patch).
+const fullParts = 3
+
+// partial is a version whose trailing parts may be omitted or wildcards (x, X or *).
+type partial struct {
+	version Version // omitted parts are 0
+	parts   int     // number of parts given
+}
+
+// next returns the lowest version above every version matching the partial,
+// e.g. 1.3.0 for 1.2.x.
+func (p partial) next() Version {
+	if p.parts == 1 {
+		return Version{Major: p.version.Major + 1}
+	}
+	return Version{Major: p.version.Major, Minor: p.version.Minor + 1}
+}
+
+func parsePartial(s string) (partial, error) {
+	var p partial
+
+	core, _, _ := strings.Cut(s, "+")
+	core, prerelease, hasPrerelease := strings.Cut(core, "-")
+
+	fields := []*int{&p.version.Major, &p.version.Minor, &p.version.Patch}
+	parts := strings.Split(strings.TrimPrefix(core, "v"), ".")
+	if len(parts) > fullParts {
+		return p, fmt.Errorf("%q has too many components", s)
+	}
+
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" || (part == "" && i == 0 && len(parts) == 1) {
+			break
+		}
+
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("%q is not a version", s)
+		}
+		*fields[i] = n
+		p.parts++
+	}
+
+	if hasPrerelease {
+		if p.parts < fullParts {
+			return p, fmt.Errorf("%q has a prerelease without a patch version", s)
+		}
+		p.version.Prerelease = strings.Split(prerelease, ".")
+	}
+
+	return p, nil
+}
+
+//nolint:cyclop // one case per operator, following the node-semver desugaring rules
+func parseComparator(token string) ([]comparator, error) {
+	op := ""
+	for _, candidate := range []string{">=", "<=", ">", "<", "=", "~>", "~", "^"} {
+		if strings.HasPrefix(token, candidate) {
+			op = candidate
+			break
+		}
+	}
+
+	p, err := parsePartial(strings.TrimPrefix(token, op))
+	if err != nil {
+		return nil, err
+	}
+	v := p.version
+
+	// Comparators without a version match every version, or none.
+	if p.parts == 0 {
+		if op == "<" || op == ">" {
+			return []comparator{{"<", Version{}}, {">", Version{}}}, nil
+		}
+		return nil, nil
+	}
+
+	switch op {
+	case ">=":
+		return []comparator{{">=", v}}, nil
+	case "<":
+		return []comparator{{"<", v}}, nil
+	case ">":
+		if p.parts < fullParts {
+			return []comparator{{">=", p.next()}}, nil
+		}
+		return []comparator{{">", v}}, nil
+	case "<=":
+		if p.parts < fullParts {
+			return []comparator{{"<", p.next()}}, nil
+		}
+		return []comparator{{"<=", v}}, nil
+	case "~", "~>":
+		upper := partial{version: v, parts: min(p.parts, 2)} //nolint:mnd // ~1.2.3 allows patch updates
+		return []comparator{{">=", v}, {"<", upper.next()}}, nil
+	case "^":
+		return []comparator{{">=", v}, {"<", caretUpper(p)}}, nil
+	default:
+		if p.parts < fullParts {
+			return []comparator{{">=", v}, {"<", p.next()}}, nil
+		}
+		return []comparator{{"=", v}}, nil
+	}
+}
+
+// caretUpper returns the exclusive upper bound of a caret range, which allows changes
+// that do not modify the left-most non-zero part.
+func caretUpper(p partial) Version {
+	v := p.version
+	switch {
+	case v.Major > 0 || p.parts == 1:
+		return Version{Major: v.Major + 1}
+	case v.Minor > 0 || p.parts == 2: //nolint:mnd // ^0.x allows patch updates
+		return Version{Minor: v.Minor + 1}
+	default:
+		return Version{Patch: v.Patch + 1}
+	}
+}
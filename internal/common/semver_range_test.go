@@ -0,0 +1,173 @@
+package common
+
+import (
+	"testing"
+)
+
+func Test_Range_Contains(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		rng      string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "[正常系] 空の範囲",
+			rng:      "",
+			contains: []string{"0.0.1", "22.1.0"},
+		},
+		{
+			name:     "[正常系] ワイルドカード",
+			rng:      "*",
+			contains: []string{"0.0.1", "22.1.0"},
+		},
+		{
+			name:     "[正常系] >= 部分バージョン",
+			rng:      ">=18.12",
+			contains: []string{"18.12.0", "20.0.0"},
+			excludes: []string{"18.11.9", "16.20.2"},
+		},
+		{
+			name:     "[正常系] 演算子の後の空白",
+			rng:      ">= 18",
+			contains: []string{"18.0.0"},
+			excludes: []string{"17.9.0"},
+		},
+		{
+			name:     "[正常系] AND",
+			rng:      ">=18 <21",
+			contains: []string{"18.0.0", "20.19.0"},
+			excludes: []string{"21.0.0", "17.0.0"},
+		},
+		{
+			name:     "[正常系] OR",
+			rng:      "^18.12 || >=20",
+			contains: []string{"18.20.4", "22.1.0"},
+			excludes: []string{"19.0.0", "18.11.0"},
+		},
+		{
+			name:     "[正常系] X-range",
+			rng:      "20.x",
+			contains: []string{"20.0.0", "20.19.1"},
+			excludes: []string{"21.0.0", "19.9.9"},
+		},
+		{
+			name:     "[正常系] メジャーバージョンのみ",
+			rng:      "20",
+			contains: []string{"20.11.1"},
+			excludes: []string{"21.0.0"},
+		},
+		{
+			name:     "[正常系] 完全なバージョン",
+			rng:      "v20.11.1",
+			contains: []string{"20.11.1"},
+			excludes: []string{"20.11.2"},
+		},
+		{
+			name:     "[正常系] チルダ",
+			rng:      "~18.12.1",
+			contains: []string{"18.12.1", "18.12.9"},
+			excludes: []string{"18.13.0", "18.12.0"},
+		},
+		{
+			name:     "[正常系] メジャーバージョンのみのチルダ",
+			rng:      "~18",
+			contains: []string{"18.20.0"},
+			excludes: []string{"19.0.0"},
+		},
+		{
+			name:     "[正常系] キャレット",
+			rng:      "^20.1",
+			contains: []string{"20.1.0", "20.19.0"},
+			excludes: []string{"21.0.0", "20.0.9"},
+		},
+		{
+			name:     "[正常系] 0.x のキャレット",
+			rng:      "^0.2.3",
+			contains: []string{"0.2.3", "0.2.9"},
+			excludes: []string{"0.3.0"},
+		},
+		{
+			name:     "[正常系] 0.0.x のキャレット",
+			rng:      "^0.0.3",
+			contains: []string{"0.0.3"},
+			excludes: []string{"0.0.4"},
+		},
+		{
+			name:     "[正常系] > 部分バージョン",
+			rng:      ">18",
+			contains: []string{"19.0.0"},
+			excludes: []string{"18.20.0"},
+		},
+		{
+			name:     "[正常系] <= 部分バージョン",
+			rng:      "<=18",
+			contains: []string{"18.20.0"},
+			excludes: []string{"19.0.0"},
+		},
+		{
+			name:     "[正常系] ハイフン",
+			rng:      "18.12 - 20",
+			contains: []string{"18.12.0", "20.19.0"},
+			excludes: []string{"18.11.0", "21.0.0"},
+		},
+		{
+			name:     "[正常系] 完全なバージョンのハイフン",
+			rng:      "18.12.0 - 20.1.0",
+			contains: []string{"20.1.0"},
+			excludes: []string{"20.1.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := ParseRange(tt.rng)
+			if err != nil {
+				t.Fatalf("ParseRange(%q) error: %v", tt.rng, err)
+			}
+
+			for _, v := range tt.contains {
+				if !r.Contains(MustParseVersion(v)) {
+					t.Errorf("%q does not contain %s", tt.rng, v)
+				}
+			}
+			for _, v := range tt.excludes {
+				if r.Contains(MustParseVersion(v)) {
+					t.Errorf("%q contains %s", tt.rng, v)
+				}
+			}
+		})
+	}
+}
+
+func Test_ParseRange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		rng     string
+		wantErr bool
+	}{
+		{name: "[正常系] 比較演算子", rng: ">=18.12.0 <23", wantErr: false},
+		{name: "[正常系] プレリリース", rng: ">=20.0.0-rc.1", wantErr: false},
+		{name: "[異常系] 数値ではない", rng: ">=latest", wantErr: true},
+		{name: "[異常系] 部分が多すぎる", rng: "1.2.3.4", wantErr: true},
+		{name: "[異常系] パッチのないプレリリース", rng: "1.2-rc.1", wantErr: true},
+		{name: "[異常系] ORの一方が不正", rng: "^18 || foo", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseRange(tt.rng)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRange(%q) error = %v, wantErr %v", tt.rng, err, tt.wantErr)
+			}
+		})
+	}
+}
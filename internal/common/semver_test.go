@@ -60,3 +60,114 @@ func Test_MajorVersion(t *testing.T) {
 		})
 	}
 }
+
+func Test_ParseVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		version string
+		want    Version
+		wantErr bool
+	}{
+		{
+			name:    "[正常系] major.minor.patch",
+			version: "9.15.0",
+			want:    Version{Major: 9, Minor: 15, Patch: 0},
+		},
+		{
+			name:    "[正常系] lockfileVersion 形式",
+			version: "6.0",
+			want:    Version{Major: 6},
+		},
+		{
+			name:    "[正常系] 改行と v プレフィックス",
+			version: "v10.2.1\n",
+			want:    Version{Major: 10, Minor: 2, Patch: 1},
+		},
+		{
+			name:    "[正常系] プレリリースとビルドメタデータ",
+			version: "10.0.0-rc.1+build.5",
+			want:    Version{Major: 10, Prerelease: []string{"rc", "1"}, Build: "build.5"},
+		},
+		{
+			name:    "[正常系] pnpm 7 の実験的な lockfileVersion",
+			version: "5.4-inlineSpecifiers",
+			want:    Version{Major: 5, Minor: 4, Prerelease: []string{"inlineSpecifiers"}},
+		},
+		{
+			name:    "[異常系] 空文字",
+			version: " ",
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] 数値でない",
+			version: "9.x",
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] 要素が多すぎる",
+			version: "1.2.3.4",
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] 空のプレリリース",
+			version: "1.2.3-",
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] 空のプレリリース識別子",
+			version: "1.2.3-rc..1",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseVersion(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.String() != tt.want.String() {
+				t.Errorf("ParseVersion() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Version_Compare(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want int
+	}{
+		{name: "[正常系] 等しい", a: "9.0.0", b: "9.0", want: 0},
+		{name: "[正常系] major", a: "8.15.9", b: "9.0.0", want: -1},
+		{name: "[正常系] minor は数値で比較", a: "7.10.0", b: "7.9.0", want: 1},
+		{name: "[正常系] patch", a: "9.1.1", b: "9.1.2", want: -1},
+		{name: "[正常系] プレリリースは正式版より前", a: "10.0.0-rc.1", b: "10.0.0", want: -1},
+		{name: "[正常系] 数値の識別子は数値で比較", a: "10.0.0-rc.10", b: "10.0.0-rc.9", want: 1},
+		{name: "[正常系] 数値の識別子は英数字より前", a: "1.0.0-1", b: "1.0.0-alpha", want: -1},
+		{name: "[正常系] 識別子が多い方が後", a: "1.0.0-alpha.1", b: "1.0.0-alpha", want: 1},
+		{name: "[正常系] ビルドメタデータは無視", a: "1.0.0+a", b: "1.0.0+b", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := MustParseVersion(tt.a).Compare(MustParseVersion(tt.b))
+			if got != tt.want {
+				t.Errorf("%s.Compare(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package errcode
+
+const prefix = "NPPD-"
+
+// Codes are grouped by domain: E001-E009 lockfile, E010-E019 package.json,
+// E020-E039 pnpm, E040-E059 store, E060-E069 fetcher, E070-E079 hash checks.
+const (
+	LockfileNotFound           Code = "NPPD-E001"
+	LockfileLoadFailed         Code = "NPPD-E002"
+	LockfileParseFailed        Code = "NPPD-E003"
+	LockfileUnsupportedVersion Code = "NPPD-E004"
+	UnsupportedSelector        Code = "NPPD-E005"
+
+	PackageJSONNotFound              Code = "NPPD-E010"
+	PackageJSONParseFailed           Code = "NPPD-E011"
+	PackageJSONInvalidPackageManager Code = "NPPD-E012"
+
+	PnpmNotFound               Code = "NPPD-E020"
+	PnpmExecuteFailed          Code = "NPPD-E021"
+	PnpmParseFailed            Code = "NPPD-E022"
+	PnpmUnsupportedLockfile    Code = "NPPD-E023"
+	PnpmOutdatedLockfile       Code = "NPPD-E024"
+	PnpmLockfileConfigMismatch Code = "NPPD-E025"
+	PnpmFetchNotFound          Code = "NPPD-E026"
+	PnpmTarballIntegrity       Code = "NPPD-E027"
+	PnpmNoMatchingVersion      Code = "NPPD-E028"
+	PnpmUnsupportedEngine      Code = "NPPD-E029"
+	PnpmOther                  Code = "NPPD-E030"
+	PnpmPackageManagerMismatch Code = "NPPD-E031"
+	NodeNotFound               Code = "NPPD-E032"
+	NodeUnsupported            Code = "NPPD-E033"
+	PnpmCorepackShim           Code = "NPPD-E034"
+	PnpmSandboxUnavailable     Code = "NPPD-E035"
+
+	StoreCleanupFailed     Code = "NPPD-E040"
+	StoreCopyFailed        Code = "NPPD-E041"
+	TarballCreateFailed    Code = "NPPD-E042"
+	StoreHashFailed        Code = "NPPD-E043"
+	NormalizeJSONFailed    Code = "NPPD-E044"
+	StoreReadFailed        Code = "NPPD-E045"
+	TarballReadFailed      Code = "NPPD-E046"
+	SetPermissionsFailed   Code = "NPPD-E047"
+	IncompleteStore        Code = "NPPD-E048"
+	IntegrityMismatch      Code = "NPPD-E049"
+	InvalidTarball         Code = "NPPD-E050"
+	UnsupportedStoreLayout Code = "NPPD-E051"
+	OutputNotEmpty         Code = "NPPD-E052"
+
+	WriteOutputFailed         Code = "NPPD-E060"
+	UnsupportedFetcherVersion Code = "NPPD-E061"
+
+	HashMismatch    Code = "NPPD-E070"
+	NotReproducible Code = "NPPD-E071"
+)
+
+var catalog = []Entry{
+	{
+		Code:  LockfileNotFound,
+		Title: "pnpm-lock.yaml not found",
+		Hint:  "pass the directory containing pnpm-lock.yaml; run pnpm install there to create it",
+		Explanation: "The source directory does not contain a pnpm-lock.yaml. " +
+			"Dependencies can only be prefetched for a project with a lockfile, " +
+			"as pnpm install runs with --frozen-lockfile.",
+	},
+	{
+		Code:  LockfileLoadFailed,
+		Title: "failed to load pnpm-lock.yaml",
+		Hint:  "check that pnpm-lock.yaml is a readable file",
+		Explanation: "pnpm-lock.yaml exists but cannot be read, " +
+			"e.g. because it is a directory or its permissions do not allow reading it.",
+	},
+	{
+		Code:  LockfileParseFailed,
+		Title: "failed to parse pnpm-lock.yaml",
+		Hint:  "check pnpm-lock.yaml for merge conflict markers, or regenerate it with pnpm install",
+		Explanation: "pnpm-lock.yaml is not valid YAML or its lockfileVersion is not a version number.\n\n" +
+			"Lockfiles are usually broken by unresolved merge conflicts. " +
+			"Resolve them, or delete the lockfile and run pnpm install to regenerate it.",
+	},
+	{
+		Code:  LockfileUnsupportedVersion,
+		Title: "unsupported lockfile version",
+		Hint:  "regenerate pnpm-lock.yaml with pnpm 8 or later",
+		Explanation: "The store completeness check reads the packages of pnpm-lock.yaml, " +
+			"which requires lockfileVersion 6.0 or later.\n\n" +
+			"Without --strict the check is skipped with a warning; with --strict it is fatal.",
+	},
+	{
+		Code:  UnsupportedSelector,
+		Title: "unsupported workspace selector",
+		Hint:  "select the projects by package name or directory in --workspace, or drop --strict",
+		Explanation: "The packages expected in the store are computed from pnpm-lock.yaml for the " +
+			"projects selected by --workspace. Package names, directories and the !, ... and ^ " +
+			"modifiers are supported; changed-since selectors ([<ref>]) are not.\n\n" +
+			"Without --strict the completeness check is skipped with a warning; with --strict it is fatal.",
+	},
+	{
+		Code:        PackageJSONNotFound,
+		Title:       "package.json not found",
+		Hint:        "check that every importer in pnpm-lock.yaml has a package.json",
+		Explanation: "A project listed in pnpm-lock.yaml has no package.json, so it can only be selected by its directory.",
+	},
+	{
+		Code:        PackageJSONParseFailed,
+		Title:       "failed to parse package.json",
+		Hint:        "check the package.json for syntax errors",
+		Explanation: "A package.json of a project listed in pnpm-lock.yaml is not valid JSON.",
+	},
+	{
+		Code:  PackageJSONInvalidPackageManager,
+		Title: "invalid packageManager field in package.json",
+		Hint:  `use the corepack format "pnpm@<version>", optionally followed by "+sha512.<hash>"`,
+		Explanation: "The packageManager field of the package.json in the source directory is not of the form " +
+			"<name>@<version>[+<hash>] with a semantic version, so the pnpm version cannot be checked against it.\n\n" +
+			"Without --strict-package-manager the check is skipped with a warning; with it the error is fatal.",
+	},
+	{
+		Code:  PnpmNotFound,
+		Title: "pnpm not found",
+		Hint:  "add pnpm to PATH or pass --pnpm-path, e.g. --pnpm-path $(which pnpm)",
+		Explanation: "pnpm is neither found on PATH nor at --pnpm-path, " +
+			"or the file at --pnpm-path is not executable.",
+	},
+	{
+		Code:  PnpmExecuteFailed,
+		Title: "failed to execute pnpm",
+		Hint:  "check the pnpm output above; rerun with -v to see the complete output",
+		Explanation: "pnpm, or a --pre-install-command, exited with an error that is not recognized " +
+			"by its ERR_PNPM_* code.\n\n" +
+			"The complete output of the failed command is shown with the error and written to --log-file.",
+	},
+	{
+		Code:        PnpmParseFailed,
+		Title:       "failed to parse pnpm output",
+		Hint:        "check that --pnpm-path points to pnpm and not to a wrapper printing extra output",
+		Explanation: "The output of pnpm --version is not a version number.",
+	},
+	{
+		Code:  PnpmUnsupportedLockfile,
+		Title: "pnpm-lock.yaml is not supported by the provided pnpm version",
+		Hint:  "pass --pnpm-path to the pnpm version named in the message, e.g. pnpm_9 for lockfileVersion 9.0",
+		Explanation: "None of the pnpm installations found on PATH or at --pnpm-path can install from " +
+			"the lockfileVersion of pnpm-lock.yaml with --frozen-lockfile.\n\n" +
+			"| pnpm | writes | installs from |\n" +
+			"| ---- | ------ | ------------- |\n" +
+			"| 7 | 5.4 | 5.x, and 6.0 since 7.24 |\n" +
+			"| 8 | 6.0 | 5.x, 6.x |\n" +
+			"| 9, 10 | 9.0 | 6.x, 9.x |\n\n" +
+			"pnpm versions that install from a lockfile they would convert, and pnpm versions outside " +
+			"this table, are only warned about.\n\n" +
+			"--pnpm-path can be given multiple times, and can be a directory such as one containing " +
+			"pnpm_8, pnpm_9 and pnpm_10; the pnpm best matching the lockfile and the packageManager field is used.",
+	},
+	{
+		Code:  PnpmOutdatedLockfile,
+		Title: "pnpm-lock.yaml is not up to date with package.json",
+		Hint:  "update pnpm-lock.yaml by running pnpm install in the source directory and commit it",
+		Explanation: "pnpm install failed with ERR_PNPM_OUTDATED_LOCKFILE. " +
+			"Dependencies are installed with --frozen-lockfile, so the lockfile must match the dependencies in package.json.",
+	},
+	{
+		Code:  PnpmLockfileConfigMismatch,
+		Title: "pnpm-lock.yaml was created with different settings",
+		Hint: "settings recorded in pnpm-lock.yaml (e.g. overrides, packageExtensions, patchedDependencies " +
+			"or auto-install-peers) differ from the current ones; regenerate the lockfile or apply " +
+			"the same settings with --pre-install-command",
+		Explanation: "pnpm install failed with ERR_PNPM_LOCKFILE_CONFIG_MISMATCH. " +
+			"pnpm records settings that affect the resolution in pnpm-lock.yaml, and refuses a frozen " +
+			"install if the current settings differ.",
+	},
+	{
+		Code:  PnpmFetchNotFound,
+		Title: "a package was not found in the registry",
+		Hint:  "check the registry in NIX_NPM_REGISTRY and .npmrc; the package may be private or unpublished",
+		Explanation: "pnpm install failed with ERR_PNPM_FETCH_404. " +
+			"The registry answered 404 for a package tarball referenced by pnpm-lock.yaml.",
+	},
+	{
+		Code:  PnpmTarballIntegrity,
+		Title: "a package tarball does not match its integrity in pnpm-lock.yaml",
+		Hint: "the registry serves a different tarball than when pnpm-lock.yaml was created; " +
+			"check for mirrors or proxies that modify tarballs, or update the lockfile",
+		Explanation: "pnpm install failed with ERR_PNPM_TARBALL_INTEGRITY. " +
+			"The checksum of a downloaded tarball differs from the integrity recorded in pnpm-lock.yaml.",
+	},
+	{
+		Code:  PnpmNoMatchingVersion,
+		Title: "no version in the registry matches the requested range",
+		Hint:  "the configured registry does not provide a version referenced by pnpm-lock.yaml; check NIX_NPM_REGISTRY and .npmrc",
+		Explanation: "pnpm install failed with ERR_PNPM_NO_MATCHING_VERSION. " +
+			"This usually means that a different registry is used than when pnpm-lock.yaml was created.",
+	},
+	{
+		Code:  PnpmUnsupportedEngine,
+		Title: "the engines field of a project does not support the current environment",
+		Hint:  "use a pnpm (--pnpm-path) and Node.js version that satisfy the engines field of package.json",
+		Explanation: "pnpm install failed with ERR_PNPM_UNSUPPORTED_ENGINE. " +
+			"A project requires a pnpm or Node.js version in the engines field of its package.json " +
+			"that is not the one used for the install.",
+	},
+	{
+		Code:        PnpmOther,
+		Title:       "an unspecified pnpm error occurred",
+		Hint:        "rerun with -v and check the debug output",
+		Explanation: "Preparing the pnpm invocation failed, e.g. because the environment could not be read.",
+	},
+	{
+		Code:  PnpmPackageManagerMismatch,
+		Title: "pnpm version does not match the packageManager field",
+		Hint:  "pass --pnpm-path to the pnpm version pinned by the packageManager field of package.json",
+		Explanation: "The packageManager field of package.json pins a pnpm version whose major or minor version " +
+			"differs from the pnpm used for the install. " +
+			"pnpm does not switch to the pinned version, as manage-package-manager-versions is disabled " +
+			"so that pnpm does not download it, " +
+			"so another pnpm version may resolve or lay out the store differently than the project's.\n\n" +
+			"--pnpm-path can be given multiple times; the pnpm matching the packageManager field is preferred. " +
+			"Without --strict-package-manager a mismatch is a warning; with it the mismatch is fatal.",
+	},
+	{
+		Code:  NodeNotFound,
+		Title: "Node.js not found",
+		Hint:  "add node to PATH or pass --node-path, e.g. --node-path $(which node)",
+		Explanation: "pnpm runs on Node.js, but node is neither found on PATH nor at --node-path, " +
+			"or the file at --node-path is not executable or does not print its version.",
+	},
+	{
+		Code:  NodeUnsupported,
+		Title: "the Node.js version is not supported by pnpm",
+		Hint:  "pass --node-path to a Node.js version satisfying the range in the message, e.g. nodejs_22 from nixpkgs",
+		Explanation: "The Node.js version found on PATH or at --node-path does not satisfy the engines.node " +
+			"field of the package.json of pnpm, so pnpm would fail to start or crash. For pnpm without a " +
+			"package.json next to its script, such as native wrappers, the range of its version is used:\n\n" +
+			"| pnpm | engines.node |\n" +
+			"| ---- | ------------ |\n" +
+			"| 7 | >=14.6 |\n" +
+			"| 8 | >=16.14 |\n" +
+			"| 9, 10 | >=18.12 |\n\n" +
+			"A Node.js version outside the engines.node field of the project's package.json is only warned about.",
+	},
+	{
+		Code:  PnpmCorepackShim,
+		Title: "pnpm is a corepack shim without a cached pnpm",
+		Hint:  "run corepack install in the source directory, or pass --pnpm-path to a pnpm that is not a corepack shim",
+		Explanation: "The pnpm on PATH or at --pnpm-path is a corepack shim, which runs the pnpm version pinned by " +
+			"the packageManager field of package.json (or the last known good version of corepack) " +
+			"and downloads it on first use. A shim is resolved to the pnpm in the corepack cache " +
+			"(COREPACK_HOME, by default ~/.cache/node/corepack) if it is already there; " +
+			"otherwise running it is not hermetic.\n\n" +
+			"Without --strict-package-manager the shim is used with a warning; with it the error is fatal.",
+	},
+	{
+		Code:  PnpmSandboxUnavailable,
+		Title: "the sandbox is not available",
+		Hint:  "install bubblewrap (bwrap) and enable unprivileged user namespaces, or pass --sandbox=none",
+		Explanation: "--sandbox=bwrap runs pnpm and the pre-install commands in a bubblewrap sandbox, " +
+			"where only the source directory, the temporary pnpm store and temporary directories are writable " +
+			"and HOME is hidden. bwrap has to be on PATH and able to create a sandbox, " +
+			"which needs unprivileged user namespaces or a setuid bwrap.\n\n" +
+			"The run is not continued without the sandbox it was asked for.",
+	},
+	{
+		Code:  StoreCleanupFailed,
+		Title: "failed to cleanup store",
+		Hint:  "check the permissions of the temporary directory (TMPDIR)",
+		Explanation: "Temporary directories of pnpm in the store could not be removed before hashing, " +
+			"or the temporary store could not be removed afterwards.",
+	},
+	{
+		Code:        StoreCopyFailed,
+		Title:       "failed to copy store",
+		Hint:        "check the free space and the permissions of the temporary directory (TMPDIR)",
+		Explanation: "The input of hash or convert could not be copied to a temporary directory, or the destination already exists.",
+	},
+	{
+		Code:        TarballCreateFailed,
+		Title:       "failed to create tarball",
+		Hint:        "check the free space of the temporary directory (TMPDIR)",
+		Explanation: "The pnpm-store.tar.zst of a fetcher v3 output could not be written.",
+	},
+	{
+		Code:        StoreHashFailed,
+		Title:       "failed to hash store",
+		Hint:        "check the permissions of the temporary directory (TMPDIR)",
+		Explanation: "A file of the fetcher output could not be read while computing the NAR hash.",
+	},
+	{
+		Code:  NormalizeJSONFailed,
+		Title: "failed to normalize json",
+		Hint:  "rerun the prefetch; a corrupt index file is usually caused by an interrupted install",
+		Explanation: "A JSON file of the store, usually an index file, could not be read, parsed or rewritten " +
+			"while removing the fields that differ between installs.",
+	},
+	{
+		Code:        StoreReadFailed,
+		Title:       "failed to read store",
+		Hint:        "check that the path is a pnpm store, a fetcher output or a .nar file",
+		Explanation: "A pnpm store, fetcher output or NAR file could not be read.",
+	},
+	{
+		Code:        TarballReadFailed,
+		Title:       "failed to read tarball",
+		Hint:        "check that the file is a pnpm-store.tar.zst written by fetcher v3",
+		Explanation: "A pnpm-store.tar.zst is not a valid zstd compressed tar archive.",
+	},
+	{
+		Code:        SetPermissionsFailed,
+		Title:       "failed to set permissions",
+		Hint:        "check the permissions of the temporary directory (TMPDIR)",
+		Explanation: "Fetcher v2 and later make the store read-only before hashing; changing the mode of a file failed.",
+	},
+	{
+		Code:  IncompleteStore,
+		Title: "pnpm store is incomplete",
+		Hint:  "check --workspace and the --os/--cpu/--libc pnpm flags, or drop --strict",
+		Explanation: "With --strict, packages that pnpm-lock.yaml requires for the selected projects " +
+			"and platform are missing from the store after pnpm install.\n\n" +
+			"The hash of an incomplete store differs between machines, as the missing packages " +
+			"usually depend on the platform.",
+	},
+	{
+		Code:  IntegrityMismatch,
+		Title: "store integrity verification failed",
+		Hint:  "rerun the prefetch; if it fails again, check for mirrors or proxies that modify tarballs",
+		Explanation: "A file in the store does not match the checksum in its index file, or a package does " +
+			"not match its integrity in pnpm-lock.yaml. The store was corrupted during the install.\n\n" +
+			"The verification can be skipped with --no-verify.",
+	},
+	{
+		Code:  InvalidTarball,
+		Title: "invalid tarball",
+		Hint:  "recreate the tarball with convert --fetcher-version 3",
+		Explanation: "verify-tarball found entries in a pnpm-store.tar.zst that are not reproducible, " +
+			"e.g. non-zero timestamps, owners or unsorted entries.",
+	},
+	{
+		Code:  UnsupportedStoreLayout,
+		Title: "unsupported store layout",
+		Hint:  "use a pnpm version that writes a v3 or v10 store",
+		Explanation: "The store contains a store version directory other than v3 and v10, " +
+			"or the input is neither a directory, a .tar.zst file nor a .nar file.",
+	},
+	{
+		Code:  OutputNotEmpty,
+		Title: "output directory is not empty",
+		Hint:  "pass a directory that does not exist or is empty",
+		Explanation: "unpack only extracts a pnpm-store.tar.zst into a new or empty directory, " +
+			"so the files of the tarball are never mixed with existing ones.",
+	},
+	{
+		Code:        WriteOutputFailed,
+		Title:       "failed to write fetcher output",
+		Hint:        "check the free space and the permissions of the output directory",
+		Explanation: "The .fetcher-version file or the tarball of the fetcher output could not be written.",
+	},
+	{
+		Code:        UnsupportedFetcherVersion,
+		Title:       "unsupported fetcher version",
+		Hint:        "pass the fetcherVersion of your pnpm.fetchDeps call as --fetcher-version",
+		Explanation: "--fetcher-version is not a fetcher version known to this release.",
+	},
+	{
+		Code:  HashMismatch,
+		Title: "hash mismatch",
+		Hint:  "update the hash in your Nix expression to the computed one",
+		Explanation: "The computed hash differs from --hash. " +
+			"This is expected after the dependencies changed.\n\n" +
+			"If the dependencies did not change, run with --check-reproducible to find non-reproducible files.",
+	},
+	{
+		Code:  NotReproducible,
+		Title: "hashes differ between runs",
+		Hint:  "check the file-level report for the files that differ",
+		Explanation: "--check-reproducible installed the dependencies several times and the hashes differ. " +
+			"The report lists the files that differ between the first run and every other run.",
+	},
+}
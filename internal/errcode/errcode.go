@@ -0,0 +1,117 @@
+// Package errcode is the catalog of stable error codes. Every domain error type
+// has a code with a one-line hint and a long-form explanation, which are printed
+// by the explain subcommand and rendered to docs/errors.md.
+package errcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// docsURL is the page docs/errors.md is published at. Every code has an anchor.
+const docsURL = "https://github.com/cffnpwr/nix-prefetch-pnpm-deps/blob/main/docs/errors.md"
+
+// Code is a stable error code of the form NPPD-Ennn. Codes are never reused or renumbered.
+type Code string
+
+// Coded is implemented by errors with a catalog entry.
+type Coded interface {
+	error
+	Code() Code
+}
+
+// Entry describes an error code.
+type Entry struct {
+	Code        Code
+	Title       string // short description of the failure
+	Hint        string // one-line remediation
+	Explanation string // long-form explanation, paragraphs separated by blank lines
+}
+
+// Anchor returns the anchor of the code in docs/errors.md.
+func (c Code) Anchor() string {
+	return strings.ToLower(string(c))
+}
+
+// DocsURL returns the URL of the explanation of the code.
+func (c Code) DocsURL() string {
+	return docsURL + "#" + c.Anchor()
+}
+
+// Entry returns the catalog entry of the code. Codes without an entry get an
+// entry with only the code set.
+func (c Code) Entry() Entry {
+	for _, e := range catalog {
+		if e.Code == c {
+			return e
+		}
+	}
+	return Entry{Code: c}
+}
+
+// Hint returns the one-line hint of the code.
+func (c Code) Hint() string {
+	return c.Entry().Hint
+}
+
+// All returns all catalog entries ordered by code.
+func All() []Entry {
+	return append([]Entry(nil), catalog...)
+}
+
+// Lookup returns the catalog entry of code. The code is matched case-insensitively
+// and the NPPD- prefix may be omitted.
+func Lookup(code string) (Entry, bool) {
+	normalized := strings.ToUpper(strings.TrimSpace(code))
+	if !strings.HasPrefix(normalized, prefix) {
+		normalized = prefix + normalized
+	}
+
+	for _, e := range catalog {
+		if string(e.Code) == normalized {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Text returns the long-form explanation of the entry as plain text.
+func (e Entry) Text() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: %s\n\n", e.Code, e.Title)
+	fmt.Fprintf(&b, "%s\n", e.Explanation)
+	if e.Hint != "" {
+		fmt.Fprintf(&b, "\nhint: %s\n", e.Hint)
+	}
+	fmt.Fprintf(&b, "\nsee %s\n", e.Code.DocsURL())
+
+	return b.String()
+}
+
+// Markdown renders the catalog as the content of docs/errors.md.
+func Markdown() string {
+	var b strings.Builder
+
+	b.WriteString("# Error Codes\n\n")
+	b.WriteString("<!-- Generated from internal/errcode; run `go test ./internal/errcode -update` after changing the catalog. -->\n\n")
+	b.WriteString("Errors are reported with a stable code. ")
+	b.WriteString("Run `nix-prefetch-pnpm-deps explain <code>` to print an explanation offline.\n\n")
+
+	b.WriteString("| Code | Description |\n")
+	b.WriteString("| ---- | ----------- |\n")
+	for _, e := range catalog {
+		fmt.Fprintf(&b, "| [%s](#%s) | %s |\n", e.Code, e.Code.Anchor(), e.Title)
+	}
+
+	for _, e := range catalog {
+		fmt.Fprintf(&b, "\n## %s\n\n", e.Code)
+		fmt.Fprintf(&b, "**%s**\n\n", e.Title)
+		fmt.Fprintf(&b, "%s\n", e.Explanation)
+		if e.Hint != "" {
+			fmt.Fprintf(&b, "\n**Hint:** %s\n", e.Hint)
+		}
+	}
+
+	return b.String()
+}
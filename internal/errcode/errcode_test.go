@@ -0,0 +1,215 @@
+package errcode_test
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+	fetcher_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/fetcher/errors"
+	lockfile_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile/errors"
+	packagejson_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson/errors"
+	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+// update rewrites docs/errors.md: go test ./internal/errcode -update
+var update = flag.Bool("update", false, "update docs/errors.md from the catalog")
+
+const docsPath = "../../docs/errors.md"
+
+func Test_Lookup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		code   string
+		want   errcode.Code
+		wantOK bool
+	}{
+		{
+			name:   "[正常系] 完全なコード",
+			code:   "NPPD-E020",
+			want:   errcode.PnpmNotFound,
+			wantOK: true,
+		},
+		{
+			name:   "[正常系] 小文字",
+			code:   "nppd-e020",
+			want:   errcode.PnpmNotFound,
+			wantOK: true,
+		},
+		{
+			name:   "[正常系] プレフィックスなし",
+			code:   "E070",
+			want:   errcode.HashMismatch,
+			wantOK: true,
+		},
+		{
+			name:   "[異常系] 存在しないコード",
+			code:   "NPPD-E999",
+			wantOK: false,
+		},
+		{
+			name:   "[異常系] 空文字列",
+			code:   "",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := errcode.Lookup(tt.code)
+			if ok != tt.wantOK {
+				t.Fatalf("Lookup(%q) ok = %t, want %t", tt.code, ok, tt.wantOK)
+			}
+
+			if ok && got.Code != tt.want {
+				t.Errorf("Lookup(%q) = %s, want %s", tt.code, got.Code, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Catalog(t *testing.T) {
+	t.Parallel()
+
+	format := regexp.MustCompile(`^NPPD-E\d{3}$`)
+	var prev errcode.Code
+
+	for _, e := range errcode.All() {
+		t.Run("[正常系] "+string(e.Code), func(t *testing.T) {
+			t.Parallel()
+
+			if !format.MatchString(string(e.Code)) {
+				t.Errorf("code %q does not match %s", e.Code, format)
+			}
+
+			if e.Title == "" || e.Hint == "" || e.Explanation == "" {
+				t.Errorf("entry %s has an empty title, hint or explanation", e.Code)
+			}
+
+			if strings.Contains(e.Hint, "\n") {
+				t.Errorf("hint of %s is not a single line", e.Code)
+			}
+		})
+
+		if e.Code <= prev {
+			t.Errorf("catalog is not ordered by code: %s after %s", e.Code, prev)
+		}
+		prev = e.Code
+	}
+}
+
+// Test_Codes checks that the code of every domain error type has a catalog entry.
+func Test_Codes(t *testing.T) {
+	t.Parallel()
+
+	errs := []errcode.Coded{
+		&lockfile_err.LockfileNotFoundError{},
+		&lockfile_err.FailedToLoadError{},
+		&lockfile_err.FailedToParseError{},
+		&lockfile_err.UnsupportedVersionError{},
+		&lockfile_err.UnsupportedSelectorError{},
+		&packagejson_err.PackageJSONNotFoundError{},
+		&packagejson_err.FailedToParseError{},
+		&packagejson_err.InvalidPackageManagerError{},
+		&pnpm_err.PnpmNotFoundError{},
+		&pnpm_err.FailedToExecuteError{},
+		&pnpm_err.FailedToParseError{},
+		&pnpm_err.UnsupportedLockfileVersionError{},
+		&pnpm_err.OutdatedLockfileError{},
+		&pnpm_err.LockfileConfigMismatchError{},
+		&pnpm_err.FetchNotFoundError{},
+		&pnpm_err.TarballIntegrityError{},
+		&pnpm_err.NoMatchingVersionError{},
+		&pnpm_err.UnsupportedEngineError{},
+		&pnpm_err.OtherError{},
+		&pnpm_err.PackageManagerMismatchError{},
+		&pnpm_err.NodeNotFoundError{},
+		&pnpm_err.UnsupportedNodeError{},
+		&pnpm_err.CorepackShimError{},
+		&pnpm_err.SandboxUnavailableError{},
+		&store_err.FailedToCleanupError{},
+		&store_err.FailedToCopyError{},
+		&store_err.FailedToCreateTarballError{},
+		&store_err.FailedToHashError{},
+		&store_err.FailedToNormalizeJSONError{},
+		&store_err.FailedToReadStoreError{},
+		&store_err.FailedToReadTarballError{},
+		&store_err.FailedToSetPermissionsError{},
+		&store_err.IncompleteStoreError{},
+		&store_err.IntegrityMismatchError{},
+		&store_err.InvalidTarballError{},
+		&store_err.UnsupportedStoreLayoutError{},
+		&store_err.OutputNotEmptyError{},
+		&fetcher_err.FailedToWriteOutputError{},
+		&fetcher_err.UnsupportedVersionError{},
+	}
+
+	seen := map[errcode.Code]string{}
+	for _, err := range errs {
+		code := err.Code()
+
+		if _, ok := errcode.Lookup(string(code)); !ok {
+			t.Errorf("%T: code %s has no catalog entry", err, code)
+		}
+
+		name := fmt.Sprintf("%T", err)
+		if other, ok := seen[code]; ok {
+			t.Errorf("%s and %s share the code %s", other, name, code)
+		}
+		seen[code] = name
+	}
+}
+
+func Test_Markdown(t *testing.T) {
+	t.Parallel()
+
+	osFs := afero.NewOsFs()
+	got := errcode.Markdown()
+
+	if *update {
+		//nolint:mnd // docs permissions
+		if err := afero.WriteFile(osFs, docsPath, []byte(got), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", docsPath, err)
+		}
+	}
+
+	want, err := afero.ReadFile(osFs, docsPath)
+	if err != nil {
+		t.Fatalf("failed to read %s (run go test with -update to create it): %v", docsPath, err)
+	}
+
+	if string(want) != got {
+		t.Errorf("%s is out of date with the catalog; run go test ./internal/errcode -update", docsPath)
+	}
+
+	for _, e := range errcode.All() {
+		if !strings.Contains(got, "\n## "+string(e.Code)+"\n") {
+			t.Errorf("%s has no section for %s, so %s is a dead link", docsPath, e.Code, e.Code.DocsURL())
+		}
+	}
+}
+
+func Test_Entry_Text(t *testing.T) {
+	t.Parallel()
+
+	got := errcode.PnpmNotFound.Entry().Text()
+
+	for _, want := range []string{
+		"NPPD-E020: pnpm not found\n",
+		"\nhint: add pnpm to PATH",
+		"\nsee https://github.com/cffnpwr/nix-prefetch-pnpm-deps/blob/main/docs/errors.md#nppd-e020\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Text() = %q, want it to contain %q", got, want)
+		}
+	}
+}
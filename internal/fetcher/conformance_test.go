@@ -0,0 +1,224 @@
+package fetcher_test
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/fetcher"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+// update rewrites the expected outputs in testdata: go test ./internal/fetcher -update
+var update = flag.Bool("update", false, "update the conformance fixtures in testdata")
+
+// conformanceStore is a raw pnpm store fixture shared by all fetcher versions.
+const conformanceStore = "testdata/store"
+
+// loadFixtureStore copies the raw store fixture into an in-memory file system.
+func loadFixtureStore(t *testing.T) (afero.Fs, string) {
+	t.Helper()
+
+	osFs := afero.NewOsFs()
+	afs := afero.NewMemMapFs()
+
+	err := afero.Walk(osFs, conformanceStore, func(p string, info fs.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		rel, err := filepath.Rel(conformanceStore, p)
+		if err != nil {
+			return err
+		}
+
+		dst := filepath.Join("/store", rel)
+		if info.IsDir() {
+			return afs.MkdirAll(dst, 0o755)
+		}
+
+		data, err := afero.ReadFile(osFs, p)
+		if err != nil {
+			return err
+		}
+
+		return afero.WriteFile(afs, dst, data, info.Mode().Perm())
+	})
+	if err != nil {
+		t.Fatalf("failed to load %s: %v", conformanceStore, err)
+	}
+
+	return afs, "/store"
+}
+
+// readFixture returns the content of an expected output file, or rewrites it with got if -update is set.
+func readFixture(t *testing.T, path string, got string) string {
+	t.Helper()
+
+	osFs := afero.NewOsFs()
+
+	if *update {
+		if err := osFs.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create %s: %v", filepath.Dir(path), err)
+		}
+
+		if err := afero.WriteFile(osFs, path, []byte(got), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	data, err := afero.ReadFile(osFs, path)
+	if err != nil {
+		t.Fatalf("missing conformance fixture %s (run go test with -update to create it): %v", path, err)
+	}
+
+	return string(data)
+}
+
+// hashUnpackedOutput hashes a fetcher v3 output with its tarball unpacked into a
+// directory of the same name, after checking that the tarball is reproducible.
+func hashUnpackedOutput(t *testing.T, afs afero.Fs, outPath string) (string, *store.Manifest) {
+	t.Helper()
+
+	tarballPath := filepath.Join(outPath, store.TarballFileName)
+
+	report, err := store.VerifyTarball(afs, tarballPath)
+	if err != nil {
+		t.Fatalf("VerifyTarball() error: %v", err)
+	}
+
+	if len(report.Violations) > 0 {
+		t.Errorf("VerifyTarball() violations:\n  %s", strings.Join(report.Violations, "\n  "))
+	}
+
+	unpackedPath := "/unpacked"
+
+	version, readErr := afero.ReadFile(afs, filepath.Join(outPath, store.FetcherVersionFileName))
+	if readErr != nil {
+		t.Fatalf("failed to read %s: %v", store.FetcherVersionFileName, readErr)
+	}
+
+	if err := afs.MkdirAll(unpackedPath, 0o755); err != nil {
+		t.Fatalf("MkdirAll() error: %v", err)
+	}
+
+	if err := afero.WriteFile(afs, filepath.Join(unpackedPath, store.FetcherVersionFileName), version, 0o444); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+
+	if err := store.Unpack(afs, tarballPath, filepath.Join(unpackedPath, store.TarballFileName)); err != nil {
+		t.Fatalf("Unpack() error: %v", err)
+	}
+
+	hash, manifest, hashErr := store.HashTree(afs, unpackedPath, store.HashOptions{Manifest: true})
+	if hashErr != nil {
+		t.Fatalf("HashTree() error: %v", hashErr)
+	}
+
+	return hash, manifest
+}
+
+// Test_Conformance runs every registered fetcher version on the raw store fixture and
+// compares the output with testdata/v<version>/{hash,manifest.json}.
+func Test_Conformance(t *testing.T) {
+	t.Parallel()
+
+	for _, f := range fetcher.All() {
+		t.Run(fmt.Sprintf("[正常系] fetcher v%d", f.Version()), func(t *testing.T) {
+			t.Parallel()
+
+			afs, storePath := loadFixtureStore(t)
+			if err := f.Normalize(afs, storePath, nil); err != nil {
+				t.Fatalf("Normalize() error: %v", err)
+			}
+
+			outDir := storePath
+			if !f.StoreIsOutput() {
+				outDir = "/out"
+				if err := afs.MkdirAll(outDir, 0o755); err != nil {
+					t.Fatalf("MkdirAll() error: %v", err)
+				}
+			}
+
+			outPath, err := f.WriteOutput(afs, storePath, outDir, nil)
+			if err != nil {
+				t.Fatalf("WriteOutput() error: %v", err)
+			}
+
+			if outPath != outDir {
+				t.Errorf("WriteOutput() = %q, want %q", outPath, outDir)
+			}
+
+			// v1 outputs keep the writable permissions of the raw store and are only
+			// distinguished by the absence of temporary directories.
+			if f.Layout() != store.LayoutOutputV1 {
+				layout, layoutErr := store.DetectLayout(afs, outPath)
+				if layoutErr != nil {
+					t.Fatalf("DetectLayout() error: %v", layoutErr)
+				}
+
+				if layout != f.Layout() {
+					t.Errorf("DetectLayout() = %s, want %s", layout, f.Layout())
+				}
+			}
+
+			for _, dir := range []string{"tmp", "projects"} {
+				if exists, _ := afero.Exists(afs, filepath.Join(storePath, "v10", dir)); exists {
+					t.Errorf("v10/%s was not removed", dir)
+				}
+			}
+
+			var phases []string
+			hash, manifest, err := f.Hash(afs, outPath, store.HashOptions{
+				Manifest: true,
+				Progress: func(p store.Progress) { phases = append(phases, p.Phase) },
+			})
+			if err != nil {
+				t.Fatalf("Hash() error: %v", err)
+			}
+
+			if len(phases) == 0 || phases[0] != store.PhaseHash {
+				t.Errorf("Hash() reported phases %v, want %s", phases, store.PhaseHash)
+			}
+
+			plainHash, _, err := f.Hash(afs, outPath, store.HashOptions{})
+			if err != nil {
+				t.Fatalf("Hash() error: %v", err)
+			}
+
+			if plainHash != hash {
+				t.Errorf("Hash() without manifest = %s, with manifest = %s", plainHash, hash)
+			}
+
+			// The compressed bytes of a v3 tarball depend on the libzstd build, so the v3
+			// fixtures describe the output with the tarball unpacked, and VerifyTarball
+			// checks the tar headers that the unpacked tree does not record.
+			if f.Layout() == store.LayoutOutputV3 {
+				hash, manifest = hashUnpackedOutput(t, afs, outPath)
+			}
+
+			manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+			if err != nil {
+				t.Fatalf("failed to encode manifest: %v", err)
+			}
+
+			fixtureDir := filepath.Join("testdata", fmt.Sprintf("v%d", f.Version()))
+
+			wantManifest := readFixture(t, filepath.Join(fixtureDir, "manifest.json"), string(manifestJSON)+"\n")
+			if got := string(manifestJSON) + "\n"; got != wantManifest {
+				t.Errorf("manifest mismatch\nwant: %s\ngot:  %s", wantManifest, got)
+			}
+
+			wantHash := readFixture(t, filepath.Join(fixtureDir, "hash"), hash+"\n")
+			if strings.TrimSpace(wantHash) != hash {
+				t.Errorf("hash = %s, want %s", hash, strings.TrimSpace(wantHash))
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package fetcher_err
+
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type FailedToWriteOutputError struct{ baseError }
+
+var _ FetcherErrorIF = (*FailedToWriteOutputError)(nil)
+
+func (e *FailedToWriteOutputError) Error() string {
+	errMsg := "failed to write fetcher output"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = errMsg + "\ncaused by: " + e.Cause.Error()
+	}
+	return errMsg
+}
+
+func (e *FailedToWriteOutputError) Code() errcode.Code {
+	return errcode.WriteOutputFailed
+}
+
+func (e *FailedToWriteOutputError) Is(target error) bool {
+	_, ok := target.(*FailedToWriteOutputError)
+	return ok
+}
+
+func (e *FailedToWriteOutputError) As(target any) bool {
+	if t, ok := target.(**FailedToWriteOutputError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
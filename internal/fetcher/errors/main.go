@@ -0,0 +1,28 @@
+package fetcher_err
+
+import "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+
+type FetcherErrorIF interface {
+	error
+	Unwrap() error
+	Is(target error) bool
+	As(target any) bool
+
+	SetMessage(string)
+	SetCause(error)
+
+	// fetcherError marks the errors of this package, so that errors.As and type switches
+	// can tell them apart from the errors of other packages.
+	fetcherError()
+}
+
+func NewFetcherError(e FetcherErrorIF, message string, cause error) FetcherErrorIF {
+	e.SetMessage(message)
+	e.SetCause(cause)
+	return e
+}
+
+// baseError is embedded in every error type of this package to implement FetcherErrorIF.
+type baseError struct{ common.BaseError }
+
+func (baseError) fetcherError() {}
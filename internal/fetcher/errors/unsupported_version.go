@@ -0,0 +1,39 @@
+package fetcher_err
+
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type UnsupportedVersionError struct{ baseError }
+
+var _ FetcherErrorIF = (*UnsupportedVersionError)(nil)
+
+func (e *UnsupportedVersionError) Error() string {
+	errMsg := "unsupported fetcher version"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = errMsg + "\ncaused by: " + e.Cause.Error()
+	}
+	return errMsg
+}
+
+func (e *UnsupportedVersionError) Code() errcode.Code {
+	return errcode.UnsupportedFetcherVersion
+}
+
+func (e *UnsupportedVersionError) Is(target error) bool {
+	_, ok := target.(*UnsupportedVersionError)
+	return ok
+}
+
+func (e *UnsupportedVersionError) As(target any) bool {
+	if t, ok := target.(**UnsupportedVersionError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
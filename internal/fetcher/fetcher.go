@@ -0,0 +1,71 @@
+// Package fetcher implements the fetcher versions of nixpkgs' fetchPnpmDeps.
+//
+// A fetcher version defines how the pnpm store written by pnpm install is normalized,
+// how the fixed-output derivation output is laid out and how it is hashed.
+// Each version lives in its own file and registers itself in the registry;
+// adding a version means adding such a file and its conformance fixtures in testdata.
+package fetcher
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	fetcher_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/fetcher/errors"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+// Fetcher is a single fetcher version.
+type Fetcher interface {
+	// Version returns the fetcher version as passed to fetchPnpmDeps.
+	Version() int
+	// Description returns a one-line summary for --help.
+	Description() string
+
+	// Normalize prepares the pnpm store at storePath in place for hashing.
+	// progress receives the progress of the normalization steps if not nil.
+	Normalize(afs afero.Fs, storePath string, progress store.ProgressFunc) error
+
+	// Layout returns the layout of the output as detected by store.DetectLayout.
+	Layout() store.Layout
+	// StoreIsOutput reports whether the normalized store is the output itself.
+	// Otherwise WriteOutput builds the output in a separate directory.
+	StoreIsOutput() bool
+	// WriteOutput writes the output of a normalized store and returns its path.
+	// outDir must already exist; it is not used if StoreIsOutput is true.
+	WriteOutput(afs afero.Fs, storePath string, outDir string, progress store.ProgressFunc) (string, error)
+
+	// Hash returns the hash of an output written by WriteOutput and, if requested
+	// in opts, the manifest of the hashed tree.
+	Hash(afs afero.Fs, outputPath string, opts store.HashOptions) (string, *store.Manifest, error)
+}
+
+// narHasher hashes outputs as NAR archives, like Nix does for fixed-output derivations
+// with outputHashMode = "recursive".
+type narHasher struct{}
+
+func (narHasher) Hash(afs afero.Fs, outputPath string, opts store.HashOptions) (string, *store.Manifest, error) {
+	hash, manifest, err := store.HashTree(afs, outputPath, opts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return hash, manifest, nil
+}
+
+// writeFetcherVersion writes the read-only .fetcher-version file of version to dir.
+func writeFetcherVersion(afs afero.Fs, dir string, version int) error {
+	path := filepath.Join(dir, store.FetcherVersionFileName)
+
+	//nolint:mnd // read-only file permissions
+	if err := afero.WriteFile(afs, path, fmt.Appendf(nil, "%d\n", version), 0o444); err != nil {
+		return fetcher_err.NewFetcherError(
+			&fetcher_err.FailedToWriteOutputError{},
+			"failed to write "+store.FetcherVersionFileName,
+			err,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package fetcher
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	fetcher_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/fetcher/errors"
+)
+
+var registry = make(map[int]Fetcher)
+
+// register adds a fetcher version to the registry. It is called from the init
+// function of each version and panics on duplicate versions.
+func register(f Fetcher) {
+	if _, ok := registry[f.Version()]; ok {
+		panic(fmt.Sprintf("fetcher version %d is registered twice", f.Version()))
+	}
+
+	registry[f.Version()] = f
+}
+
+// Get returns the fetcher of the given version.
+func Get(version int) (Fetcher, fetcher_err.FetcherErrorIF) {
+	f, ok := registry[version]
+	if !ok {
+		return nil, fetcher_err.NewFetcherError(
+			&fetcher_err.UnsupportedVersionError{},
+			fmt.Sprintf("unsupported fetcher version %d (expected: %s)", version, VersionList()),
+			nil,
+		)
+	}
+
+	return f, nil
+}
+
+// All returns every registered fetcher, ordered by version.
+func All() []Fetcher {
+	fetchers := make([]Fetcher, 0, len(registry))
+	for _, version := range Versions() {
+		fetchers = append(fetchers, registry[version])
+	}
+
+	return fetchers
+}
+
+// Versions returns the registered fetcher versions in ascending order.
+func Versions() []int {
+	return slices.Sorted(maps.Keys(registry))
+}
+
+// VersionList returns the registered versions for messages, e.g. "1, 2, or 3".
+func VersionList() string {
+	versions := Versions()
+
+	names := make([]string, len(versions))
+	for i, version := range versions {
+		names[i] = strconv.Itoa(version)
+	}
+
+	if len(names) < 2 { //nolint:mnd // a single version needs no conjunction
+		return strings.Join(names, "")
+	}
+
+	return strings.Join(names[:len(names)-1], ", ") + ", or " + names[len(names)-1]
+}
@@ -0,0 +1,73 @@
+package fetcher_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/fetcher"
+	fetcher_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/fetcher/errors"
+)
+
+func Test_Get(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		version     int
+		wantVersion int
+		wantErr     fetcher_err.FetcherErrorIF
+	}{
+		{
+			name:        "[正常系] 登録済みのバージョン",
+			version:     3,
+			wantVersion: 3,
+		},
+		{
+			name:    "[異常系] 未登録のバージョン",
+			version: 0,
+			wantErr: &fetcher_err.UnsupportedVersionError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := fetcher.Get(tt.version)
+
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Errorf("Get() error = %v, wantErr %v", gotErr, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr == nil && got.Version() != tt.wantVersion {
+				t.Errorf("Get().Version() = %d, want %d", got.Version(), tt.wantVersion)
+			}
+		})
+	}
+}
+
+func Test_All(t *testing.T) {
+	t.Parallel()
+
+	versions := fetcher.Versions()
+	fetchers := fetcher.All()
+
+	if len(fetchers) != len(versions) {
+		t.Fatalf("len(All()) = %d, want %d", len(fetchers), len(versions))
+	}
+
+	for i, f := range fetchers {
+		if f.Version() != versions[i] {
+			t.Errorf("All()[%d].Version() = %d, want %d", i, f.Version(), versions[i])
+		}
+
+		if f.Description() == "" {
+			t.Errorf("fetcher v%d has no description", f.Version())
+		}
+	}
+
+	if got, want := fetcher.VersionList(), "1, 2, or 3"; got != want {
+		t.Errorf("VersionList() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,43 @@
+package fetcher
+
+import (
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+func init() {
+	register(v1{})
+}
+
+// v1 is the first fetcher version. The output is the store with temporary directories
+// removed and JSON files normalized; permissions are kept as pnpm wrote them.
+type v1 struct{ narHasher }
+
+func (v1) Version() int {
+	return 1
+}
+
+func (v1) Description() string {
+	return "First version. Here to preserve backwards compatibility"
+}
+
+func (v1) Normalize(afs afero.Fs, storePath string, progress store.ProgressFunc) error {
+	if err := store.Normalize(afs, store.NormalizeOptions{StorePath: storePath, Progress: progress}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (v1) Layout() store.Layout {
+	return store.LayoutOutputV1
+}
+
+func (v1) StoreIsOutput() bool {
+	return true
+}
+
+func (v1) WriteOutput(_ afero.Fs, storePath string, _ string, _ store.ProgressFunc) (string, error) {
+	return storePath, nil
+}
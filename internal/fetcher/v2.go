@@ -0,0 +1,53 @@
+package fetcher
+
+import (
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+func init() {
+	register(v2{})
+}
+
+// v2 additionally normalizes permissions and records .fetcher-version in the store.
+// See https://github.com/NixOS/nixpkgs/pull/422975
+type v2 struct{ narHasher }
+
+func (v2) Version() int {
+	return 2 //nolint:mnd // fetcher version
+}
+
+func (v2) Description() string {
+	return "Ensure consistent permissions. See https://github.com/NixOS/nixpkgs/pull/422975"
+}
+
+func (f v2) Normalize(afs afero.Fs, storePath string, progress store.ProgressFunc) error {
+	// Written before the permissions are set, as they make the store read-only.
+	if err := writeFetcherVersion(afs, storePath, f.Version()); err != nil {
+		return err
+	}
+
+	normalizeErr := store.Normalize(afs, store.NormalizeOptions{
+		StorePath:      storePath,
+		SetPermissions: true,
+		Progress:       progress,
+	})
+	if normalizeErr != nil {
+		return normalizeErr
+	}
+
+	return nil
+}
+
+func (v2) Layout() store.Layout {
+	return store.LayoutOutputV2
+}
+
+func (v2) StoreIsOutput() bool {
+	return true
+}
+
+func (v2) WriteOutput(_ afero.Fs, storePath string, _ string, _ store.ProgressFunc) (string, error) {
+	return storePath, nil
+}
@@ -0,0 +1,65 @@
+package fetcher
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+func init() {
+	register(v3{})
+}
+
+// v3 normalizes the store like v2 and packs it into a reproducible tarball.
+// The output holds .fetcher-version and pnpm-store.tar.zst.
+// See https://github.com/NixOS/nixpkgs/pull/469950
+type v3 struct{ narHasher }
+
+func (v3) Version() int {
+	return 3 //nolint:mnd // fetcher version
+}
+
+func (v3) Description() string {
+	return "Build a reproducible tarball. See https://github.com/NixOS/nixpkgs/pull/469950"
+}
+
+func (v3) Normalize(afs afero.Fs, storePath string, progress store.ProgressFunc) error {
+	normalizeErr := store.Normalize(afs, store.NormalizeOptions{
+		StorePath:      storePath,
+		SetPermissions: true,
+		Progress:       progress,
+	})
+	if normalizeErr != nil {
+		return normalizeErr
+	}
+
+	return nil
+}
+
+func (v3) Layout() store.Layout {
+	return store.LayoutOutputV3
+}
+
+func (v3) StoreIsOutput() bool {
+	return false
+}
+
+func (f v3) WriteOutput(
+	afs afero.Fs,
+	storePath string,
+	outDir string,
+	progress store.ProgressFunc,
+) (string, error) {
+	if err := writeFetcherVersion(afs, outDir, f.Version()); err != nil {
+		return "", err
+	}
+
+	tarballPath := filepath.Join(outDir, store.TarballFileName)
+	if err := store.CreateTarball(afs, storePath, tarballPath, progress); err != nil {
+		return "", err
+	}
+
+	return outDir, nil
+}
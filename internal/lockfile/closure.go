@@ -0,0 +1,155 @@
+package lockfile
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	lockfile_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile/errors"
+)
+
+// lockfileVersionWithSnapshots is the first lockfile major version that records
+// package dependencies in "snapshots" instead of "packages".
+const lockfileVersionWithSnapshots = 9
+
+// linkPrefix marks importer dependencies on other workspace projects.
+const linkPrefix = "link:"
+
+// ImporterIDs returns the paths of all projects in the lockfile, sorted.
+// Lockfiles without "importers" have a single root project ".".
+func (l *Lockfile) ImporterIDs() []string {
+	return slices.Sorted(maps.Keys(l.importers()))
+}
+
+func (l *Lockfile) importers() map[string]Importer {
+	if len(l.Importers) > 0 {
+		return l.Importers
+	}
+
+	return map[string]Importer{".": l.Importer}
+}
+
+// DependencyClosure returns every package installed for the given importers, keyed by
+// "name@version" and mapped to its resolution integrity ("" if it has none).
+// Optional packages that do not support platform are skipped with their dependencies,
+// like pnpm does. Workspace links are not followed; select linked projects explicitly.
+func (l *Lockfile) DependencyClosure(
+	importerIDs []string,
+	platform Platform,
+) (map[string]string, lockfile_err.LockfileErrorIF) {
+	major, err := l.MajorVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	if major < lockfileVersionWithAtKeys {
+		return nil, lockfile_err.NewLockfileError(
+			&lockfile_err.UnsupportedVersionError{},
+			"lockfileVersion "+l.LockfileVersion+" is not supported, 6.0 or later is required",
+			nil,
+		)
+	}
+
+	importers := l.importers()
+	closure := make(map[string]string)
+	visited := make(map[string]bool)
+
+	var queue []string
+
+	enqueue := func(name string, version string) {
+		if strings.HasPrefix(version, linkPrefix) {
+			return
+		}
+
+		key := dependencyKey(name, version, major)
+		if !visited[key] {
+			visited[key] = true
+			queue = append(queue, key)
+		}
+	}
+
+	for _, id := range importerIDs {
+		importer := importers[id]
+		for _, deps := range []map[string]ImporterDependency{
+			importer.Dependencies,
+			importer.DevDependencies,
+			importer.OptionalDependencies,
+		} {
+			for name, dep := range deps {
+				enqueue(name, dep.Version)
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+
+		pkgKey, pkg, deps, optional := l.lookupPackage(key, major)
+		if optional && !platform.Supports(pkg) {
+			continue
+		}
+
+		id := pkgKey
+		if name, version, ok := ParsePackageKey(pkgKey, major); ok {
+			id = name + "@" + version
+		}
+
+		closure[id] = pkg.Resolution.Integrity
+
+		for _, depMap := range deps {
+			for name, version := range depMap {
+				enqueue(name, version)
+			}
+		}
+	}
+
+	return closure, nil
+}
+
+// lookupPackage returns the "packages" key and entry of a dependency key, the
+// dependencies of the package and whether it is optional.
+func (l *Lockfile) lookupPackage(key string, major int) (string, PackageEntry, []map[string]string, bool) {
+	if major >= lockfileVersionWithSnapshots {
+		pkgKey := stripPeerSuffix(key)
+		snapshot := l.Snapshots[key]
+
+		return pkgKey, l.Packages[pkgKey], []map[string]string{
+			snapshot.Dependencies,
+			snapshot.OptionalDependencies,
+		}, snapshot.Optional
+	}
+
+	pkg := l.Packages[key]
+
+	return key, pkg, []map[string]string{pkg.Dependencies, pkg.OptionalDependencies}, pkg.Optional
+}
+
+// dependencyKey returns the snapshot (v9+) or package (v6, v7) key of a dependency.
+// Aliased dependencies ("npm:other@1.0.0") record the full key as their version.
+func dependencyKey(name string, version string, major int) string {
+	base := stripPeerSuffix(version)
+
+	if major >= lockfileVersionWithSnapshots {
+		if strings.Contains(base, "@") {
+			return version
+		}
+
+		return name + "@" + version
+	}
+
+	if strings.HasPrefix(base, "/") || strings.HasPrefix(base, "file:") {
+		return version
+	}
+
+	return "/" + name + "@" + version
+}
+
+// stripPeerSuffix removes the "(peer@version)" suffix of a key or version.
+func stripPeerSuffix(s string) string {
+	if i := strings.Index(s, "("); i >= 0 {
+		return s[:i]
+	}
+
+	return s
+}
@@ -0,0 +1,141 @@
+package lockfile_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile"
+	lockfile_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile/errors"
+)
+
+const workspaceLockfileV9 = `lockfileVersion: '9.0'
+importers:
+  .:
+    devDependencies:
+      typescript: {specifier: ^5.0.0, version: 5.0.0}
+  packages/a:
+    dependencies:
+      b: {specifier: workspace:*, version: link:../b}
+      foo: {specifier: ^1.0.0, version: 1.0.0(bar@2.0.0)}
+      alias: {specifier: npm:baz@^3.0.0, version: baz@3.0.0}
+  packages/b:
+    dependencies:
+      bar: {specifier: ^2.0.0, version: 2.0.0}
+packages:
+  typescript@5.0.0:
+    resolution: {integrity: sha512-ts}
+  foo@1.0.0:
+    resolution: {integrity: sha512-foo}
+  bar@2.0.0:
+    resolution: {integrity: sha512-bar}
+  baz@3.0.0:
+    resolution: {integrity: sha512-baz}
+  native-darwin@1.0.0:
+    resolution: {integrity: sha512-darwin}
+    os: [darwin]
+  native-linux@1.0.0:
+    resolution: {integrity: sha512-linux}
+    os: [linux]
+    cpu: [x64]
+snapshots:
+  typescript@5.0.0: {}
+  foo@1.0.0(bar@2.0.0):
+    dependencies:
+      bar: 2.0.0
+    optionalDependencies:
+      native-darwin: 1.0.0
+      native-linux: 1.0.0
+  bar@2.0.0: {}
+  baz@3.0.0: {}
+  native-darwin@1.0.0:
+    optional: true
+  native-linux@1.0.0:
+    optional: true
+`
+
+func Test_DependencyClosure(t *testing.T) {
+	t.Parallel()
+
+	linuxX64 := lockfile.Platform{OS: []string{"linux"}, CPU: []string{"x64"}}
+
+	tests := []struct {
+		name      string
+		data      string
+		importers []string
+		platform  lockfile.Platform
+		want      map[string]string
+		wantErr   lockfile_err.LockfileErrorIF
+	}{
+		{
+			name:      "[正常系] v9: 選択したimporterの依存のみ含まれプラットフォーム外のoptional依存は除外される",
+			data:      workspaceLockfileV9,
+			importers: []string{"packages/a"},
+			platform:  linuxX64,
+			want: map[string]string{
+				"foo@1.0.0":          "sha512-foo",
+				"bar@2.0.0":          "sha512-bar",
+				"baz@3.0.0":          "sha512-baz",
+				"native-linux@1.0.0": "sha512-linux",
+			},
+		},
+		{
+			name:      "[正常系] v9: 複数のプラットフォームを指定するとそれぞれのoptional依存が含まれる",
+			data:      workspaceLockfileV9,
+			importers: []string{"."},
+			platform:  lockfile.Platform{OS: []string{"darwin"}, CPU: []string{"arm64"}},
+			want: map[string]string{
+				"typescript@5.0.0": "sha512-ts",
+			},
+		},
+		{
+			name: "[正常系] v6: packagesに依存関係が記録されている",
+			data: `lockfileVersion: '6.0'
+dependencies:
+  foo:
+    specifier: ^1.0.0
+    version: 1.0.0
+packages:
+  /foo@1.0.0:
+    resolution: {integrity: sha512-foo}
+    dependencies:
+      bar: 2.0.0
+  /bar@2.0.0:
+    resolution: {integrity: sha512-bar}
+`,
+			importers: []string{"."},
+			platform:  linuxX64,
+			want: map[string]string{
+				"foo@1.0.0": "sha512-foo",
+				"bar@2.0.0": "sha512-bar",
+			},
+		},
+		{
+			name:      "[異常系] v5のlockfileはサポートされない",
+			data:      "lockfileVersion: 5.4\ndependencies:\n  foo: 1.0.0\n",
+			importers: []string{"."},
+			wantErr:   &lockfile_err.UnsupportedVersionError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			l, parseErr := lockfile.Parse([]byte(tt.data))
+			if parseErr != nil {
+				t.Fatalf("Parse() error = %v", parseErr)
+			}
+
+			got, gotErr := l.DependencyClosure(tt.importers, tt.platform)
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("DependencyClosure() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("DependencyClosure() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
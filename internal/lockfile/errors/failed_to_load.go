@@ -1,8 +1,10 @@
 package lockfile_err
 
-import "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
 
-type FailedToLoadError struct{ common.BaseError }
+type FailedToLoadError struct{ baseError }
 
 var _ LockfileErrorIF = (*FailedToLoadError)(nil)
 
@@ -19,6 +21,10 @@ func (e *FailedToLoadError) Error() string {
 	return errMsg
 }
 
+func (e *FailedToLoadError) Code() errcode.Code {
+	return errcode.LockfileLoadFailed
+}
+
 func (e *FailedToLoadError) Is(target error) bool {
 	_, ok := target.(*FailedToLoadError)
 	return ok
@@ -1,5 +1,7 @@
 package lockfile_err
 
+import "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+
 type LockfileErrorIF interface {
 	error
 	Unwrap() error
@@ -8,6 +10,10 @@ type LockfileErrorIF interface {
 
 	SetMessage(string)
 	SetCause(error)
+
+	// lockfileError marks the errors of this package, so that errors.As and type switches
+	// can tell them apart from the errors of other packages.
+	lockfileError()
 }
 
 func NewLockfileError(e LockfileErrorIF, message string, cause error) LockfileErrorIF {
@@ -15,3 +21,8 @@ func NewLockfileError(e LockfileErrorIF, message string, cause error) LockfileEr
 	e.SetCause(cause)
 	return e
 }
+
+// baseError is embedded in every error type of this package to implement LockfileErrorIF.
+type baseError struct{ common.BaseError }
+
+func (baseError) lockfileError() {}
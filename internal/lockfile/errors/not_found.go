@@ -3,10 +3,10 @@ package lockfile_err
 import (
 	"fmt"
 
-	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
 )
 
-type LockfileNotFoundError struct{ common.BaseError }
+type LockfileNotFoundError struct{ baseError }
 
 var _ LockfileErrorIF = (*LockfileNotFoundError)(nil)
 
@@ -22,6 +22,10 @@ func (e *LockfileNotFoundError) Error() string {
 	return errMsg
 }
 
+func (e *LockfileNotFoundError) Code() errcode.Code {
+	return errcode.LockfileNotFound
+}
+
 func (e *LockfileNotFoundError) Is(target error) bool {
 	_, ok := target.(*LockfileNotFoundError)
 	return ok
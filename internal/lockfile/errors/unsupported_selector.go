@@ -0,0 +1,39 @@
+package lockfile_err
+
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type UnsupportedSelectorError struct{ baseError }
+
+var _ LockfileErrorIF = (*UnsupportedSelectorError)(nil)
+
+func (e *UnsupportedSelectorError) Error() string {
+	errMsg := "unsupported workspace selector"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = errMsg + "\ncaused by: " + e.Cause.Error()
+	}
+	return errMsg
+}
+
+func (e *UnsupportedSelectorError) Code() errcode.Code {
+	return errcode.UnsupportedSelector
+}
+
+func (e *UnsupportedSelectorError) Is(target error) bool {
+	_, ok := target.(*UnsupportedSelectorError)
+	return ok
+}
+
+func (e *UnsupportedSelectorError) As(target any) bool {
+	if t, ok := target.(**UnsupportedSelectorError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package lockfile_err
+
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type UnsupportedVersionError struct{ baseError }
+
+var _ LockfileErrorIF = (*UnsupportedVersionError)(nil)
+
+func (e *UnsupportedVersionError) Error() string {
+	errMsg := "unsupported lockfile version"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = errMsg + "\ncaused by: " + e.Cause.Error()
+	}
+	return errMsg
+}
+
+func (e *UnsupportedVersionError) Code() errcode.Code {
+	return errcode.LockfileUnsupportedVersion
+}
+
+func (e *UnsupportedVersionError) Is(target error) bool {
+	_, ok := target.(*UnsupportedVersionError)
+	return ok
+}
+
+func (e *UnsupportedVersionError) As(target any) bool {
+	if t, ok := target.(**UnsupportedVersionError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package lockfile
+
+import (
+	"strings"
+
+	lockfile_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile/errors"
+)
+
+// lockfileVersionWithAtKeys is the first lockfile major version whose package keys
+// use "name@version" instead of "/name/version".
+const lockfileVersionWithAtKeys = 6
+
+// PackageIntegrities returns the resolution integrity of every package in the lockfile,
+// keyed by "name@version". Packages without an integrity (e.g. git dependencies) are omitted.
+func (l *Lockfile) PackageIntegrities() (map[string]string, lockfile_err.LockfileErrorIF) {
+	major, err := l.MajorVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	integrities := make(map[string]string, len(l.Packages))
+	for key, pkg := range l.Packages {
+		if pkg.Resolution.Integrity == "" {
+			continue
+		}
+
+		name, version, ok := ParsePackageKey(key, major)
+		if !ok {
+			continue
+		}
+
+		integrities[name+"@"+version] = pkg.Resolution.Integrity
+	}
+
+	return integrities, nil
+}
+
+// ParsePackageKey splits a key of the "packages" section into the package name and version.
+// Leading slashes and peer dependency suffixes ("(peer@1.0.0)" or "_peer@1.0.0") are removed.
+// Lockfiles before v6 use "/name/version" keys, later ones "name@version".
+func ParsePackageKey(key string, lockfileMajorVersion int) (string, string, bool) {
+	key = strings.TrimPrefix(key, "/")
+	if i := strings.Index(key, "("); i >= 0 {
+		key = key[:i]
+	}
+
+	if lockfileMajorVersion >= lockfileVersionWithAtKeys {
+		at := strings.LastIndex(key, "@")
+		if at <= 0 || at == len(key)-1 {
+			return "", "", false
+		}
+
+		return key[:at], key[at+1:], true
+	}
+
+	// "/name/version" or "/@scope/name/version", optionally followed by "_peer@version"
+	segments := 2
+	if strings.HasPrefix(key, "@") {
+		segments = 3
+	}
+
+	parts := strings.SplitN(key, "/", segments)
+	if len(parts) != segments || parts[segments-1] == "" {
+		return "", "", false
+	}
+
+	version, _, _ := strings.Cut(parts[segments-1], "_")
+
+	return strings.Join(parts[:segments-1], "/"), version, true
+}
@@ -0,0 +1,139 @@
+package lockfile_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile"
+	lockfile_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile/errors"
+)
+
+func Test_ParsePackageKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		key         string
+		major       int
+		wantName    string
+		wantVersion string
+		wantOk      bool
+	}{
+		{
+			name:        "[正常系] v9形式",
+			key:         "foo@1.0.0",
+			major:       9,
+			wantName:    "foo",
+			wantVersion: "1.0.0",
+			wantOk:      true,
+		},
+		{
+			name:        "[正常系] v9形式のスコープ付きパッケージ",
+			key:         "@scope/foo@1.0.0",
+			major:       9,
+			wantName:    "@scope/foo",
+			wantVersion: "1.0.0",
+			wantOk:      true,
+		},
+		{
+			name:        "[正常系] v6形式のpeer依存付きキー",
+			key:         "/foo@1.0.0(bar@2.0.0)",
+			major:       6,
+			wantName:    "foo",
+			wantVersion: "1.0.0",
+			wantOk:      true,
+		},
+		{
+			name:        "[正常系] v5形式",
+			key:         "/foo/1.0.0",
+			major:       5,
+			wantName:    "foo",
+			wantVersion: "1.0.0",
+			wantOk:      true,
+		},
+		{
+			name:        "[正常系] v5形式のスコープ付きパッケージとpeer依存",
+			key:         "/@scope/foo/1.0.0_bar@2.0.0",
+			major:       5,
+			wantName:    "@scope/foo",
+			wantVersion: "1.0.0",
+			wantOk:      true,
+		},
+		{
+			name:   "[異常系] バージョンがないキー",
+			key:    "@scope/foo",
+			major:  9,
+			wantOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotName, gotVersion, gotOk := lockfile.ParsePackageKey(tt.key, tt.major)
+			if gotName != tt.wantName || gotVersion != tt.wantVersion || gotOk != tt.wantOk {
+				t.Errorf(
+					"ParsePackageKey() = (%q, %q, %t), want (%q, %q, %t)",
+					gotName, gotVersion, gotOk,
+					tt.wantName, tt.wantVersion, tt.wantOk,
+				)
+			}
+		})
+	}
+}
+
+func Test_PackageIntegrities(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]string
+		wantErr lockfile_err.LockfileErrorIF
+	}{
+		{
+			name: "[正常系] integrityを持つパッケージのみ返される",
+			data: `lockfileVersion: '9.0'
+packages:
+  foo@1.0.0:
+    resolution: {integrity: sha512-foo}
+  '@scope/bar@2.0.0':
+    resolution: {integrity: sha512-bar}
+  baz@git+https://example.com/baz.git#abc:
+    resolution: {tarball: https://example.com/baz.tgz}
+`,
+			want: map[string]string{
+				"foo@1.0.0":        "sha512-foo",
+				"@scope/bar@2.0.0": "sha512-bar",
+			},
+		},
+		{
+			name:    "[異常系] 不正なlockfileVersion",
+			data:    "lockfileVersion: 'abc'",
+			wantErr: &lockfile_err.FailedToParseError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			l, parseErr := lockfile.Parse([]byte(tt.data))
+			if parseErr != nil {
+				t.Fatalf("Parse() error = %v", parseErr)
+			}
+
+			got, gotErr := l.PackageIntegrities()
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("PackageIntegrities() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("PackageIntegrities() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -9,7 +9,73 @@ import (
 )
 
 type Lockfile struct {
-	LockfileVersion string `yaml:"lockfileVersion"`
+	LockfileVersion string                   `yaml:"lockfileVersion"`
+	Settings        *Settings                `yaml:"settings,omitempty"`
+	Importers       map[string]Importer      `yaml:"importers,omitempty"`
+	Packages        map[string]PackageEntry  `yaml:"packages,omitempty"`
+	Snapshots       map[string]SnapshotEntry `yaml:"snapshots,omitempty"`
+
+	// Lockfiles of projects without a workspace before v9 list the dependencies of the
+	// root project at the top level instead of in "importers".
+	Importer `yaml:",inline"`
+}
+
+// Settings is the "settings" section (lockfile v6+), recording the pnpm settings the
+// lockfile was resolved with. pnpm refuses a frozen install if its settings differ.
+type Settings struct {
+	AutoInstallPeers         *bool `yaml:"autoInstallPeers,omitempty"`
+	ExcludeLinksFromLockfile *bool `yaml:"excludeLinksFromLockfile,omitempty"`
+}
+
+// Importer is a project of the workspace, keyed by its path relative to the lockfile.
+type Importer struct {
+	Dependencies         map[string]ImporterDependency `yaml:"dependencies,omitempty"`
+	DevDependencies      map[string]ImporterDependency `yaml:"devDependencies,omitempty"`
+	OptionalDependencies map[string]ImporterDependency `yaml:"optionalDependencies,omitempty"`
+}
+
+// ImporterDependency is a direct dependency of an importer.
+// Lockfiles before v6 record only the version as a plain string.
+type ImporterDependency struct {
+	Specifier string `yaml:"specifier,omitempty"`
+	Version   string `yaml:"version"`
+}
+
+func (d *ImporterDependency) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		d.Version = node.Value
+		return nil
+	}
+
+	type plain ImporterDependency
+
+	return node.Decode((*plain)(d))
+}
+
+// PackageEntry is a single entry of the "packages" section.
+// Before lockfile v9, the dependencies of a package are recorded here as well.
+type PackageEntry struct {
+	Resolution           Resolution        `yaml:"resolution"`
+	Dependencies         map[string]string `yaml:"dependencies,omitempty"`
+	OptionalDependencies map[string]string `yaml:"optionalDependencies,omitempty"`
+	Optional             bool              `yaml:"optional,omitempty"`
+	OS                   []string          `yaml:"os,omitempty"`
+	CPU                  []string          `yaml:"cpu,omitempty"`
+	Libc                 []string          `yaml:"libc,omitempty"`
+}
+
+// SnapshotEntry is a single entry of the "snapshots" section (lockfile v9+),
+// keyed by the package key with its peer dependency suffix.
+type SnapshotEntry struct {
+	Dependencies         map[string]string `yaml:"dependencies,omitempty"`
+	OptionalDependencies map[string]string `yaml:"optionalDependencies,omitempty"`
+	Optional             bool              `yaml:"optional,omitempty"`
+}
+
+// Resolution describes where a package was resolved from.
+type Resolution struct {
+	Integrity string `yaml:"integrity,omitempty"`
+	Tarball   string `yaml:"tarball,omitempty"`
 }
 
 func Parse(data []byte) (*Lockfile, lockfile_err.LockfileErrorIF) {
@@ -56,15 +122,25 @@ func Load(fs afero.Fs, path string) (*Lockfile, lockfile_err.LockfileErrorIF) {
 	return Parse(data)
 }
 
-func (l *Lockfile) MajorVersion() (int, lockfile_err.LockfileErrorIF) {
-	major, err := common.MajorVersion(l.LockfileVersion)
+// Version returns the parsed lockfileVersion.
+func (l *Lockfile) Version() (common.Version, lockfile_err.LockfileErrorIF) {
+	v, err := common.ParseVersion(l.LockfileVersion)
 	if err != nil {
-		return 0, lockfile_err.NewLockfileError(
+		return common.Version{}, lockfile_err.NewLockfileError(
 			&lockfile_err.FailedToParseError{},
 			"invalid lockfile version format: "+l.LockfileVersion,
 			err,
 		)
 	}
 
-	return major, nil
+	return v, nil
+}
+
+func (l *Lockfile) MajorVersion() (int, lockfile_err.LockfileErrorIF) {
+	v, err := l.Version()
+	if err != nil {
+		return 0, err
+	}
+
+	return v.Major, nil
 }
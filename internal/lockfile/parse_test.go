@@ -14,6 +14,8 @@ import (
 func Test_Parse(t *testing.T) {
 	t.Parallel()
 
+	yes, no := true, false
+
 	tests := []struct {
 		name    string
 		data    []byte
@@ -30,9 +32,20 @@ func Test_Parse(t *testing.T) {
 			data: []byte("lockfileVersion: '6.1'"),
 			want: &lockfile.Lockfile{LockfileVersion: "6.1"},
 		},
+		{
+			name: "[正常系] settings",
+			data: []byte("lockfileVersion: '9.0'\nsettings:\n  autoInstallPeers: true\n  excludeLinksFromLockfile: false"),
+			want: &lockfile.Lockfile{
+				LockfileVersion: "9.0",
+				Settings: &lockfile.Settings{
+					AutoInstallPeers:         &yes,
+					ExcludeLinksFromLockfile: &no,
+				},
+			},
+		},
 		{
 			name: "[正常系] 他のフィールドがあっても無視",
-			data: []byte("lockfileVersion: '9.0'\nsettings:\n  autoInstallPeers: true"),
+			data: []byte("lockfileVersion: '9.0'\noverrides:\n  foo: 1.0.0"),
 			want: &lockfile.Lockfile{LockfileVersion: "9.0"},
 		},
 		{
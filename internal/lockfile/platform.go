@@ -0,0 +1,92 @@
+package lockfile
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// Platform is the set of operating systems, CPU architectures and libc implementations
+// that optional dependencies are installed for (pnpm's supportedArchitectures).
+type Platform struct {
+	OS   []string
+	CPU  []string
+	Libc []string // not checked if empty
+}
+
+// nodePlatforms maps GOOS to the values of Node.js' process.platform.
+var nodePlatforms = map[string]string{"windows": "win32"}
+
+// nodeArchs maps GOARCH to the values of Node.js' process.arch.
+var nodeArchs = map[string]string{"amd64": "x64", "386": "ia32"}
+
+// muslLoaderPattern matches the dynamic loader of musl based systems, e.g. /lib/ld-musl-x86_64.so.1.
+const muslLoaderPattern = "/lib/ld-musl-*.so.1"
+
+// CurrentPlatform returns the platform pnpm installs optional dependencies for by default.
+// On linux, the libc is musl if afs has the musl dynamic loader, and glibc otherwise.
+func CurrentPlatform(afs afero.Fs) Platform {
+	osName, ok := nodePlatforms[runtime.GOOS]
+	if !ok {
+		osName = runtime.GOOS
+	}
+
+	cpu, ok := nodeArchs[runtime.GOARCH]
+	if !ok {
+		cpu = runtime.GOARCH
+	}
+
+	p := Platform{OS: []string{osName}, CPU: []string{cpu}}
+	if runtime.GOOS == "linux" {
+		p.Libc = []string{currentLibc(afs)}
+	}
+
+	return p
+}
+
+// currentLibc returns the libc implementation of a linux system.
+func currentLibc(afs afero.Fs) string {
+	if loaders, err := afero.Glob(afs, muslLoaderPattern); err == nil && len(loaders) > 0 {
+		return "musl"
+	}
+
+	return "glibc"
+}
+
+// Supports reports whether a package restricted to the os, cpu and libc of pkg
+// is installed on this platform.
+func (p Platform) Supports(pkg PackageEntry) bool {
+	if !matchPlatformList(p.OS, pkg.OS) || !matchPlatformList(p.CPU, pkg.CPU) {
+		return false
+	}
+
+	return len(p.Libc) == 0 || matchPlatformList(p.Libc, pkg.Libc)
+}
+
+// matchPlatformList implements pnpm's checkList: a package list matches if it is empty,
+// is "any", contains one of the values, or consists only of negations ("!value") that
+// exclude none of the values.
+func matchPlatformList(values []string, list []string) bool {
+	if len(list) == 0 || (len(list) == 1 && list[0] == "any") {
+		return true
+	}
+
+	match := false
+	negations := 0
+
+	for _, value := range values {
+		for _, item := range list {
+			if negated, ok := strings.CutPrefix(item, "!"); ok {
+				if negated == value {
+					return false
+				}
+				negations++
+			} else if item == value {
+				match = true
+			}
+		}
+	}
+
+	return match || negations == len(list)*len(values)
+}
@@ -0,0 +1,106 @@
+package lockfile_test
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile"
+)
+
+func Test_PlatformSupports(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		platform lockfile.Platform
+		pkg      lockfile.PackageEntry
+		want     bool
+	}{
+		{
+			name:     "[正常系] 制限のないパッケージ",
+			platform: lockfile.Platform{OS: []string{"linux"}, CPU: []string{"x64"}},
+			want:     true,
+		},
+		{
+			name:     "[正常系] 否定のみのリストで除外されない",
+			platform: lockfile.Platform{OS: []string{"linux"}, CPU: []string{"x64"}},
+			pkg:      lockfile.PackageEntry{OS: []string{"!win32"}},
+			want:     true,
+		},
+		{
+			name:     "[正常系] 否定で除外される",
+			platform: lockfile.Platform{OS: []string{"win32"}, CPU: []string{"x64"}},
+			pkg:      lockfile.PackageEntry{OS: []string{"!win32"}},
+			want:     false,
+		},
+		{
+			name:     "[正常系] 複数のサポート対象のいずれかに一致",
+			platform: lockfile.Platform{OS: []string{"linux", "darwin"}, CPU: []string{"arm64"}},
+			pkg:      lockfile.PackageEntry{OS: []string{"darwin"}, CPU: []string{"arm64"}},
+			want:     true,
+		},
+		{
+			name:     "[正常系] libcが一致しない",
+			platform: lockfile.Platform{OS: []string{"linux"}, CPU: []string{"x64"}, Libc: []string{"glibc"}},
+			pkg:      lockfile.PackageEntry{OS: []string{"linux"}, Libc: []string{"musl"}},
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.platform.Supports(tt.pkg); got != tt.want {
+				t.Errorf("Supports() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_CurrentPlatform(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("libc is only detected on linux")
+	}
+
+	tests := []struct {
+		name     string
+		setupFs  func() afero.Fs
+		wantLibc []string
+	}{
+		{
+			name: "[正常系] muslのローダーがあればmusl",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				afero.WriteFile(fs, "/lib/ld-musl-x86_64.so.1", nil, 0o755)
+				return fs
+			},
+			wantLibc: []string{"musl"},
+		},
+		{
+			name: "[正常系] muslのローダーがなければglibc",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				afero.WriteFile(fs, "/lib64/ld-linux-x86-64.so.2", nil, 0o755)
+				return fs
+			},
+			wantLibc: []string{"glibc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := lockfile.CurrentPlatform(tt.setupFs())
+			if !slices.Equal(got.Libc, tt.wantLibc) {
+				t.Errorf("CurrentPlatform().Libc = %v, want %v", got.Libc, tt.wantLibc)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package lockfile
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	lockfile_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile/errors"
+)
+
+// workspaceSelector is a parsed pnpm --filter selector.
+type workspaceSelector struct {
+	pattern     string
+	exclude     bool // "!<selector>"
+	deps        bool // "<selector>..."
+	dependents  bool // "...<selector>"
+	excludeSelf bool // "<selector>^..." or "...^<selector>"
+}
+
+func parseWorkspaceSelector(raw string) (workspaceSelector, lockfile_err.LockfileErrorIF) {
+	var sel workspaceSelector
+
+	s := raw
+	s, sel.exclude = strings.CutPrefix(s, "!")
+	s, sel.dependents = strings.CutPrefix(s, "...")
+	if sel.dependents {
+		s, sel.excludeSelf = strings.CutPrefix(s, "^")
+	}
+	s, sel.deps = strings.CutSuffix(s, "...")
+	if sel.deps {
+		var excludeSelf bool
+		s, excludeSelf = strings.CutSuffix(s, "^")
+		sel.excludeSelf = sel.excludeSelf || excludeSelf
+	}
+
+	if s == "" || strings.ContainsAny(s, "[]") {
+		return sel, lockfile_err.NewLockfileError(
+			&lockfile_err.UnsupportedSelectorError{},
+			"unsupported workspace selector "+raw,
+			nil,
+		)
+	}
+
+	sel.pattern = s
+
+	return sel, nil
+}
+
+// matches reports whether the selector pattern matches a project by path or name.
+// Path patterns start with "." or are enclosed in braces, everything else matches names.
+func (s workspaceSelector) matches(id string, name string) bool {
+	pattern := s.pattern
+
+	if inner, ok := strings.CutPrefix(pattern, "{"); ok {
+		pattern = "./" + strings.TrimSuffix(inner, "}")
+	}
+
+	if !strings.HasPrefix(pattern, ".") {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}
+
+	pattern = path.Clean(pattern)
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return id == dir || strings.HasPrefix(id, dir+"/")
+	}
+
+	ok, _ := path.Match(pattern, id)
+
+	return ok
+}
+
+// SelectImporters returns the projects selected by pnpm --filter selectors, sorted.
+// names maps project paths to their package names. Supported are name globs
+// ("@scope/*"), path globs ("./packages/*", "{packages/**}"), "..." to include
+// dependencies (suffix) or dependents (prefix) over workspace links, "^" to exclude the
+// matched project itself and "!" to exclude projects. Without selectors, all projects are selected.
+func (l *Lockfile) SelectImporters(
+	names map[string]string,
+	selectors []string,
+) ([]string, lockfile_err.LockfileErrorIF) {
+	ids := l.ImporterIDs()
+	if len(selectors) == 0 {
+		return ids, nil
+	}
+
+	links, dependents := l.workspaceLinks()
+	selected := make(map[string]bool)
+	excluded := make(map[string]bool)
+	onlyExclusions := true
+
+	for _, raw := range selectors {
+		sel, err := parseWorkspaceSelector(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		target := selected
+		if sel.exclude {
+			target = excluded
+		} else {
+			onlyExclusions = false
+		}
+
+		for _, id := range ids {
+			if !sel.matches(id, names[id]) {
+				continue
+			}
+
+			if !sel.excludeSelf {
+				target[id] = true
+			}
+
+			if sel.deps {
+				walkWorkspaceGraph(links, id, target)
+			}
+
+			if sel.dependents {
+				walkWorkspaceGraph(dependents, id, target)
+			}
+		}
+	}
+
+	if onlyExclusions {
+		for _, id := range ids {
+			selected[id] = true
+		}
+	}
+
+	var result []string
+	for _, id := range ids {
+		if selected[id] && !excluded[id] {
+			result = append(result, id)
+		}
+	}
+
+	return result, nil
+}
+
+// workspaceLinks returns the workspace dependency graph ("link:" dependencies between
+// projects) and its reverse.
+func (l *Lockfile) workspaceLinks() (map[string][]string, map[string][]string) {
+	importers := l.importers()
+	links := make(map[string][]string)
+	dependents := make(map[string][]string)
+
+	for id, importer := range importers {
+		for _, deps := range []map[string]ImporterDependency{
+			importer.Dependencies,
+			importer.DevDependencies,
+			importer.OptionalDependencies,
+		} {
+			for _, dep := range deps {
+				rel, ok := strings.CutPrefix(dep.Version, linkPrefix)
+				if !ok {
+					continue
+				}
+
+				target := path.Clean(path.Join(id, rel))
+				if _, exists := importers[target]; !exists {
+					continue
+				}
+
+				links[id] = append(links[id], target)
+				dependents[target] = append(dependents[target], id)
+			}
+		}
+	}
+
+	for id := range links {
+		slices.Sort(links[id])
+	}
+
+	for id := range dependents {
+		slices.Sort(dependents[id])
+	}
+
+	return links, dependents
+}
+
+// walkWorkspaceGraph adds every project reachable from id (excluding id itself) to result.
+func walkWorkspaceGraph(graph map[string][]string, id string, result map[string]bool) {
+	visited := map[string]bool{id: true}
+	queue := []string{id}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, next := range graph[current] {
+			if visited[next] {
+				continue
+			}
+
+			visited[next] = true
+			result[next] = true
+			queue = append(queue, next)
+		}
+	}
+}
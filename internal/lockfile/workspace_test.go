@@ -0,0 +1,84 @@
+package lockfile_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile"
+	lockfile_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile/errors"
+)
+
+func Test_SelectImporters(t *testing.T) {
+	t.Parallel()
+
+	l, parseErr := lockfile.Parse([]byte(workspaceLockfileV9))
+	if parseErr != nil {
+		t.Fatalf("Parse() error = %v", parseErr)
+	}
+
+	names := map[string]string{".": "root", "packages/a": "@app/a", "packages/b": "@app/b"}
+
+	tests := []struct {
+		name      string
+		selectors []string
+		want      []string
+		wantErr   lockfile_err.LockfileErrorIF
+	}{
+		{
+			name: "[正常系] セレクタなしは全importer",
+			want: []string{".", "packages/a", "packages/b"},
+		},
+		{
+			name:      "[正常系] パッケージ名で選択",
+			selectors: []string{"@app/a"},
+			want:      []string{"packages/a"},
+		},
+		{
+			name:      "[正常系] 依存するworkspaceを含めて選択",
+			selectors: []string{"@app/a..."},
+			want:      []string{"packages/a", "packages/b"},
+		},
+		{
+			name:      "[正常系] 依存先のみを選択",
+			selectors: []string{"@app/a^..."},
+			want:      []string{"packages/b"},
+		},
+		{
+			name:      "[正常系] 依存元を含めて選択",
+			selectors: []string{"...@app/b"},
+			want:      []string{"packages/a", "packages/b"},
+		},
+		{
+			name:      "[正常系] パスのglobで選択",
+			selectors: []string{"./packages/*"},
+			want:      []string{"packages/a", "packages/b"},
+		},
+		{
+			name:      "[正常系] 除外のみの場合は残り全て",
+			selectors: []string{"!{packages/**}"},
+			want:      []string{"."},
+		},
+		{
+			name:      "[異常系] gitの変更による選択はサポートされない",
+			selectors: []string{"[origin/main]"},
+			wantErr:   &lockfile_err.UnsupportedSelectorError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := l.SelectImporters(names, tt.selectors)
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("SelectImporters() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("SelectImporters() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
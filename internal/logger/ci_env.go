@@ -14,6 +14,10 @@ const (
 	teamCity       CI = "teamcity"
 	buildkite      CI = "buildkite"
 	travisCI       CI = "travis_ci"
+	jenkins        CI = "jenkins"
+	circleCI       CI = "circleci"
+	woodpecker     CI = "woodpecker"
+	forgejoActions CI = "forgejo_actions"
 	others         CI = "others"
 
 	ciEnv             = "CI"
@@ -23,8 +27,20 @@ const (
 	teamCityEnv       = "TEAMCITY_VERSION"
 	buildkiteEnv      = "BUILDKITE"
 	travisCIEnv       = "TRAVIS"
+	jenkinsEnv        = "JENKINS_URL"
+	circleCIEnv       = "CIRCLECI"
+	forgejoActionsEnv = "FORGEJO_ACTIONS"
+
+	// woodpeckerCIValue is the value of CI in Woodpecker, which is not truthy.
+	woodpeckerCIValue = "woodpecker"
 )
 
+// supportsWorkflowCommands reports whether ci understands GitHub Actions workflow
+// commands such as ::group:: and ::error::.
+func (ci CI) supportsWorkflowCommands() bool {
+	return ci == gitHubActions || ci == forgejoActions
+}
+
 // isTruthy returns `true` if environment variable has truthy value.
 func isTruthy(val string) bool {
 	switch strings.ToLower(val) {
@@ -37,6 +53,9 @@ func isTruthy(val string) bool {
 
 func detectCI() (CI, bool) {
 	switch {
+	// Forgejo Actions also sets GITHUB_ACTIONS for compatibility.
+	case isTruthy(os.Getenv(forgejoActionsEnv)):
+		return forgejoActions, true
 	case isTruthy(os.Getenv(gitHubActionsEnv)):
 		return gitHubActions, true
 	case isTruthy(os.Getenv(gitLabCIEnv)):
@@ -49,6 +68,12 @@ func detectCI() (CI, bool) {
 		return buildkite, true
 	case isTruthy(os.Getenv(travisCIEnv)):
 		return travisCI, true
+	case os.Getenv(jenkinsEnv) != "":
+		return jenkins, true
+	case isTruthy(os.Getenv(circleCIEnv)):
+		return circleCI, true
+	case os.Getenv(ciEnv) == woodpeckerCIValue:
+		return woodpecker, true
 	case isTruthy(os.Getenv(ciEnv)):
 		return others, true
 	default:
@@ -17,6 +17,9 @@ func clearCIEnvVars(t *testing.T) {
 	t.Setenv("TEAMCITY_VERSION", "")
 	t.Setenv("BUILDKITE", "")
 	t.Setenv("TRAVIS", "")
+	t.Setenv("JENKINS_URL", "")
+	t.Setenv("CIRCLECI", "")
+	t.Setenv("FORGEJO_ACTIONS", "")
 }
 
 func Test_isTruthy(t *testing.T) {
@@ -101,6 +104,30 @@ func Test_detectCI(t *testing.T) {
 			wantCI: travisCI,
 			wantOK: true,
 		},
+		{
+			name:   "[正常系] Jenkinsを検出する",
+			envs:   map[string]string{"JENKINS_URL": "https://jenkins.example.com/"},
+			wantCI: jenkins,
+			wantOK: true,
+		},
+		{
+			name:   "[正常系] CircleCIを検出する",
+			envs:   map[string]string{"CI": "true", "CIRCLECI": "true"},
+			wantCI: circleCI,
+			wantOK: true,
+		},
+		{
+			name:   "[正常系] CI=woodpeckerでWoodpeckerを検出する",
+			envs:   map[string]string{"CI": "woodpecker"},
+			wantCI: woodpecker,
+			wantOK: true,
+		},
+		{
+			name:   "[正常系] GITHUB_ACTIONSも設定されたForgejo Actionsを検出する",
+			envs:   map[string]string{"FORGEJO_ACTIONS": "true", "GITHUB_ACTIONS": "true"},
+			wantCI: forgejoActions,
+			wantOK: true,
+		},
 		{
 			name:   "[正常系] CI環境変数のみの場合はothersを返す",
 			envs:   map[string]string{"CI": "true"},
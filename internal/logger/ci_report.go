@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// stepSummaryEnv is the environment variable holding the path of the job summary
+// file in GitHub Actions and Forgejo Actions.
+const stepSummaryEnv = "GITHUB_STEP_SUMMARY"
+
+// Annotation is an error on a source location, shown in the UI of the CI provider.
+type Annotation struct {
+	Title   string
+	Message string
+	File    string // path of the annotated file, optional
+	Line    int    // 1-based line in File, 0 if unknown
+}
+
+// Summary is a result table written to the job summary of the CI provider.
+type Summary struct {
+	Title string
+	Rows  []SummaryRow
+}
+
+// SummaryRow is a single row of a Summary.
+type SummaryRow struct {
+	Name  string
+	Value string
+}
+
+// markdown renders s as a Markdown heading followed by a two-column table.
+func (s Summary) markdown() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "### %s\n\n| | |\n|---|---|\n", s.Title)
+	for _, row := range s.Rows {
+		fmt.Fprintf(&b, "| %s | %s |\n", escapeTableCell(row.Name), escapeTableCell(row.Value))
+	}
+	b.WriteByte('\n')
+
+	return b.String()
+}
+
+// escapeTableCell keeps value on a single cell of a Markdown table.
+func escapeTableCell(value string) string {
+	return strings.NewReplacer("|", `\|`, "\r", "", "\n", "<br>").Replace(value)
+}
+
+// workflowCommand formats an ::error:: workflow command of GitHub Actions.
+// See https://docs.github.com/actions/reference/workflow-commands-for-github-actions
+func workflowCommand(a Annotation) string {
+	escapeData := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	escapeProperty := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+	var props []string
+	if a.File != "" {
+		props = append(props, "file="+escapeProperty.Replace(a.File))
+		if a.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", a.Line))
+		}
+	}
+	if a.Title != "" {
+		props = append(props, "title="+escapeProperty.Replace(a.Title))
+	}
+
+	cmd := "::error"
+	if len(props) > 0 {
+		cmd += " " + strings.Join(props, ",")
+	}
+
+	return cmd + "::" + escapeData.Replace(a.Message)
+}
+
+// azureLogIssue formats a task.logissue logging command of Azure Pipelines.
+// See https://learn.microsoft.com/azure/devops/pipelines/scripts/logging-commands
+func azureLogIssue(a Annotation) string {
+	escape := strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A", ";", "%3B", "]", "%5D")
+
+	props := []string{"type=error"}
+	if a.File != "" {
+		props = append(props, "sourcepath="+escape.Replace(a.File))
+		if a.Line > 0 {
+			props = append(props, fmt.Sprintf("linenumber=%d", a.Line))
+		}
+	}
+
+	msg := a.Message
+	if a.Title != "" {
+		msg = a.Title + ": " + msg
+	}
+
+	return "##vso[task.logissue " + strings.Join(props, ";") + ";]" + escape.Replace(msg)
+}
+
+// teamCityBuildProblem formats a buildProblem service message of TeamCity.
+// See https://www.jetbrains.com/help/teamcity/service-messages.html
+func teamCityBuildProblem(a Annotation) string {
+	escape := strings.NewReplacer("|", "||", "'", "|'", "\n", "|n", "\r", "|r", "[", "|[", "]", "|]")
+
+	desc := a.Message
+	if a.Title != "" {
+		desc = a.Title + ": " + desc
+	}
+	if a.File != "" {
+		location := a.File
+		if a.Line > 0 {
+			location += fmt.Sprintf(":%d", a.Line)
+		}
+		desc = location + ": " + desc
+	}
+
+	return "##teamcity[buildProblem description='" + escape.Replace(desc) + "']"
+}
+
+// Annotate writes a in the annotation syntax of the CI provider. Providers
+// without such syntax only have the error line logged by the caller.
+func (l *textLogger) Annotate(a Annotation) {
+	switch l.ci {
+	case gitHubActions, forgejoActions:
+		fmt.Fprintln(l.w, workflowCommand(a))
+	case azurePipelines:
+		fmt.Fprintln(l.w, azureLogIssue(a))
+	case teamCity:
+		fmt.Fprintln(l.w, teamCityBuildProblem(a))
+	case gitLabCI, buildkite, travisCI, jenkins, circleCI, woodpecker, others:
+	}
+}
+
+// WriteSummary appends s to the job summary of GitHub Actions and Forgejo Actions.
+func (l *textLogger) WriteSummary(s Summary) {
+	path := os.Getenv(stepSummaryEnv)
+	if !l.ci.supportsWorkflowCommands() || path == "" {
+		return
+	}
+
+	//nolint:mnd // job summary file permissions
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		l.logger.Warn("failed to open job summary", "path", path, "error", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(s.markdown()); err != nil {
+		l.logger.Warn("failed to write job summary", "path", path, "error", err)
+	}
+}
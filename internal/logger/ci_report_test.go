@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_textLogger_Annotate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		ci         CI
+		annotation Annotation
+		want       string
+	}{
+		{
+			name: "[正常系] GitHub Actionsではファイルと行付きの::error::が出力される",
+			ci:   gitHubActions,
+			annotation: Annotation{
+				Title:   "hash mismatch",
+				Message: "expected a\ngot b",
+				File:    "nix/package.nix",
+				Line:    12,
+			},
+			want: "::error file=nix/package.nix,line=12,title=hash mismatch::expected a%0Agot b\n",
+		},
+		{
+			name:       "[正常系] Forgejo Actionsではファイルなしの::error::が出力される",
+			ci:         forgejoActions,
+			annotation: Annotation{Message: "100% failed"},
+			want:       "::error::100%25 failed\n",
+		},
+		{
+			name: "[正常系] プロパティ内のカンマとコロンはエスケープされる",
+			ci:   gitHubActions,
+			annotation: Annotation{
+				Title:   "a, b: c",
+				Message: "m",
+			},
+			want: "::error title=a%2C b%3A c::m\n",
+		},
+		{
+			name: "[正常系] Azure Pipelinesではtask.logissueが出力される",
+			ci:   azurePipelines,
+			annotation: Annotation{
+				Title:   "hash mismatch",
+				Message: "expected a; got b",
+				File:    "package.nix",
+				Line:    3,
+			},
+			want: "##vso[task.logissue type=error;sourcepath=package.nix;linenumber=3;]hash mismatch: expected a%3B got b\n",
+		},
+		{
+			name: "[正常系] TeamCityではbuildProblemが出力される",
+			ci:   teamCity,
+			annotation: Annotation{
+				Title:   "hash mismatch",
+				Message: "it's [bad]",
+				File:    "package.nix",
+				Line:    3,
+			},
+			want: "##teamcity[buildProblem description='package.nix:3: hash mismatch: it|'s |[bad|]']\n",
+		},
+		{
+			name:       "[正常系] 注釈構文のないCI環境では何も出力されない",
+			ci:         gitLabCI,
+			annotation: Annotation{Message: "m"},
+			want:       "",
+		},
+		{
+			name:       "[正常系] 非CI環境では何も出力されない",
+			ci:         CI(""),
+			annotation: Annotation{Message: "m"},
+			want:       "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &bytes.Buffer{}
+			l := newTextLogger(slog.LevelInfo, w, tt.ci)
+			l.Annotate(tt.annotation)
+
+			if got := w.String(); got != tt.want {
+				t.Errorf("Annotate() output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_textLogger_WriteSummary(t *testing.T) {
+	summary := Summary{
+		Title: "nix-prefetch-pnpm-deps",
+		Rows: []SummaryRow{
+			{Name: "Result", Value: "❌ hash mismatch"},
+			{Name: "Note", Value: "a|b\nc"},
+		},
+	}
+	wantMarkdown := "### nix-prefetch-pnpm-deps\n\n| | |\n|---|---|\n" +
+		"| Result | ❌ hash mismatch |\n| Note | a\\|b<br>c |\n\n"
+
+	tests := []struct {
+		name    string
+		ci      CI
+		setEnv  bool
+		initial string
+		want    string
+	}{
+		{
+			name:    "[正常系] GitHub Actionsではジョブサマリーに追記される",
+			ci:      gitHubActions,
+			setEnv:  true,
+			initial: "previous step\n",
+			want:    "previous step\n" + wantMarkdown,
+		},
+		{
+			name:   "[正常系] GITHUB_STEP_SUMMARYがない場合は書き込まれない",
+			ci:     gitHubActions,
+			setEnv: false,
+			want:   "",
+		},
+		{
+			name:   "[正常系] 他のCI環境では書き込まれない",
+			ci:     gitLabCI,
+			setEnv: true,
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "summary.md")
+			if tt.initial != "" {
+				if err := os.WriteFile(path, []byte(tt.initial), 0o644); err != nil {
+					t.Fatalf("failed to write %s: %v", path, err)
+				}
+			}
+
+			if tt.setEnv {
+				t.Setenv(stepSummaryEnv, path)
+			} else {
+				t.Setenv(stepSummaryEnv, "")
+			}
+
+			l := newTextLogger(slog.LevelInfo, &bytes.Buffer{}, tt.ci)
+			l.WriteSummary(summary)
+
+			got, err := os.ReadFile(path)
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatalf("failed to read %s: %v", path, err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("summary = %q, want %q", string(got), tt.want)
+			}
+		})
+	}
+}
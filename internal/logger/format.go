@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Format is the output format of non-TUI logs.
+type Format string
+
+const (
+	// FormatText is the human-readable text format, with a TUI on terminals.
+	FormatText Format = "text"
+	// FormatJSON writes one JSON object per line.
+	FormatJSON Format = "json"
+	// FormatLogfmt writes key=value pairs per line.
+	FormatLogfmt Format = "logfmt"
+)
+
+// Formats returns all supported formats.
+func Formats() []Format {
+	return []Format{FormatText, FormatJSON, FormatLogfmt}
+}
+
+// ParseFormat parses a format name.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats() {
+		if string(f) == strings.ToLower(s) {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported log format %q", s)
+}
+
+// ParseLevel parses a level name: debug, info, warn or error.
+func ParseLevel(s string) (LogLevel, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unsupported log level %q", s)
+	}
+
+	return level, nil
+}
+
+// structured reports whether f is a machine-readable format. Structured logs never
+// use the TUI and carry the phase, exit code and duration of steps and commands as attributes.
+func (f Format) structured() bool {
+	return f == FormatJSON || f == FormatLogfmt
+}
+
+// newHandler returns the slog.Handler writing f to w.
+func (f Format) newHandler(w io.Writer, level LogLevel) slog.Handler {
+	switch f {
+	case FormatJSON:
+		return newJSONHandler(w, level)
+	case FormatLogfmt:
+		return newLogfmtHandler(w, level)
+	case FormatText:
+		return newTextHandler(w, level)
+	default:
+		return newTextHandler(w, level)
+	}
+}
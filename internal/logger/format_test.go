@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func Test_ParseFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    Format
+		wantErr bool
+	}{
+		{name: "[正常系] text", input: "text", want: FormatText},
+		{name: "[正常系] json", input: "json", want: FormatJSON},
+		{name: "[正常系] 大文字のlogfmt", input: "LOGFMT", want: FormatLogfmt},
+		{name: "[異常系] 未対応のフォーマット", input: "yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("ParseFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ParseLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    LogLevel
+		wantErr bool
+	}{
+		{name: "[正常系] debug", input: "debug", want: slog.LevelDebug},
+		{name: "[正常系] 大文字のWARN", input: "WARN", want: slog.LevelWarn},
+		{name: "[正常系] error", input: "error", want: slog.LevelError},
+		{name: "[異常系] 未対応のレベル", input: "verbose", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseLevel(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"time"
+)
+
+// durationKeySuffix is appended to the key of duration attributes in structured
+// formats, whose values are written in milliseconds.
+const durationKeySuffix = "_ms"
+
+// newJSONHandler returns a handler that writes one JSON object per record:
+//
+//	{"time":"2006-01-02T15:04:05+07:00","level":"INFO","msg":"pnpm install completed in 1.2s","phase":"pnpm install","duration_ms":1200}
+func newJSONHandler(w io.Writer, level slog.Level) slog.Handler {
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: replaceStructuredAttr,
+	})
+}
+
+// replaceStructuredAttr makes attributes easy to index: timestamps are written
+// in RFC 3339 without sub-second digits like the text format, and durations
+// as integer milliseconds under "<key>_ms".
+func replaceStructuredAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+
+	switch {
+	case a.Key == slog.TimeKey && a.Value.Kind() == slog.KindTime:
+		return slog.String(slog.TimeKey, a.Value.Time().Format(time.RFC3339))
+	case a.Value.Kind() == slog.KindDuration:
+		return slog.Int64(a.Key+durationKeySuffix, a.Value.Duration().Milliseconds())
+	default:
+		return a
+	}
+}
@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func Test_newStructuredLogger_json(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		run        func(l Logger)
+		wantFields map[string]any
+	}{
+		{
+			name: "[正常系] コマンドの失敗にphase・exit_code・durationが付与される",
+			run: func(l Logger) {
+				cl := l.CommandLogger(slog.LevelInfo, "pnpm install")
+				cl.Fail(1)
+			},
+			wantFields: map[string]any{
+				"level":     "ERROR",
+				"phase":     "pnpm install",
+				"exit_code": float64(1),
+			},
+		},
+		{
+			name: "[正常系] ステップの失敗にphaseとerrorが付与される",
+			run: func(l Logger) {
+				sl := l.StepLogger(slog.LevelInfo, "compute NAR hash")
+				sl.Fail(errors.New("boom"))
+			},
+			wantFields: map[string]any{
+				"level": "ERROR",
+				"phase": "compute NAR hash",
+				"error": "boom",
+			},
+		},
+		{
+			name: "[正常系] 呼び出し元の属性がそのまま出力される",
+			run: func(l Logger) {
+				l.Info("loaded pnpm-lock.yaml", "path", "./src/pnpm-lock.yaml")
+			},
+			wantFields: map[string]any{
+				"msg":  "loaded pnpm-lock.yaml",
+				"path": "./src/pnpm-lock.yaml",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &bytes.Buffer{}
+			tt.run(newStructuredLogger(slog.LevelInfo, w, FormatJSON))
+
+			lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+			var record map[string]any
+			if err := json.Unmarshal([]byte(lines[len(lines)-1]), &record); err != nil {
+				t.Fatalf("last line %q is not JSON: %v", lines[len(lines)-1], err)
+			}
+
+			for key, want := range tt.wantFields {
+				if record[key] != want {
+					t.Errorf("record[%q] = %v, want %v", key, record[key], want)
+				}
+			}
+
+			if _, ok := record["time"].(string); !ok {
+				t.Errorf("record has no time: %v", record)
+			}
+		})
+	}
+}
+
+func Test_replaceStructuredAttr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		groups []string
+		attr   slog.Attr
+		want   slog.Attr
+	}{
+		{
+			name: "[正常系] durationはミリ秒に変換されキーに_msが付与される",
+			attr: slog.Duration("duration", 1500000000),
+			want: slog.Int64("duration_ms", 1500),
+		},
+		{
+			name: "[正常系] その他の属性はそのまま",
+			attr: slog.String("path", "/tmp/store"),
+			want: slog.String("path", "/tmp/store"),
+		},
+		{
+			name:   "[正常系] グループ内の属性はそのまま",
+			groups: []string{"group"},
+			attr:   slog.Duration("duration", 1),
+			want:   slog.Duration("duration", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := replaceStructuredAttr(tt.groups, tt.attr); !got.Equal(tt.want) {
+				t.Errorf("replaceStructuredAttr() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
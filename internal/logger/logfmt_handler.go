@@ -0,0 +1,16 @@
+package logger
+
+import (
+	"io"
+	"log/slog"
+)
+
+// newLogfmtHandler returns a handler that writes records in logfmt:
+//
+//	time=2006-01-02T15:04:05+07:00 level=INFO msg="pnpm install completed in 1.2s" phase="pnpm install" duration_ms=1200
+func newLogfmtHandler(w io.Writer, level slog.Level) slog.Handler {
+	return slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: replaceStructuredAttr,
+	})
+}
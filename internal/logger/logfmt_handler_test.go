@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func Test_newLogfmtHandler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		log       func(l *slog.Logger)
+		wantRegex string
+	}{
+		{
+			name: "[正常系] 秒単位のタイムスタンプとkey=value形式で出力される",
+			log: func(l *slog.Logger) {
+				l.Info("loaded pnpm-lock.yaml", "path", "./src/pnpm-lock.yaml")
+			},
+			wantRegex: `^time=\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(Z|[+-]\d{2}:\d{2}) level=INFO msg="loaded pnpm-lock.yaml" path=./src/pnpm-lock.yaml\n$`,
+		},
+		{
+			name: "[正常系] durationはミリ秒で出力される",
+			log: func(l *slog.Logger) {
+				l.Info("done", "phase", "pnpm install", "duration", 2*time.Second)
+			},
+			wantRegex: `msg=done phase="pnpm install" duration_ms=2000\n$`,
+		},
+		{
+			name: "[正常系] レベル未満のログは出力されない",
+			log: func(l *slog.Logger) {
+				l.Debug("hidden")
+			},
+			wantRegex: `^$`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &bytes.Buffer{}
+			tt.log(slog.New(newLogfmtHandler(w, slog.LevelInfo)))
+
+			if !regexp.MustCompile(tt.wantRegex).MatchString(w.String()) {
+				t.Errorf("output = %q, want to match %q", w.String(), tt.wantRegex)
+			}
+		})
+	}
+}
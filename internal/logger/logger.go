@@ -15,27 +15,36 @@ type Logger interface {
 	Info(msg string, args ...any)
 	Warn(msg string, args ...any)
 	Error(msg string, args ...any)
-	Fatal(msg string, args ...any)
 
 	Debugf(tmpl string, args ...any)
 	Infof(tmpl string, args ...any)
 	Warnf(tmpl string, args ...any)
 	Errorf(tmpl string, args ...any)
-	Fatalf(tmpl string, args ...any)
 
 	StepLogger(logLevel LogLevel, msg string) StepLogger
 	CommandLogger(logLevel LogLevel, name string) CommandLogger
 
+	// Annotate reports an error on a source location in the UI of the CI provider, if supported.
+	Annotate(a Annotation)
+	// WriteSummary writes a result table to the job summary of the CI provider, if supported.
+	WriteSummary(s Summary)
+
 	Close() error
 }
 
 type StepLogger interface {
+	// Progress updates the progress of the step.
+	Progress(p StepProgress)
 	Done()
 	Fail(err error)
 }
 
 type CommandLogger interface {
 	io.Writer
+	// Progress updates the progress of the command.
+	Progress(p Progress)
+	// Debug logs a raw output line that is only shown at debug level.
+	Debug(line string)
 	Done()
 	Fail(exitCode int)
 }
@@ -44,11 +53,30 @@ type fdWriter interface {
 	Fd() uintptr
 }
 
-func New(level LogLevel) Logger {
-	return newLogger(level, os.Stderr)
+// Options are the options of New.
+type Options struct {
+	// Format is the output format; the zero value is FormatText.
+	Format Format
+	// LogFile receives every message at debug level and the complete output of
+	// commands with timestamps, regardless of the level and the terminal mode.
+	// It is closed by Close. Nothing is written if it is nil.
+	LogFile io.WriteCloser
 }
 
-func newLogger(level LogLevel, w io.Writer) Logger {
+func New(level LogLevel, opts Options) Logger {
+	l := newLogger(level, os.Stderr, opts.Format)
+	if opts.LogFile != nil {
+		return newTeeLogger(l, opts.LogFile)
+	}
+
+	return l
+}
+
+func newLogger(level LogLevel, w io.Writer, format Format) Logger {
+	if format.structured() {
+		return newStructuredLogger(level, w, format)
+	}
+
 	ci, isCI := detectCI()
 	// Use TUI Logger in non-CI environments and when `w` is TTY.
 	if isTTY(w) && !isCI {
@@ -75,6 +75,7 @@ func Test_newLogger(t *testing.T) {
 	tests := []struct {
 		name     string
 		envs     map[string]string
+		format   Format
 		setup    func(t *testing.T) io.Writer
 		wantType reflect.Type
 	}{
@@ -100,6 +101,15 @@ func Test_newLogger(t *testing.T) {
 			},
 			wantType: reflect.TypeFor[*tuiLogger](),
 		},
+		{
+			name:   "[正常系] 構造化フォーマットの場合はTTYでもtextLoggerを返す",
+			format: FormatJSON,
+			setup: func(t *testing.T) io.Writer {
+				t.Helper()
+				return newPTY(t)
+			},
+			wantType: reflect.TypeFor[*textLogger](),
+		},
 		{
 			name: "[正常系] CI環境かつTTYの場合はtextLoggerを返す",
 			envs: map[string]string{"CI": "true"},
@@ -119,7 +129,7 @@ func Test_newLogger(t *testing.T) {
 			}
 			w := tt.setup(t)
 
-			got := newLogger(slog.LevelInfo, w)
+			got := newLogger(slog.LevelInfo, w, tt.format)
 			t.Cleanup(func() { got.Close() })
 
 			gotType := reflect.TypeOf(got)
@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// progressBarWidth is the number of cells of the progress bar in the TUI.
+const progressBarWidth = 30
+
+// Progress is the progress reported by a command, e.g. pnpm install.
+type Progress struct {
+	Resolved   int           // packages resolved
+	Fetched    int           // packages downloaded or found in the store
+	Added      int           // packages added to the project
+	Total      int           // packages to fetch, 0 while unknown
+	Downloaded int64         // bytes downloaded
+	ETA        time.Duration // estimated time until all packages are fetched, 0 if unknown
+}
+
+// summary formats the counters of p on a single line.
+func (p Progress) summary() string {
+	parts := []string{fmt.Sprintf("resolved %d", p.Resolved)}
+
+	if p.Total > 0 {
+		parts = append(parts, fmt.Sprintf("fetched %d/%d", p.Fetched, p.Total))
+	} else {
+		parts = append(parts, fmt.Sprintf("fetched %d", p.Fetched))
+	}
+
+	parts = append(parts, fmt.Sprintf("added %d", p.Added), formatBytes(p.Downloaded)+" downloaded")
+
+	if p.ETA > 0 {
+		parts = append(parts, "ETA "+p.ETA.Round(time.Second).String())
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// bar renders a progress bar of the fetched packages, or an empty string while
+// the number of packages is unknown.
+func (p Progress) bar(width int) string {
+	if p.Total <= 0 {
+		return ""
+	}
+
+	return renderBar(width, int64(p.Fetched), int64(p.Total))
+}
+
+// StepProgress is the progress reported by a step, e.g. hashing the store.
+type StepProgress struct {
+	Phase      string // what the step is currently doing, e.g. "hash"
+	Current    int    // files processed
+	Total      int    // files to process, 0 while unknown
+	Bytes      int64  // bytes processed
+	TotalBytes int64  // bytes to process, 0 if not counted
+}
+
+// summary formats the counters of p on a single line.
+func (p StepProgress) summary() string {
+	var b strings.Builder
+	if p.Phase != "" {
+		b.WriteString(p.Phase + ": ")
+	}
+
+	if p.Total > 0 {
+		fmt.Fprintf(&b, "%d/%d files", p.Current, p.Total)
+	} else {
+		fmt.Fprintf(&b, "%d files", p.Current)
+	}
+
+	if p.TotalBytes > 0 {
+		fmt.Fprintf(&b, ", %s/%s", formatBytes(p.Bytes), formatBytes(p.TotalBytes))
+	} else if p.Bytes > 0 {
+		b.WriteString(", " + formatBytes(p.Bytes))
+	}
+
+	return b.String()
+}
+
+// bar renders a progress bar of the processed bytes, or of the processed files if
+// the bytes are not counted. It is empty while the total is unknown.
+func (p StepProgress) bar(width int) string {
+	if p.TotalBytes > 0 {
+		return renderBar(width, p.Bytes, p.TotalBytes)
+	}
+
+	if p.Total > 0 {
+		return renderBar(width, int64(p.Current), int64(p.Total))
+	}
+
+	return ""
+}
+
+// done reports whether all files of the step have been processed.
+func (p StepProgress) done() bool {
+	return p.Total > 0 && p.Current >= p.Total
+}
+
+// renderBar renders a bar of width cells filled in proportion to current/total.
+func renderBar(width int, current, total int64) string {
+	filled := int(min(int64(width)*current/total, int64(width)))
+
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "]"
+}
+
+// formatBytes formats n with a binary unit, e.g. "1.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,176 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Progress_summary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		progress Progress
+		want     string
+	}{
+		{
+			name:     "[正常系] 総数が不明な場合",
+			progress: Progress{Resolved: 3, Fetched: 1},
+			want:     "resolved 3, fetched 1, added 0, 0 B downloaded",
+		},
+		{
+			name: "[正常系] 総数とETAがある場合",
+			progress: Progress{
+				Resolved:   10,
+				Fetched:    4,
+				Added:      2,
+				Total:      10,
+				Downloaded: 5 * 1024 * 1024,
+				ETA:        1500 * time.Millisecond,
+			},
+			want: "resolved 10, fetched 4/10, added 2, 5.0 MiB downloaded, ETA 2s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.progress.summary(); got != tt.want {
+				t.Errorf("summary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Progress_bar(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		progress Progress
+		want     string
+	}{
+		{
+			name:     "[正常系] 総数が不明な場合は空",
+			progress: Progress{Fetched: 1},
+			want:     "",
+		},
+		{
+			name:     "[正常系] 途中",
+			progress: Progress{Fetched: 1, Total: 4},
+			want:     "[█░░░]",
+		},
+		{
+			name:     "[正常系] 総数を超えても溢れない",
+			progress: Progress{Fetched: 5, Total: 4},
+			want:     "[████]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.progress.bar(4); got != tt.want {
+				t.Errorf("bar() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_StepProgress_summary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		progress StepProgress
+		want     string
+	}{
+		{
+			name:     "[正常系] 総数が不明な場合",
+			progress: StepProgress{Phase: "hash", Current: 3},
+			want:     "hash: 3 files",
+		},
+		{
+			name:     "[正常系] バイト数を数えない場合",
+			progress: StepProgress{Phase: "set permissions", Current: 1, Total: 4},
+			want:     "set permissions: 1/4 files",
+		},
+		{
+			name:     "[正常系] ファイル数とバイト数の総数がある場合",
+			progress: StepProgress{Phase: "hash", Current: 2, Total: 4, Bytes: 1536, TotalBytes: 3 << 20},
+			want:     "hash: 2/4 files, 1.5 KiB/3.0 MiB",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.progress.summary(); got != tt.want {
+				t.Errorf("summary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_StepProgress_bar(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		progress StepProgress
+		want     string
+	}{
+		{
+			name:     "[正常系] 総数が不明な場合は空",
+			progress: StepProgress{Current: 1},
+			want:     "",
+		},
+		{
+			name:     "[正常系] バイト数がない場合はファイル数で描画される",
+			progress: StepProgress{Current: 2, Total: 4},
+			want:     "[██░░]",
+		},
+		{
+			name:     "[正常系] バイト数がある場合はバイト数で描画される",
+			progress: StepProgress{Current: 1, Total: 4, Bytes: 300, TotalBytes: 400},
+			want:     "[███░]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.progress.bar(4); got != tt.want {
+				t.Errorf("bar() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_formatBytes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		n    int64
+		want string
+	}{
+		{name: "[正常系] バイト", n: 512, want: "512 B"},
+		{name: "[正常系] KiB", n: 1536, want: "1.5 KiB"},
+		{name: "[正常系] GiB", n: 3 << 30, want: "3.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := formatBytes(tt.n); got != tt.want {
+				t.Errorf("formatBytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
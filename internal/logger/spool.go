@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"os"
+	"sync"
+)
+
+// spoolMemoryLimit is the amount of command output kept in memory before it is
+// spilled to a temporary file.
+const spoolMemoryLimit = 1 << 20 // 1 MiB
+
+// outputSpool retains the complete output of a command, so that it can be shown
+// in full when the command fails. The output is kept in memory up to
+// spoolMemoryLimit and spilled to a temporary file beyond that.
+type outputSpool struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	file  *os.File // nil until the output is spilled
+	limit int
+}
+
+func newOutputSpool() *outputSpool {
+	return &outputSpool{limit: spoolMemoryLimit}
+}
+
+// writeLine appends a single output line.
+func (s *outputSpool) writeLine(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil && s.buf.Len()+len(line)+1 > s.limit {
+		s.spill()
+	}
+
+	if s.file != nil {
+		if _, err := io.WriteString(s.file, line+"\n"); err == nil {
+			return
+		}
+	}
+
+	s.buf.WriteString(line)
+	s.buf.WriteByte('\n')
+}
+
+// spill moves the buffered output to a temporary file. The output stays in
+// memory if the file cannot be created.
+func (s *outputSpool) spill() {
+	f, err := os.CreateTemp("", "nix-prefetch-pnpm-output-")
+	if err != nil {
+		// Keep buffering in memory; losing the output would be worse.
+		s.limit = math.MaxInt
+		return
+	}
+
+	if _, err := f.Write(s.buf.Bytes()); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		s.limit = math.MaxInt
+		return
+	}
+
+	s.buf.Reset()
+	s.file = f
+}
+
+// lines returns the retained output without the trailing newline.
+func (s *outputSpool) lines() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out bytes.Buffer
+	if s.file != nil {
+		if _, err := s.file.Seek(0, io.SeekStart); err == nil {
+			_, _ = io.Copy(&out, s.file)
+		}
+		_, _ = s.file.Seek(0, io.SeekEnd)
+	}
+	out.Write(s.buf.Bytes())
+
+	return string(bytes.TrimRight(out.Bytes(), "\n"))
+}
+
+// close releases the temporary file, if any.
+func (s *outputSpool) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		_ = s.file.Close()
+		_ = os.Remove(s.file.Name())
+		s.file = nil
+	}
+	s.buf.Reset()
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_outputSpool(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		limit     int
+		lines     int
+		wantSpill bool
+	}{
+		{
+			name:      "[正常系] 上限以下の出力はメモリに保持される",
+			limit:     1 << 10,
+			lines:     3,
+			wantSpill: false,
+		},
+		{
+			name:      "[正常系] 上限を超えた出力は一時ファイルに退避され全て保持される",
+			limit:     16,
+			lines:     20,
+			wantSpill: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newOutputSpool()
+			s.limit = tt.limit
+
+			want := make([]string, 0, tt.lines)
+			for i := range tt.lines {
+				line := fmt.Sprintf("line%d", i)
+				s.writeLine(line)
+				want = append(want, line)
+			}
+
+			if got := s.lines(); got != strings.Join(want, "\n") {
+				t.Errorf("lines() = %q, want %q", got, strings.Join(want, "\n"))
+			}
+
+			// Reading the output must not prevent further writes.
+			s.writeLine("last")
+			if got := s.lines(); !strings.HasSuffix(got, "\nlast") {
+				t.Errorf("lines() = %q, want suffix %q", got, "\nlast")
+			}
+
+			if spilled := s.file != nil; spilled != tt.wantSpill {
+				t.Errorf("spilled = %v, want %v", spilled, tt.wantSpill)
+			}
+
+			var tempPath string
+			if s.file != nil {
+				tempPath = s.file.Name()
+			}
+
+			s.close()
+			if tempPath != "" {
+				if _, err := os.Stat(tempPath); !os.IsNotExist(err) {
+					t.Errorf("temporary file %s was not removed", tempPath)
+				}
+			}
+		})
+	}
+}
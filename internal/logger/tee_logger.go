@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// teeLogger writes every message to the terminal logger and to a log file.
+// The log file is written by a text logger at debug level, so it is independent
+// of the level and the mode of the terminal logger.
+type teeLogger struct {
+	primary Logger
+	file    Logger
+	closer  io.Closer
+}
+
+func newTeeLogger(primary Logger, logFile io.WriteCloser) Logger {
+	return &teeLogger{
+		primary: primary,
+		file:    newTextLogger(slog.LevelDebug, logFile, CI("")),
+		closer:  logFile,
+	}
+}
+
+func (l *teeLogger) Debug(msg string, args ...any) {
+	l.file.Debug(msg, args...)
+	l.primary.Debug(msg, args...)
+}
+
+func (l *teeLogger) Info(msg string, args ...any) {
+	l.file.Info(msg, args...)
+	l.primary.Info(msg, args...)
+}
+
+func (l *teeLogger) Warn(msg string, args ...any) {
+	l.file.Warn(msg, args...)
+	l.primary.Warn(msg, args...)
+}
+
+func (l *teeLogger) Error(msg string, args ...any) {
+	l.file.Error(msg, args...)
+	l.primary.Error(msg, args...)
+}
+
+func (l *teeLogger) Debugf(tmpl string, args ...any) {
+	l.file.Debugf(tmpl, args...)
+	l.primary.Debugf(tmpl, args...)
+}
+
+func (l *teeLogger) Infof(tmpl string, args ...any) {
+	l.file.Infof(tmpl, args...)
+	l.primary.Infof(tmpl, args...)
+}
+
+func (l *teeLogger) Warnf(tmpl string, args ...any) {
+	l.file.Warnf(tmpl, args...)
+	l.primary.Warnf(tmpl, args...)
+}
+
+func (l *teeLogger) Errorf(tmpl string, args ...any) {
+	l.file.Errorf(tmpl, args...)
+	l.primary.Errorf(tmpl, args...)
+}
+
+func (l *teeLogger) StepLogger(logLevel LogLevel, msg string) StepLogger {
+	return &teeStepLogger{
+		file:    l.file.StepLogger(logLevel, msg),
+		primary: l.primary.StepLogger(logLevel, msg),
+	}
+}
+
+func (l *teeLogger) CommandLogger(logLevel LogLevel, name string) CommandLogger {
+	return &teeCommandLogger{
+		file:    l.file.CommandLogger(logLevel, name),
+		primary: l.primary.CommandLogger(logLevel, name),
+		warnf:   l.primary.Warnf,
+	}
+}
+
+// Annotate only reports to the terminal logger; the log file has no CI provider.
+func (l *teeLogger) Annotate(a Annotation) {
+	l.primary.Annotate(a)
+}
+
+// WriteSummary only reports to the terminal logger; the log file has no CI provider.
+func (l *teeLogger) WriteSummary(s Summary) {
+	l.primary.WriteSummary(s)
+}
+
+func (l *teeLogger) Close() error {
+	return errors.Join(l.primary.Close(), l.file.Close(), l.closer.Close())
+}
+
+// teeStepLogger implements StepLogger for teeLogger.
+type teeStepLogger struct {
+	primary StepLogger
+	file    StepLogger
+}
+
+func (s *teeStepLogger) Progress(p StepProgress) {
+	s.file.Progress(p)
+	s.primary.Progress(p)
+}
+
+func (s *teeStepLogger) Done() {
+	s.file.Done()
+	s.primary.Done()
+}
+
+func (s *teeStepLogger) Fail(err error) {
+	s.file.Fail(err)
+	s.primary.Fail(err)
+}
+
+// teeCommandLogger implements CommandLogger for teeLogger.
+type teeCommandLogger struct {
+	primary CommandLogger
+	file    CommandLogger
+	warnf   func(tmpl string, args ...any)
+
+	// fileFailed stops writing to the log file after the first failure, which is
+	// warned about once. The log file must not change the result of the command.
+	fileFailed atomic.Bool
+	warnOnce   sync.Once
+}
+
+func (c *teeCommandLogger) Write(p []byte) (int, error) {
+	if !c.fileFailed.Load() {
+		if _, err := c.file.Write(p); err != nil {
+			c.fileFailed.Store(true)
+			c.warnOnce.Do(func() {
+				c.warnf("failed to write command output to the log file, it is incomplete: %v", err)
+			})
+		}
+	}
+
+	return c.primary.Write(p)
+}
+
+func (c *teeCommandLogger) Progress(p Progress) {
+	c.file.Progress(p)
+	c.primary.Progress(p)
+}
+
+func (c *teeCommandLogger) Debug(line string) {
+	c.file.Debug(line)
+	c.primary.Debug(line)
+}
+
+func (c *teeCommandLogger) Done() {
+	c.file.Done()
+	c.primary.Done()
+}
+
+func (c *teeCommandLogger) Fail(exitCode int) {
+	c.file.Fail(exitCode)
+	c.primary.Fail(exitCode)
+}
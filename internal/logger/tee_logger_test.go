@@ -0,0 +1,171 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// nopWriteCloser records whether the log file was closed.
+type nopWriteCloser struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (w *nopWriteCloser) Close() error {
+	w.closed = true
+	return nil
+}
+
+func Test_teeLogger(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		level           LogLevel
+		run             func(l Logger)
+		wantFile        []string
+		wantTerminal    []string
+		wantTerminalOut []string
+	}{
+		{
+			name:  "[正常系] ログファイルには端末のレベルに関係なくdebugログが書き込まれる",
+			level: slog.LevelError,
+			run: func(l Logger) {
+				l.Debugf("fetcher version: %d", 3)
+				l.Info("loaded pnpm-lock.yaml")
+			},
+			wantFile:        []string{"DEBUG fetcher version: 3", "INFO loaded pnpm-lock.yaml"},
+			wantTerminalOut: []string{"fetcher version", "loaded pnpm-lock.yaml"},
+		},
+		{
+			name:  "[正常系] コマンドの全出力と生の出力がログファイルに書き込まれる",
+			level: slog.LevelInfo,
+			run: func(l Logger) {
+				cl := l.CommandLogger(slog.LevelInfo, "pnpm install")
+				_, _ = cl.Write([]byte("Progress: resolved 1\n"))
+				cl.Debug(`{"name":"pnpm:stage"}`)
+				cl.Done()
+			},
+			wantFile: []string{
+				`INFO scope="pnpm install" Progress: resolved 1`,
+				`DEBUG scope="pnpm install" {"name":"pnpm:stage"}`,
+				"INFO pnpm install completed in",
+			},
+			wantTerminal:    []string{`scope="pnpm install" Progress: resolved 1`},
+			wantTerminalOut: []string{`{"name":"pnpm:stage"}`},
+		},
+		{
+			name:  "[正常系] ステップの失敗が両方に書き込まれる",
+			level: slog.LevelInfo,
+			run: func(l Logger) {
+				sl := l.StepLogger(slog.LevelInfo, "compute NAR hash")
+				sl.Fail(errors.New("boom"))
+			},
+			wantFile:     []string{"ERROR compute NAR hash failed in"},
+			wantTerminal: []string{"ERROR compute NAR hash failed in"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			terminal := &bytes.Buffer{}
+			file := &nopWriteCloser{}
+			l := newTeeLogger(newTextLogger(tt.level, terminal, CI("")), file)
+
+			tt.run(l)
+			if err := l.Close(); err != nil {
+				t.Fatalf("Close() error: %v", err)
+			}
+
+			if !file.closed {
+				t.Error("log file was not closed")
+			}
+			for _, want := range tt.wantFile {
+				if !strings.Contains(file.String(), want) {
+					t.Errorf("log file = %q, want to contain %q", file.String(), want)
+				}
+			}
+			for _, want := range tt.wantTerminal {
+				if !strings.Contains(terminal.String(), want) {
+					t.Errorf("terminal = %q, want to contain %q", terminal.String(), want)
+				}
+			}
+			for _, absent := range tt.wantTerminalOut {
+				if strings.Contains(terminal.String(), absent) {
+					t.Errorf("terminal = %q, should not contain %q", terminal.String(), absent)
+				}
+			}
+		})
+	}
+}
+
+// failingCommandLogger is a CommandLogger whose writes fail, like a full disk.
+type failingCommandLogger struct {
+	CommandLogger
+}
+
+func (failingCommandLogger) Write([]byte) (int, error) {
+	return 0, errors.New("no space left on device")
+}
+
+func Test_teeCommandLogger_Write(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		file         func(primary Logger) CommandLogger
+		wantTerminal []string
+		wantWarnings int
+	}{
+		{
+			name: "[正常系] 両方に書き込まれる",
+			file: func(primary Logger) CommandLogger {
+				return primary.CommandLogger(slog.LevelInfo, "pnpm install")
+			},
+			wantTerminal: []string{"line 1", "line 2"},
+		},
+		{
+			name: "[異常系] ログファイルへの書き込みに失敗しても端末への書き込みは続き、警告は一度だけ",
+			file: func(Logger) CommandLogger {
+				return failingCommandLogger{}
+			},
+			wantTerminal: []string{"line 1", "line 2", "failed to write command output to the log file"},
+			wantWarnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			terminal := &bytes.Buffer{}
+			primary := newTextLogger(slog.LevelInfo, terminal, CI(""))
+			c := &teeCommandLogger{
+				primary: primary.CommandLogger(slog.LevelInfo, "pnpm install"),
+				file:    tt.file(newTextLogger(slog.LevelInfo, &bytes.Buffer{}, CI(""))),
+				warnf:   primary.Warnf,
+			}
+
+			for _, line := range []string{"line 1\n", "line 2\n"} {
+				n, err := c.Write([]byte(line))
+				if err != nil || n != len(line) {
+					t.Fatalf("Write() = %d, %v, want %d, nil", n, err, len(line))
+				}
+			}
+
+			for _, want := range tt.wantTerminal {
+				if !strings.Contains(terminal.String(), want) {
+					t.Errorf("terminal = %q, want to contain %q", terminal.String(), want)
+				}
+			}
+			if got := strings.Count(terminal.String(), "WARN"); got != tt.wantWarnings {
+				t.Errorf("terminal has %d warnings, want %d: %q", got, tt.wantWarnings, terminal.String())
+			}
+		})
+	}
+}
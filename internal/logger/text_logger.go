@@ -5,16 +5,23 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
-	"os"
 	"strings"
 	"time"
 )
 
+// progressLogInterval is the minimum time between progress summary lines of a command.
+const progressLogInterval = 5 * time.Second
+
+// textLogger implements Logger with one line per message, written by a slog.Handler
+// in the text format or, for structured logs, in JSON or logfmt.
 type textLogger struct {
 	logger *slog.Logger
 	level  LogLevel
 	w      io.Writer
 	ci     CI
+	// structured logs carry the phase, exit code and duration of steps and
+	// commands as attributes, which the text format already includes in the message.
+	structured bool
 }
 
 func newTextLogger(level LogLevel, w io.Writer, ci CI) Logger {
@@ -27,16 +34,22 @@ func newTextLogger(level LogLevel, w io.Writer, ci CI) Logger {
 	}
 }
 
+// newStructuredLogger returns a logger writing format to w. CI folding markers are
+// not written, as they would break the format.
+func newStructuredLogger(level LogLevel, w io.Writer, format Format) Logger {
+	return &textLogger{
+		logger:     slog.New(format.newHandler(w, level)),
+		level:      level,
+		w:          w,
+		structured: true,
+	}
+}
+
 func (l *textLogger) Debug(msg string, args ...any) { l.logger.Debug(msg, args...) }
 func (l *textLogger) Info(msg string, args ...any)  { l.logger.Info(msg, args...) }
 func (l *textLogger) Warn(msg string, args ...any)  { l.logger.Warn(msg, args...) }
 func (l *textLogger) Error(msg string, args ...any) { l.logger.Error(msg, args...) }
 
-func (l *textLogger) Fatal(msg string, args ...any) {
-	l.logger.Error(msg, args...)
-	os.Exit(1)
-}
-
 func (l *textLogger) Debugf(tmpl string, args ...any) {
 	l.logger.Debug(fmt.Errorf(tmpl, args...).Error())
 }
@@ -53,31 +66,33 @@ func (l *textLogger) Errorf(tmpl string, args ...any) {
 	l.logger.Error(fmt.Errorf(tmpl, args...).Error())
 }
 
-func (l *textLogger) Fatalf(tmpl string, args ...any) {
-	l.logger.Error(fmt.Errorf(tmpl, args...).Error())
-	os.Exit(1)
-}
-
 func (l *textLogger) StepLogger(logLevel LogLevel, msg string) StepLogger {
 	start := time.Now()
-	l.log(logLevel, "start "+msg)
+	l.log(logLevel, "start "+msg, l.fields("phase", msg)...)
 
 	return &textStepLogger{
-		logger:   l,
-		logLevel: logLevel,
-		msg:      msg,
-		start:    start.Round(0), // Remove monotonic time
+		logger:           l,
+		logLevel:         logLevel,
+		msg:              msg,
+		start:            start.Round(0), // Remove monotonic time
+		progressInterval: progressLogInterval,
 	}
 }
 
 func (l *textLogger) CommandLogger(logLevel LogLevel, name string) CommandLogger {
 	start := time.Now()
 	cl := &textCommandLogger{
-		logger:       l,
-		scopedLogger: l.logger.With("scope", name),
-		logLevel:     logLevel,
-		name:         name,
-		start:        start.Round(0), // Remove monotonic time
+		logger:           l,
+		scopedLogger:     l.logger.With("scope", name),
+		logLevel:         logLevel,
+		name:             name,
+		start:            start.Round(0), // Remove monotonic time
+		progressInterval: progressLogInterval,
+	}
+	// Output lines are logged at info level; below that they are retained
+	// and only logged if the command fails.
+	if !l.logger.Enabled(context.Background(), slog.LevelInfo) {
+		cl.output = newOutputSpool()
 	}
 	cl.writeFoldStart()
 	return cl
@@ -87,8 +102,17 @@ func (l *textLogger) Close() error {
 	return nil
 }
 
-func (l *textLogger) log(level LogLevel, msg string) {
-	l.logger.Log(context.TODO(), level, msg)
+func (l *textLogger) log(level LogLevel, msg string, args ...any) {
+	l.logger.Log(context.TODO(), level, msg, args...)
+}
+
+// fields returns the key-value pairs args for structured logs and nothing otherwise.
+func (l *textLogger) fields(args ...any) []any {
+	if !l.structured {
+		return nil
+	}
+
+	return args
 }
 
 // textStepLogger implements StepLogger for text mode.
@@ -97,16 +121,46 @@ type textStepLogger struct {
 	logLevel LogLevel
 	msg      string
 	start    time.Time
+
+	progressInterval time.Duration // minimum time between progress summary lines
+	lastProgress     time.Time     // when the last progress summary was logged
+}
+
+// Progress logs a summary line at most every progressInterval; the completion of
+// a phase is always logged.
+func (s *textStepLogger) Progress(p StepProgress) {
+	if now := time.Now(); p.done() || now.Sub(s.lastProgress) >= s.progressInterval {
+		s.lastProgress = now
+		s.logger.log(
+			s.logLevel,
+			fmt.Sprintf("%s progress: %s", s.msg, p.summary()),
+			s.logger.fields(
+				"phase", s.msg,
+				"subphase", p.Phase,
+				"files", p.Current,
+				"total_files", p.Total,
+				"bytes", p.Bytes,
+				"total_bytes", p.TotalBytes,
+			)...,
+		)
+	}
 }
 
 func (s *textStepLogger) Done() {
 	elapsed := time.Since(s.start)
-	s.logger.log(s.logLevel, fmt.Sprintf("%s completed in %s", s.msg, elapsed))
+	s.logger.log(
+		s.logLevel,
+		fmt.Sprintf("%s completed in %s", s.msg, elapsed),
+		s.logger.fields("phase", s.msg, "duration", elapsed)...,
+	)
 }
 
 func (s *textStepLogger) Fail(err error) {
 	elapsed := time.Since(s.start)
-	s.logger.logger.Error(fmt.Sprintf("%s failed in %s", s.msg, elapsed), "error", err)
+	s.logger.logger.Error(
+		fmt.Sprintf("%s failed in %s", s.msg, elapsed),
+		append([]any{"error", err}, s.logger.fields("phase", s.msg, "duration", elapsed)...)...,
+	)
 }
 
 // textCommandLogger implements CommandLogger for text mode.
@@ -116,26 +170,94 @@ type textCommandLogger struct {
 	logLevel     LogLevel
 	name         string
 	start        time.Time
+
+	progressInterval time.Duration // minimum time between progress summary lines
+	progress         *Progress     // latest progress not logged yet
+	lastProgress     time.Time     // when the last progress summary was logged
+
+	output *outputSpool // output retained while it is not logged, nil otherwise
 }
 
 func (c *textCommandLogger) Write(p []byte) (int, error) {
 	for line := range strings.SplitSeq(strings.Trim(string(p), "\n"), "\n") {
+		if c.output != nil {
+			c.output.writeLine(line)
+			continue
+		}
 		c.scopedLogger.Info(line)
 	}
 	return len(p), nil
 }
 
+// Progress logs a summary line at most every progressInterval; the latest
+// progress is always logged when the command finishes.
+func (c *textCommandLogger) Progress(p Progress) {
+	c.progress = &p
+
+	if now := time.Now(); now.Sub(c.lastProgress) >= c.progressInterval {
+		c.lastProgress = now
+		c.flushProgress()
+	}
+}
+
+func (c *textCommandLogger) Debug(line string) {
+	c.scopedLogger.Debug(line)
+}
+
+func (c *textCommandLogger) flushProgress() {
+	if c.progress == nil {
+		return
+	}
+
+	c.logger.log(
+		c.logLevel,
+		fmt.Sprintf("%s progress: %s", c.name, c.progress.summary()),
+		c.logger.fields(
+			"phase", c.name,
+			"resolved", c.progress.Resolved,
+			"fetched", c.progress.Fetched,
+			"added", c.progress.Added,
+			"total", c.progress.Total,
+			"downloaded_bytes", c.progress.Downloaded,
+		)...,
+	)
+	c.progress = nil
+}
+
 func (c *textCommandLogger) Done() {
+	if c.output != nil {
+		c.output.close()
+	}
+	c.flushProgress()
 	elapsed := time.Since(c.start)
-	c.logger.log(c.logLevel, fmt.Sprintf("%s completed in %s", c.name, elapsed))
+	c.logger.log(
+		c.logLevel,
+		fmt.Sprintf("%s completed in %s", c.name, elapsed),
+		c.logger.fields("phase", c.name, "exit_code", 0, "duration", elapsed)...,
+	)
 	c.writeFoldEnd()
 }
 
 func (c *textCommandLogger) Fail(exitCode int) {
+	if c.output != nil {
+		// The output was not logged; show it in full so the cause of the failure is visible.
+		if out := c.output.lines(); out != "" {
+			for line := range strings.SplitSeq(out, "\n") {
+				c.scopedLogger.Error(line)
+			}
+		}
+		c.output.close()
+	}
+	c.flushProgress()
 	elapsed := time.Since(c.start)
 	c.logger.logger.Error(
 		fmt.Sprintf("%s failed with exit code %d in %s", c.name, exitCode, elapsed),
+		c.logger.fields("phase", c.name, "exit_code", exitCode, "duration", elapsed)...,
 	)
+	if c.logger.ci == buildkite {
+		// Expand the collapsed "---" group so that the failure is visible.
+		fmt.Fprintln(c.logger.w, "^^^ +++")
+	}
 	c.writeFoldEnd()
 }
 
@@ -147,12 +269,12 @@ func (c *textCommandLogger) foldID() string {
 func (c *textCommandLogger) writeFoldStart() {
 	ci := c.logger.ci
 	switch ci {
-	case gitHubActions:
+	case gitHubActions, forgejoActions:
 		fmt.Fprintf(c.logger.w, "::group::%s\n", c.name)
 	case gitLabCI:
 		fmt.Fprintf(
 			c.logger.w,
-			"\x1b[0Ksection_start:%d:%s\r\x1b[0K%s\n",
+			"\x1b[0Ksection_start:%d:%s[collapsed=true]\r\x1b[0K%s\n",
 			time.Now().Unix(),
 			c.foldID(),
 			c.name,
@@ -165,17 +287,18 @@ func (c *textCommandLogger) writeFoldStart() {
 		fmt.Fprintf(c.logger.w, "--- %s\n", c.name)
 	case travisCI:
 		fmt.Fprintf(c.logger.w, "travis_fold:start:%s\n%s\n", c.foldID(), c.name)
-	case others:
-		c.logger.log(c.logLevel, "start "+c.name)
+	case jenkins, circleCI, woodpecker, others:
+		// No folding syntax in the plain log output of these providers.
+		c.logger.log(c.logLevel, "start "+c.name, c.logger.fields("phase", c.name)...)
 	default:
-		c.logger.log(c.logLevel, "start "+c.name)
+		c.logger.log(c.logLevel, "start "+c.name, c.logger.fields("phase", c.name)...)
 	}
 }
 
 func (c *textCommandLogger) writeFoldEnd() {
 	ci := c.logger.ci
 	switch ci {
-	case gitHubActions:
+	case gitHubActions, forgejoActions:
 		fmt.Fprintln(c.logger.w, "::endgroup::")
 	case gitLabCI:
 		fmt.Fprintf(
@@ -190,6 +313,6 @@ func (c *textCommandLogger) writeFoldEnd() {
 		fmt.Fprintf(c.logger.w, "##teamcity[blockClosed name='%s']\n", c.name)
 	case travisCI:
 		fmt.Fprintf(c.logger.w, "travis_fold:end:%s\n", c.foldID())
-	case buildkite, others:
+	case buildkite, jenkins, circleCI, woodpecker, others:
 	}
 }
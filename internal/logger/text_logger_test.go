@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 )
 
 func Test_newTextLogger(t *testing.T) {
@@ -173,6 +174,137 @@ func Test_textStepLogger_Fail(t *testing.T) {
 	}
 }
 
+func Test_textCommandLogger_Fail_output(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		level      LogLevel
+		fail       bool
+		wantErrors []string
+	}{
+		{
+			name:  "[正常系] infoが無効な場合は失敗時に全出力がerrorで出力される",
+			level: slog.LevelError,
+			fail:  true,
+			wantErrors: []string{
+				`scope="pnpm install" line1`,
+				`scope="pnpm install" line2`,
+				"pnpm install failed with exit code 1",
+			},
+		},
+		{
+			name:  "[正常系] infoが無効でも成功時は出力されない",
+			level: slog.LevelError,
+			fail:  false,
+		},
+		{
+			name:  "[正常系] infoが有効な場合は出力済みのため再出力されない",
+			level: slog.LevelInfo,
+			fail:  true,
+			wantErrors: []string{
+				"pnpm install failed with exit code 1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &bytes.Buffer{}
+			l := newTextLogger(tt.level, w, CI("")).(*textLogger)
+			cl := l.CommandLogger(slog.LevelInfo, "pnpm install")
+			_, _ = cl.Write([]byte("line1\nline2\n"))
+			if tt.fail {
+				cl.Fail(1)
+			} else {
+				cl.Done()
+			}
+
+			var got []string
+			for line := range strings.SplitSeq(w.String(), "\n") {
+				if _, msg, ok := strings.Cut(line, "ERROR "); ok {
+					got = append(got, msg)
+				}
+			}
+
+			if len(got) != len(tt.wantErrors) {
+				t.Fatalf("error lines = %q, want %d lines", got, len(tt.wantErrors))
+			}
+			for i, want := range tt.wantErrors {
+				if !strings.HasPrefix(got[i], want) {
+					t.Errorf("error line %d = %q, want prefix %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func Test_textStepLogger_Progress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		interval  time.Duration
+		updates   []StepProgress
+		wantLines []string
+	}{
+		{
+			name:     "[正常系] 間隔内の進捗は省略されフェーズの完了は出力される",
+			interval: time.Hour,
+			updates: []StepProgress{
+				{Phase: "hash", Total: 3},
+				{Phase: "hash", Current: 1, Total: 3},
+				{Phase: "hash", Current: 2, Total: 3},
+				{Phase: "hash", Current: 3, Total: 3},
+			},
+			wantLines: []string{
+				"compute NAR hash progress: hash: 0/3 files",
+				"compute NAR hash progress: hash: 3/3 files",
+			},
+		},
+		{
+			name:     "[正常系] 間隔が0なら全ての進捗が出力される",
+			interval: 0,
+			updates: []StepProgress{
+				{Phase: "hash", Current: 1, Total: 2, Bytes: 512, TotalBytes: 1024},
+				{Phase: "hash", Current: 2, Total: 2, Bytes: 1024, TotalBytes: 1024},
+			},
+			wantLines: []string{
+				"compute NAR hash progress: hash: 1/2 files, 512 B/1.0 KiB",
+				"compute NAR hash progress: hash: 2/2 files, 1.0 KiB/1.0 KiB",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &bytes.Buffer{}
+			l := newTextLogger(slog.LevelDebug, w, CI("")).(*textLogger)
+			sl := l.StepLogger(slog.LevelInfo, "compute NAR hash").(*textStepLogger)
+			sl.progressInterval = tt.interval
+
+			for _, p := range tt.updates {
+				sl.Progress(p)
+			}
+
+			var got []string
+			for line := range strings.SplitSeq(w.String(), "\n") {
+				if _, msg, ok := strings.Cut(line, "INFO "); ok && strings.Contains(msg, "progress:") {
+					got = append(got, msg)
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("progress lines = %q, want %q", got, tt.wantLines)
+			}
+		})
+	}
+}
+
 func Test_textLogger_CommandLogger(t *testing.T) {
 	t.Parallel()
 
@@ -207,7 +339,7 @@ func Test_textLogger_CommandLogger(t *testing.T) {
 			logLevel:  slog.LevelInfo,
 			cmdName:   "pnpm install",
 			wantType:  reflect.TypeFor[*textCommandLogger](),
-			wantRegex: `\x1b\[0Ksection_start:\d+:pnpm_install\r\x1b\[0Kpnpm install`,
+			wantRegex: `\x1b\[0Ksection_start:\d+:pnpm_install\[collapsed=true\]\r\x1b\[0Kpnpm install`,
 		},
 		{
 			name:     "[正常系] Azure Pipelinesでは折りたたみ開始構文が出力される",
@@ -495,11 +627,18 @@ func Test_textCommandLogger_Fail(t *testing.T) {
 			wantLog:  "##teamcity[blockClosed name='pnpm install']",
 		},
 		{
-			name:      "[正常系] Buildkiteでは折りたたみ終了構文なしで失敗ログが出力される",
+			name:      "[正常系] Buildkiteでは失敗ログの後に折りたたみを展開する構文が出力される",
 			ci:        buildkite,
 			cmdName:   "pnpm install",
 			exitCode:  1,
-			wantRegex: `pnpm install failed with exit code 1 in \S+`,
+			wantRegex: `pnpm install failed with exit code 1 in \S+\n\^\^\^ \+\+\+\n`,
+		},
+		{
+			name:     "[正常系] Forgejo Actionsでは失敗ログの後に折りたたみ終了構文が出力される",
+			ci:       forgejoActions,
+			cmdName:  "pnpm install",
+			exitCode: 1,
+			wantLog:  "::endgroup::",
 		},
 		{
 			name:     "[正常系] Travis CIでは失敗ログの後に折りたたみ終了構文が出力される",
@@ -539,3 +678,67 @@ func Test_textCommandLogger_Fail(t *testing.T) {
 		})
 	}
 }
+
+func Test_textCommandLogger_Progress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		interval  time.Duration
+		updates   []Progress
+		wantLines []string
+	}{
+		{
+			name:     "[正常系] 間隔内の進捗はまとめられ終了時に最新の進捗が出力される",
+			interval: time.Hour,
+			updates: []Progress{
+				{Resolved: 1},
+				{Resolved: 2},
+				{Resolved: 3, Fetched: 1},
+			},
+			wantLines: []string{
+				"pnpm install progress: resolved 1, fetched 0, added 0, 0 B downloaded",
+				"pnpm install progress: resolved 3, fetched 1, added 0, 0 B downloaded",
+			},
+		},
+		{
+			name:     "[正常系] 間隔が0なら全ての進捗が出力される",
+			interval: 0,
+			updates: []Progress{
+				{Resolved: 1},
+				{Resolved: 2, Total: 2, Fetched: 1, Downloaded: 1536},
+			},
+			wantLines: []string{
+				"pnpm install progress: resolved 1, fetched 0, added 0, 0 B downloaded",
+				"pnpm install progress: resolved 2, fetched 1/2, added 0, 1.5 KiB downloaded",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &bytes.Buffer{}
+			l := newTextLogger(slog.LevelDebug, w, CI("")).(*textLogger)
+			cl := l.CommandLogger(slog.LevelInfo, "pnpm install").(*textCommandLogger)
+			cl.progressInterval = tt.interval
+
+			for _, p := range tt.updates {
+				cl.Progress(p)
+			}
+			cl.Done()
+
+			var got []string
+			for line := range strings.SplitSeq(w.String(), "\n") {
+				if _, msg, ok := strings.Cut(line, "INFO "); ok && strings.Contains(msg, "progress:") {
+					got = append(got, msg)
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("progress lines = %q, want %q", got, tt.wantLines)
+			}
+		})
+	}
+}
@@ -2,39 +2,65 @@ package logger
 
 import (
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 )
 
 // tuiCommandLogger implements CommandLogger for TUI mode.
+// The complete output is retained in a spool and shown in full if the command fails.
 type tuiCommandLogger struct {
 	logger *tuiLogger
+	name   string
 	start  time.Time
+	output *outputSpool
+	// silent commands are below the log level threshold: nothing is shown
+	// unless the command fails.
+	silent bool
 }
 
 func (c *tuiCommandLogger) Write(p []byte) (int, error) {
 	for line := range strings.SplitSeq(strings.TrimRight(string(p), "\n"), "\n") {
-		c.logger.send(cmdLineMsg{line: line})
+		c.output.writeLine(line)
+		if !c.silent {
+			c.logger.send(cmdLineMsg{line: line})
+		}
 	}
 	return len(p), nil
 }
 
+func (c *tuiCommandLogger) Progress(p Progress) {
+	if !c.silent {
+		c.logger.send(cmdProgressMsg{progress: p})
+	}
+}
+
+func (c *tuiCommandLogger) Debug(line string) {
+	if !c.silent && c.logger.enabled(slog.LevelDebug) {
+		c.logger.send(cmdLineMsg{line: line})
+	}
+}
+
 func (c *tuiCommandLogger) Done() {
+	defer c.output.close()
+	if c.silent {
+		return
+	}
+
 	elapsed := time.Since(c.start)
 	c.logger.send(cmdDoneMsg{elapsed: elapsed})
 }
 
 func (c *tuiCommandLogger) Fail(exitCode int) {
+	defer c.output.close()
 	elapsed := time.Since(c.start)
-	c.logger.send(cmdFailMsg{exitCode: exitCode, elapsed: elapsed})
-}
-
-// noopCommandLogger is returned when log level is below threshold.
-type noopCommandLogger struct{}
+	if c.silent {
+		c.logger.send(logLineMsg{line: renderCmdFailure(c.name, exitCode, elapsed, c.output.lines())})
+		return
+	}
 
-func (c *noopCommandLogger) Write(p []byte) (int, error) { return len(p), nil }
-func (c *noopCommandLogger) Done()                       {}
-func (c *noopCommandLogger) Fail(_ int)                  {}
+	c.logger.send(cmdFailMsg{exitCode: exitCode, elapsed: elapsed, output: c.output.lines()})
+}
 
 // formatKV formats a message with key-value pairs for TUI display.
 func formatKV(msg string, args ...any) string {
@@ -77,12 +77,6 @@ func (l *tuiLogger) Error(msg string, args ...any) {
 	l.send(logLineMsg{line: failStyle.Render("✖") + " " + formatKV(msg, args...)})
 }
 
-func (l *tuiLogger) Fatal(msg string, args ...any) {
-	l.send(logLineMsg{line: failStyle.Render("✖") + " " + formatKV(msg, args...)})
-	_ = l.Close()
-	os.Exit(1)
-}
-
 func (l *tuiLogger) Debugf(tmpl string, args ...any) {
 	if !l.enabled(slog.LevelDebug) {
 		return
@@ -111,12 +105,6 @@ func (l *tuiLogger) Errorf(tmpl string, args ...any) {
 	l.send(logLineMsg{line: failStyle.Render("✖") + " " + fmt.Errorf(tmpl, args...).Error()})
 }
 
-func (l *tuiLogger) Fatalf(tmpl string, args ...any) {
-	l.send(logLineMsg{line: failStyle.Render("✖") + " " + fmt.Errorf(tmpl, args...).Error()})
-	_ = l.Close()
-	os.Exit(1)
-}
-
 func (l *tuiLogger) StepLogger(logLevel LogLevel, msg string) StepLogger {
 	if !l.enabled(logLevel) {
 		return &noopStepLogger{}
@@ -126,13 +114,25 @@ func (l *tuiLogger) StepLogger(logLevel LogLevel, msg string) StepLogger {
 }
 
 func (l *tuiLogger) CommandLogger(logLevel LogLevel, name string) CommandLogger {
-	if !l.enabled(logLevel) {
-		return &noopCommandLogger{}
+	c := &tuiCommandLogger{
+		logger: l,
+		name:   name,
+		start:  time.Now().Round(0),
+		output: newOutputSpool(),
+		silent: !l.enabled(logLevel),
+	}
+	if !c.silent {
+		l.send(cmdStartMsg{name: name})
 	}
-	l.send(cmdStartMsg{name: name})
-	return &tuiCommandLogger{logger: l, start: time.Now().Round(0)}
+	return c
 }
 
+// Annotate does nothing, as the TUI is not used in CI.
+func (l *tuiLogger) Annotate(_ Annotation) {}
+
+// WriteSummary does nothing, as the TUI is not used in CI.
+func (l *tuiLogger) WriteSummary(_ Summary) {}
+
 func (l *tuiLogger) Close() error {
 	l.closeOnce.Do(func() {
 		close(l.done)
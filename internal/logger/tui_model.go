@@ -14,16 +14,19 @@ const ringBufferSize = 10
 
 // TUI message types.
 type (
-	logLineMsg   struct{ line string }
-	stepStartMsg struct{ msg string }
-	stepDoneMsg  struct{}
-	stepFailMsg  struct{ err error }
-	cmdStartMsg  struct{ name string }
-	cmdLineMsg   struct{ line string }
-	cmdDoneMsg   struct{ elapsed time.Duration }
-	cmdFailMsg   struct {
+	logLineMsg      struct{ line string }
+	stepStartMsg    struct{ msg string }
+	stepProgressMsg struct{ progress StepProgress }
+	stepDoneMsg     struct{}
+	stepFailMsg     struct{ err error }
+	cmdStartMsg     struct{ name string }
+	cmdLineMsg      struct{ line string }
+	cmdProgressMsg  struct{ progress Progress }
+	cmdDoneMsg      struct{ elapsed time.Duration }
+	cmdFailMsg      struct {
 		exitCode int
 		elapsed  time.Duration
+		output   string // complete output of the command
 	}
 	interruptMsg struct{}
 )
@@ -43,6 +46,7 @@ var (
 			MarginLeft(cmdBoxMarginLeft)
 
 	overflowStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	progressStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
 )
 
 // tuiModel is the Bubble Tea model.
@@ -58,6 +62,9 @@ type activeEntry struct {
 	isCommand  bool
 	ringBuf    []string
 	totalLines int
+	progress   *Progress // latest progress reported by the command
+
+	stepProgress *StepProgress // latest progress reported by the step
 }
 
 func newTUIModel() tuiModel {
@@ -94,6 +101,12 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.active = &activeEntry{msg: msg.msg}
 		return m, nil
 
+	case stepProgressMsg:
+		if m.active != nil && !m.active.isCommand {
+			m.active.stepProgress = &msg.progress
+		}
+		return m, nil
+
 	case stepDoneMsg:
 		if m.active != nil {
 			m.lines = append(m.lines, doneStyle.Render("✓")+" "+m.active.msg)
@@ -125,6 +138,12 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case cmdProgressMsg:
+		if m.active != nil && m.active.isCommand {
+			m.active.progress = &msg.progress
+		}
+		return m, nil
+
 	case cmdDoneMsg:
 		if m.active != nil {
 			m.lines = append(m.lines,
@@ -140,16 +159,11 @@ func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case cmdFailMsg:
 		if m.active != nil {
-			header := failStyle.Render("✖") +
-				fmt.Sprintf(
-					" `%s` failed with exit code %d in %s",
-					m.active.msg, msg.exitCode, msg.elapsed,
-				)
-			if len(m.active.ringBuf) > 0 {
-				cmdOutput := strings.Join(m.active.ringBuf, "\n")
-				header += "\n" + cmdBoxStyle.Render(cmdOutput)
+			cmdOutput := msg.output
+			if cmdOutput == "" {
+				cmdOutput = strings.Join(m.active.ringBuf, "\n")
 			}
-			m.lines = append(m.lines, header)
+			m.lines = append(m.lines, renderCmdFailure(m.active.msg, msg.exitCode, msg.elapsed, cmdOutput))
 			m.active = nil
 		}
 		return m, nil
@@ -184,6 +198,15 @@ func (m tuiModel) View() string {
 	if m.active.isCommand {
 		fmt.Fprintf(&b, "Executing `%s` ...", m.active.msg)
 		b.WriteByte('\n')
+		if p := m.active.progress; p != nil {
+			b.WriteString(strings.Repeat(" ", cmdBoxMarginLeft))
+			if bar := p.bar(progressBarWidth); bar != "" {
+				b.WriteString(progressStyle.Render(bar))
+				b.WriteByte(' ')
+			}
+			b.WriteString(p.summary())
+			b.WriteByte('\n')
+		}
 		cmdLines := append([]string{}, m.active.ringBuf...)
 		overflow := m.active.totalLines - len(m.active.ringBuf)
 		if overflow > 0 {
@@ -199,10 +222,29 @@ func (m tuiModel) View() string {
 	} else {
 		b.WriteString(m.active.msg)
 		b.WriteByte('\n')
+		if p := m.active.stepProgress; p != nil {
+			b.WriteString(strings.Repeat(" ", cmdBoxMarginLeft))
+			if bar := p.bar(progressBarWidth); bar != "" {
+				b.WriteString(progressStyle.Render(bar))
+				b.WriteByte(' ')
+			}
+			b.WriteString(p.summary())
+			b.WriteByte('\n')
+		}
 	}
 	return b.String()
 }
 
+// renderCmdFailure renders the line of a failed command followed by its output.
+func renderCmdFailure(name string, exitCode int, elapsed time.Duration, output string) string {
+	header := failStyle.Render("✖") +
+		fmt.Sprintf(" `%s` failed with exit code %d in %s", name, exitCode, elapsed)
+	if output != "" {
+		header += "\n" + cmdBoxStyle.Render(output)
+	}
+	return header
+}
+
 func appendRing(buf []string, line string) []string {
 	if len(buf) >= ringBufferSize {
 		copy(buf, buf[1:])
@@ -215,6 +215,23 @@ func Test_tuiModel_Update_completeLine(t *testing.T) {
 				"resolving...", "ERROR: not found",
 			},
 		},
+		{
+			name: "[正常系] cmdFailMsgに全出力がある場合はバッファから溢れた行も表示される",
+			setup: func() tuiModel {
+				m := newTUIModel()
+				m = updateModel(m, cmdStartMsg{name: "pnpm install"})
+				for i := range 15 {
+					m = updateModel(m, cmdLineMsg{line: fmt.Sprintf("line%d", i)})
+				}
+				return m
+			},
+			msg: cmdFailMsg{
+				exitCode: 1,
+				elapsed:  5 * time.Second,
+				output:   "ERR_PNPM_FETCH_404 first line\nline14",
+			},
+			wantContains: []string{"failed with exit code", "ERR_PNPM_FETCH_404 first line", "line14"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -428,6 +445,49 @@ func Test_tuiModel_View(t *testing.T) {
 				"fetching...",
 			},
 		},
+		{
+			name: "[正常系] command実行中に進捗がある場合はプログレスバーと件数が表示される",
+			setup: func() tuiModel {
+				m := newTUIModel()
+				m = updateModel(m, cmdStartMsg{name: "pnpm install"})
+				m = updateModel(m, cmdProgressMsg{progress: Progress{
+					Resolved:   4,
+					Fetched:    2,
+					Total:      4,
+					Downloaded: 2048,
+					ETA:        3 * time.Second,
+				}})
+				return m
+			},
+			wantContains: []string{
+				"Executing `pnpm install`",
+				"[" + strings.Repeat("█", 15) + strings.Repeat("░", 15) + "]",
+				"fetched 2/4",
+				"2.0 KiB downloaded",
+				"ETA 3s",
+			},
+		},
+		{
+			name: "[正常系] step実行中に進捗がある場合はプログレスバーと件数が表示される",
+			setup: func() tuiModel {
+				m := newTUIModel()
+				m = updateModel(m, stepStartMsg{msg: "compute NAR hash"})
+				m = updateModel(m, stepProgressMsg{progress: StepProgress{
+					Phase:      "hash",
+					Current:    1,
+					Total:      2,
+					Bytes:      1024,
+					TotalBytes: 4096,
+				}})
+				return m
+			},
+			wantContains: []string{
+				"compute NAR hash",
+				"[" + strings.Repeat("█", 7) + strings.Repeat("░", 23) + "]",
+				"hash: 1/2 files, 1.0 KiB/4.0 KiB",
+			},
+			wantAbsent: []string{"Executing"},
+		},
 		{
 			name: "[正常系] command実行中にoverflowがある場合は件数が表示される",
 			setup: func() tuiModel {
@@ -1,8 +1,23 @@
 package logger
 
+import "time"
+
+// stepProgressInterval is the minimum time between progress updates sent to the TUI,
+// so that steps processing many small files do not flood the program.
+const stepProgressInterval = 100 * time.Millisecond
+
 // tuiStepLogger implements StepLogger for TUI mode.
 type tuiStepLogger struct {
-	logger *tuiLogger
+	logger       *tuiLogger
+	lastProgress time.Time // when the last progress update was sent
+}
+
+// Progress sends p at most every stepProgressInterval; the completion of a phase is always sent.
+func (s *tuiStepLogger) Progress(p StepProgress) {
+	if now := time.Now(); p.done() || now.Sub(s.lastProgress) >= stepProgressInterval {
+		s.lastProgress = now
+		s.logger.send(stepProgressMsg{progress: p})
+	}
 }
 
 func (s *tuiStepLogger) Done() {
@@ -16,5 +31,6 @@ func (s *tuiStepLogger) Fail(err error) {
 // noopStepLogger is returned when log level is below threshold.
 type noopStepLogger struct{}
 
-func (s *noopStepLogger) Done()        {}
-func (s *noopStepLogger) Fail(_ error) {}
+func (s *noopStepLogger) Progress(_ StepProgress) {}
+func (s *noopStepLogger) Done()                   {}
+func (s *noopStepLogger) Fail(_ error)            {}
@@ -0,0 +1,39 @@
+package packagejson_err
+
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type FailedToParseError struct{ baseError }
+
+var _ PackageJSONErrorIF = (*FailedToParseError)(nil)
+
+func (e *FailedToParseError) Error() string {
+	errMsg := "failed to parse package.json"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = errMsg + "\ncaused by: " + e.Cause.Error()
+	}
+	return errMsg
+}
+
+func (e *FailedToParseError) Code() errcode.Code {
+	return errcode.PackageJSONParseFailed
+}
+
+func (e *FailedToParseError) Is(target error) bool {
+	_, ok := target.(*FailedToParseError)
+	return ok
+}
+
+func (e *FailedToParseError) As(target any) bool {
+	if t, ok := target.(**FailedToParseError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package packagejson_err
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type InvalidPackageManagerError struct{ baseError }
+
+var _ PackageJSONErrorIF = (*InvalidPackageManagerError)(nil)
+
+func (e *InvalidPackageManagerError) Error() string {
+	errMsg := "invalid packageManager field in package.json"
+	if e.Message != "" {
+		errMsg = fmt.Sprintf("%s: %s", errMsg, e.Message)
+	}
+
+	if e.Cause != nil {
+		errMsg = fmt.Sprintf("%s\ncaused by: %s", errMsg, e.Cause.Error())
+	}
+	return errMsg
+}
+
+func (e *InvalidPackageManagerError) Code() errcode.Code {
+	return errcode.PackageJSONInvalidPackageManager
+}
+
+func (e *InvalidPackageManagerError) Is(target error) bool {
+	_, ok := target.(*InvalidPackageManagerError)
+	return ok
+}
+
+func (e *InvalidPackageManagerError) As(target any) bool {
+	if t, ok := target.(**InvalidPackageManagerError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
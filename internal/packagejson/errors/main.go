@@ -0,0 +1,28 @@
+package packagejson_err
+
+import "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+
+type PackageJSONErrorIF interface {
+	error
+	Unwrap() error
+	Is(target error) bool
+	As(target any) bool
+
+	SetMessage(string)
+	SetCause(error)
+
+	// packageJSONError marks the errors of this package, so that errors.As and type switches
+	// can tell them apart from the errors of other packages.
+	packageJSONError()
+}
+
+func NewPackageJSONError(e PackageJSONErrorIF, message string, cause error) PackageJSONErrorIF {
+	e.SetMessage(message)
+	e.SetCause(cause)
+	return e
+}
+
+// baseError is embedded in every error type of this package to implement PackageJSONErrorIF.
+type baseError struct{ common.BaseError }
+
+func (baseError) packageJSONError() {}
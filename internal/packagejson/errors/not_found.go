@@ -0,0 +1,40 @@
+package packagejson_err
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type PackageJSONNotFoundError struct{ baseError }
+
+var _ PackageJSONErrorIF = (*PackageJSONNotFoundError)(nil)
+
+func (e *PackageJSONNotFoundError) Error() string {
+	errMsg := "package.json not found"
+	if e.Message != "" {
+		errMsg = fmt.Sprintf("%s: %s", errMsg, e.Message)
+	}
+
+	if e.Cause != nil {
+		errMsg = fmt.Sprintf("%s\ncaused by: %s", errMsg, e.Cause.Error())
+	}
+	return errMsg
+}
+
+func (e *PackageJSONNotFoundError) Code() errcode.Code {
+	return errcode.PackageJSONNotFound
+}
+
+func (e *PackageJSONNotFoundError) Is(target error) bool {
+	_, ok := target.(*PackageJSONNotFoundError)
+	return ok
+}
+
+func (e *PackageJSONNotFoundError) As(target any) bool {
+	if t, ok := target.(**PackageJSONNotFoundError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
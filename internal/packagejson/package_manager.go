@@ -0,0 +1,52 @@
+package packagejson
+
+import (
+	"strings"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+	packagejson_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson/errors"
+)
+
+// PackageManager is the packageManager field of package.json in corepack's
+// "<name>@<version>[+<hash>]" format, e.g. "pnpm@9.15.0+sha512.76e2...".
+type PackageManager struct {
+	Name    string
+	Version common.Version
+	Hash    string // "<algorithm>.<hex digest>" of the package manager tarball, empty if not pinned
+}
+
+// ParsePackageManager parses the value of the packageManager field.
+func ParsePackageManager(s string) (*PackageManager, packagejson_err.PackageJSONErrorIF) {
+	i := strings.LastIndex(s, "@")
+	if i <= 0 {
+		return nil, packagejson_err.NewPackageJSONError(
+			&packagejson_err.InvalidPackageManagerError{},
+			s,
+			nil,
+		)
+	}
+
+	v, err := common.ParseVersion(s[i+1:])
+	if err != nil {
+		return nil, packagejson_err.NewPackageJSONError(
+			&packagejson_err.InvalidPackageManagerError{},
+			s,
+			err,
+		)
+	}
+
+	// corepack appends the hash as semver build metadata.
+	hash := v.Build
+	v.Build = ""
+
+	return &PackageManager{Name: s[:i], Version: v, Hash: hash}, nil
+}
+
+// String returns the package manager in the format of the packageManager field.
+func (pm *PackageManager) String() string {
+	s := pm.Name + "@" + pm.Version.String()
+	if pm.Hash != "" {
+		s += "+" + pm.Hash
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package packagejson_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson"
+	packagejson_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson/errors"
+)
+
+func Test_ParsePackageManager(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    *packagejson.PackageManager
+		wantErr packagejson_err.PackageJSONErrorIF
+	}{
+		{
+			name:  "[正常系] バージョンのみ",
+			input: "pnpm@9.15.0",
+			want:  &packagejson.PackageManager{Name: "pnpm", Version: common.MustParseVersion("9.15.0")},
+		},
+		{
+			name:  "[正常系] ハッシュ付き",
+			input: "pnpm@10.2.1+sha512.398035c7bd696d0ba0b10a688ed558285329d27ea994804a52bad9167d8e3a72bcb993f9699585d3ca25779ac64949ef422757a6c31102c12ab932e5cbe5cc92",
+			want: &packagejson.PackageManager{
+				Name:    "pnpm",
+				Version: common.MustParseVersion("10.2.1"),
+				Hash:    "sha512.398035c7bd696d0ba0b10a688ed558285329d27ea994804a52bad9167d8e3a72bcb993f9699585d3ca25779ac64949ef422757a6c31102c12ab932e5cbe5cc92",
+			},
+		},
+		{
+			name:  "[正常系] プレリリース",
+			input: "pnpm@10.0.0-rc.1",
+			want:  &packagejson.PackageManager{Name: "pnpm", Version: common.MustParseVersion("10.0.0-rc.1")},
+		},
+		{
+			name:  "[正常系] pnpm以外",
+			input: "yarn@4.5.0",
+			want:  &packagejson.PackageManager{Name: "yarn", Version: common.MustParseVersion("4.5.0")},
+		},
+		{
+			name:    "[異常系] バージョンがない",
+			input:   "pnpm",
+			wantErr: &packagejson_err.InvalidPackageManagerError{},
+		},
+		{
+			name:    "[異常系] 名前がない",
+			input:   "@9.15.0",
+			wantErr: &packagejson_err.InvalidPackageManagerError{},
+		},
+		{
+			name:    "[異常系] バージョンがセマンティックバージョンではない",
+			input:   "pnpm@latest",
+			wantErr: &packagejson_err.InvalidPackageManagerError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := packagejson.ParsePackageManager(tt.input)
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("ParsePackageManager() mismatch (-want +got):\n%s", d)
+			}
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Errorf("ParsePackageManager() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+			if got != nil && got.String() != tt.input {
+				t.Errorf("String() = %q, want %q", got.String(), tt.input)
+			}
+		})
+	}
+}
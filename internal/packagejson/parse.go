@@ -0,0 +1,61 @@
+package packagejson
+
+import (
+	"encoding/json"
+
+	"github.com/spf13/afero"
+
+	packagejson_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson/errors"
+)
+
+// FileName is the name of the package manifest in a project directory.
+const FileName = "package.json"
+
+type PackageJSON struct {
+	Name           string  `json:"name"`
+	Version        string  `json:"version"`
+	PackageManager string  `json:"packageManager,omitempty"`
+	Engines        Engines `json:"engines,omitempty"`
+}
+
+// Engines maps names such as "node" and "pnpm" to the version ranges a project requires.
+type Engines map[string]string
+
+// UnmarshalJSON ignores engines fields that are not objects of strings, such as the
+// arrays of very old packages, which neither npm nor pnpm interpret.
+func (e *Engines) UnmarshalJSON(data []byte) error {
+	var engines map[string]string
+	if err := json.Unmarshal(data, &engines); err != nil {
+		*e = nil
+		return nil //nolint:nilerr // invalid engines fields are ignored
+	}
+
+	*e = engines
+	return nil
+}
+
+func Parse(data []byte) (*PackageJSON, packagejson_err.PackageJSONErrorIF) {
+	var p PackageJSON
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, packagejson_err.NewPackageJSONError(
+			&packagejson_err.FailedToParseError{},
+			"",
+			err,
+		)
+	}
+
+	return &p, nil
+}
+
+func Load(fs afero.Fs, path string) (*PackageJSON, packagejson_err.PackageJSONErrorIF) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, packagejson_err.NewPackageJSONError(
+			&packagejson_err.PackageJSONNotFoundError{},
+			path,
+			err,
+		)
+	}
+
+	return Parse(data)
+}
@@ -0,0 +1,88 @@
+package packagejson_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson"
+	packagejson_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson/errors"
+)
+
+func Test_Load(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		setupFs func() afero.Fs
+		want    *packagejson.PackageJSON
+		wantErr packagejson_err.PackageJSONErrorIF
+	}{
+		{
+			name: "[正常系] nameとversionを読み込む",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				_ = afero.WriteFile(fs, "/package.json", []byte(`{"name":"foo","version":"1.0.0","private":true}`), 0o644)
+				return fs
+			},
+			want: &packagejson.PackageJSON{Name: "foo", Version: "1.0.0"},
+		},
+		{
+			name: "[正常系] packageManagerを読み込む",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				_ = afero.WriteFile(fs, "/package.json", []byte(`{"name":"foo","packageManager":"pnpm@9.15.0"}`), 0o644)
+				return fs
+			},
+			want: &packagejson.PackageJSON{Name: "foo", PackageManager: "pnpm@9.15.0"},
+		},
+		{
+			name: "[正常系] enginesを読み込む",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				_ = afero.WriteFile(fs, "/package.json", []byte(`{"name":"foo","engines":{"node":">=20"}}`), 0o644)
+				return fs
+			},
+			want: &packagejson.PackageJSON{Name: "foo", Engines: packagejson.Engines{"node": ">=20"}},
+		},
+		{
+			name: "[正常系] オブジェクトではないenginesを無視する",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				_ = afero.WriteFile(fs, "/package.json", []byte(`{"name":"foo","engines":["node >= 0.4"]}`), 0o644)
+				return fs
+			},
+			want: &packagejson.PackageJSON{Name: "foo"},
+		},
+		{
+			name:    "[異常系] ファイルが存在しない",
+			setupFs: afero.NewMemMapFs,
+			wantErr: &packagejson_err.PackageJSONNotFoundError{},
+		},
+		{
+			name: "[異常系] パース失敗",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				_ = afero.WriteFile(fs, "/package.json", []byte(`{"name":`), 0o644)
+				return fs
+			},
+			wantErr: &packagejson_err.FailedToParseError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := packagejson.Load(tt.setupFs(), "/package.json")
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("Load() mismatch (-want +got):\n%s", d)
+			}
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Errorf("Load() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
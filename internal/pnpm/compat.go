@@ -0,0 +1,232 @@
+package pnpm
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile"
+)
+
+// LockfileSupport is how a pnpm version handles a lockfile version on a frozen install.
+type LockfileSupport int
+
+const (
+	// LockfileNative means that pnpm writes this lockfile version.
+	LockfileNative LockfileSupport = iota
+	// LockfileConverted means that pnpm installs from the lockfile but would write
+	// another lockfile version, so the project most likely uses another pnpm version.
+	LockfileConverted
+	// LockfileUnknown means that the pnpm version is not in the compatibility table.
+	LockfileUnknown
+	// LockfileRejected means that pnpm cannot install from the lockfile.
+	LockfileRejected
+)
+
+// versionRange is the range of versions from min (inclusive) to max (exclusive).
+type versionRange struct {
+	min common.Version
+	max common.Version
+}
+
+func newVersionRange(minVersion, maxVersion string) versionRange {
+	return versionRange{min: common.MustParseVersion(minVersion), max: common.MustParseVersion(maxVersion)}
+}
+
+func (r versionRange) contains(v common.Version) bool {
+	// Prereleases of the next major version (e.g. 10.0.0-rc.1) belong to it.
+	v.Prerelease = nil
+	return v.Compare(r.min) >= 0 && v.Compare(r.max) < 0
+}
+
+// pnpmLockfileSupport lists the lockfile versions a range of pnpm versions reads and writes.
+type pnpmLockfileSupport struct {
+	pnpm   versionRange
+	writes int            // major lockfile version written
+	reads  []versionRange // lockfile versions installed from; others are rejected
+}
+
+// lockfileSupport is the compatibility table of pnpm and lockfile versions.
+// pnpm 7.24 can read lockfileVersion 6.0 (written with use-lockfile-v6), pnpm 8
+// converts lockfileVersion 5.x to 6.0 and pnpm 9 dropped lockfileVersion 5.x.
+var lockfileSupport = []pnpmLockfileSupport{
+	{
+		pnpm:   newVersionRange("7.0.0", "7.24.0"),
+		writes: 5, //nolint:mnd // lockfile version
+		reads:  []versionRange{newVersionRange("5.0", "6.0")},
+	},
+	{
+		pnpm:   newVersionRange("7.24.0", "8.0.0"),
+		writes: 5, //nolint:mnd // lockfile version
+		reads:  []versionRange{newVersionRange("5.0", "7.0")},
+	},
+	{
+		pnpm:   newVersionRange("8.0.0", "9.0.0"),
+		writes: 6, //nolint:mnd // lockfile version
+		reads:  []versionRange{newVersionRange("5.0", "7.0")},
+	},
+	{
+		pnpm:   newVersionRange("9.0.0", "10.0.0"),
+		writes: 9, //nolint:mnd // lockfile version
+		reads:  []versionRange{newVersionRange("6.0", "10.0")},
+	},
+	{
+		pnpm:   newVersionRange("10.0.0", "11.0.0"),
+		writes: 9, //nolint:mnd // lockfile version
+		reads:  []versionRange{newVersionRange("6.0", "10.0")},
+	},
+}
+
+// LockfileCompatibility reports how pnpm of pnpmVersion handles a lockfile of
+// lockfileVersion, with a message describing the problem unless it is LockfileNative.
+// For pnpm versions missing from the table, lockfiles with a greater major version
+// than pnpm are rejected.
+func LockfileCompatibility(pnpmVersion common.Version, lockfileVersion common.Version) (LockfileSupport, string) {
+	lockfileStr := fmt.Sprintf("%d.%d", lockfileVersion.Major, lockfileVersion.Minor)
+
+	var support *pnpmLockfileSupport
+	for i := range lockfileSupport {
+		if lockfileSupport[i].pnpm.contains(pnpmVersion) {
+			support = &lockfileSupport[i]
+			break
+		}
+	}
+
+	if support == nil {
+		if lockfileVersion.Major > pnpmVersion.Major {
+			return LockfileRejected, fmt.Sprintf(
+				"lockfileVersion %s in pnpm-lock.yaml requires pnpm >= %d, but pnpm %s is provided",
+				lockfileStr,
+				lockfileVersion.Major,
+				pnpmVersion,
+			)
+		}
+
+		return LockfileUnknown, fmt.Sprintf(
+			"pnpm %s is not in the compatibility table; cannot check that it supports lockfileVersion %s",
+			pnpmVersion,
+			lockfileStr,
+		)
+	}
+
+	readable := false
+	for _, r := range support.reads {
+		readable = readable || r.contains(lockfileVersion)
+	}
+
+	if !readable {
+		return LockfileRejected, fmt.Sprintf(
+			"pnpm %s cannot install from lockfileVersion %s in pnpm-lock.yaml; use %s",
+			pnpmVersion,
+			lockfileStr,
+			lockfileWriters(lockfileVersion.Major),
+		)
+	}
+
+	if support.writes == lockfileVersion.Major {
+		return LockfileNative, ""
+	}
+
+	return LockfileConverted, fmt.Sprintf(
+		"pnpm %s converts lockfileVersion %s in pnpm-lock.yaml to %d.0; the project most likely uses %s",
+		pnpmVersion,
+		lockfileStr,
+		support.writes,
+		lockfileWriters(lockfileVersion.Major),
+	)
+}
+
+// lockfileWriters describes the pnpm versions writing lockfiles of the major version,
+// with their nixpkgs attribute names.
+func lockfileWriters(lockfileMajor int) string {
+	var majors []string
+	for _, s := range lockfileSupport {
+		pnpmMajor := strconv.Itoa(s.pnpm.min.Major)
+		if s.writes == lockfileMajor && !slices.Contains(majors, pnpmMajor) {
+			majors = append(majors, pnpmMajor)
+		}
+	}
+
+	if len(majors) == 0 {
+		return "the pnpm version that created pnpm-lock.yaml"
+	}
+
+	attrs := make([]string, len(majors))
+	for i, major := range majors {
+		attrs[i] = "pnpm_" + major
+	}
+
+	return fmt.Sprintf(
+		"pnpm %s (--pnpm-path to %s from nixpkgs)",
+		strings.Join(majors, " or "),
+		strings.Join(attrs, " or "),
+	)
+}
+
+// lockfileSetting is a setting recorded in the settings section of pnpm-lock.yaml.
+type lockfileSetting struct {
+	name   string // name in pnpm-lock.yaml
+	config string // name of the pnpm config
+	value  func(s *lockfile.Settings) *bool
+	// defaultValue returns the default of the pnpm version.
+	defaultValue func(pnpmVersion common.Version) bool
+}
+
+var lockfileSettings = []lockfileSetting{
+	{
+		name:   "autoInstallPeers",
+		config: "auto-install-peers",
+		value:  func(s *lockfile.Settings) *bool { return s.AutoInstallPeers },
+		// pnpm 8 enabled auto-install-peers by default.
+		defaultValue: func(v common.Version) bool { return v.Major >= 8 }, //nolint:mnd // pnpm version
+	},
+	{
+		name:         "excludeLinksFromLockfile",
+		config:       "exclude-links-from-lockfile",
+		value:        func(s *lockfile.Settings) *bool { return s.ExcludeLinksFromLockfile },
+		defaultValue: func(common.Version) bool { return false },
+	},
+}
+
+// SettingsMismatches describes the settings of pnpm-lock.yaml that differ from the
+// settings pnpm of pnpmVersion uses, given the config in the project .npmrc.
+// pnpm fails a frozen install with ERR_PNPM_LOCKFILE_CONFIG_MISMATCH if they differ.
+func SettingsMismatches(pnpmVersion common.Version, settings *lockfile.Settings, npmrc map[string]string) []string {
+	if settings == nil {
+		return nil
+	}
+
+	var mismatches []string
+	for _, s := range lockfileSettings {
+		recorded := s.value(settings)
+		if recorded == nil {
+			continue
+		}
+
+		current, source := s.defaultValue(pnpmVersion), fmt.Sprintf("pnpm %s defaults to", pnpmVersion)
+		if v, ok := npmrc[s.config]; ok {
+			parsed, err := strconv.ParseBool(v)
+			if err != nil {
+				continue
+			}
+			current, source = parsed, ".npmrc sets"
+		}
+
+		if current != *recorded {
+			mismatches = append(mismatches, fmt.Sprintf(
+				"pnpm-lock.yaml was resolved with %s: %t, but %s %s=%t; set %s=%t in .npmrc or with --pre-install-command",
+				s.name,
+				*recorded,
+				source,
+				s.config,
+				current,
+				s.config,
+				*recorded,
+			))
+		}
+	}
+
+	return mismatches
+}
@@ -0,0 +1,198 @@
+package pnpm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/lockfile"
+)
+
+func Test_LockfileCompatibility(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		pnpm        string
+		lockfile    string
+		want        LockfileSupport
+		wantMessage string
+	}{
+		{
+			name:     "[正常系] pnpm 9 と lockfile 9.0",
+			pnpm:     "9.15.0",
+			lockfile: "9.0",
+			want:     LockfileNative,
+		},
+		{
+			name:     "[正常系] pnpm 10 と lockfile 9.0",
+			pnpm:     "10.2.1",
+			lockfile: "9.0",
+			want:     LockfileNative,
+		},
+		{
+			name:     "[正常系] pnpm 8 と lockfile 6.0",
+			pnpm:     "8.15.9",
+			lockfile: "6.0",
+			want:     LockfileNative,
+		},
+		{
+			name:     "[正常系] pnpm 7 と lockfile 5.4",
+			pnpm:     "7.33.7",
+			lockfile: "5.4",
+			want:     LockfileNative,
+		},
+		{
+			name:     "[正常系] pnpm 10 のプレリリース",
+			pnpm:     "10.0.0-rc.3",
+			lockfile: "9.0",
+			want:     LockfileNative,
+		},
+		{
+			name:        "[正常系] pnpm 9 は lockfile 6.0 を変換する",
+			pnpm:        "9.15.0",
+			lockfile:    "6.0",
+			want:        LockfileConverted,
+			wantMessage: "pnpm 9.15.0 converts lockfileVersion 6.0 in pnpm-lock.yaml to 9.0; the project most likely uses pnpm 8 (--pnpm-path to pnpm_8 from nixpkgs)",
+		},
+		{
+			name:        "[正常系] pnpm 8 は lockfile 5.4 を変換する",
+			pnpm:        "8.0.0",
+			lockfile:    "5.4",
+			want:        LockfileConverted,
+			wantMessage: "pnpm 8.0.0 converts lockfileVersion 5.4 in pnpm-lock.yaml to 6.0; the project most likely uses pnpm 7 (--pnpm-path to pnpm_7 from nixpkgs)",
+		},
+		{
+			name:        "[正常系] 表にない pnpm のバージョン",
+			pnpm:        "11.0.0",
+			lockfile:    "9.0",
+			want:        LockfileUnknown,
+			wantMessage: "pnpm 11.0.0 is not in the compatibility table; cannot check that it supports lockfileVersion 9.0",
+		},
+		{
+			name:        "[異常系] pnpm 9 は lockfile 5.x を読めない",
+			pnpm:        "9.0.0",
+			lockfile:    "5.4",
+			want:        LockfileRejected,
+			wantMessage: "pnpm 9.0.0 cannot install from lockfileVersion 5.4 in pnpm-lock.yaml; use pnpm 7 (--pnpm-path to pnpm_7 from nixpkgs)",
+		},
+		{
+			name:        "[異常系] pnpm 8 は lockfile 9.0 を読めない",
+			pnpm:        "8.15.9",
+			lockfile:    "9.0",
+			want:        LockfileRejected,
+			wantMessage: "pnpm 8.15.9 cannot install from lockfileVersion 9.0 in pnpm-lock.yaml; use pnpm 9 or 10 (--pnpm-path to pnpm_9 or pnpm_10 from nixpkgs)",
+		},
+		{
+			name:     "[正常系] pnpm 7.24 以降は lockfile 6.0 を読める",
+			pnpm:     "7.24.0",
+			lockfile: "6.0",
+			want:     LockfileConverted,
+		},
+		{
+			name:     "[異常系] pnpm 7.24 より前は lockfile 6.0 を読めない",
+			pnpm:     "7.23.0",
+			lockfile: "6.0",
+			want:     LockfileRejected,
+		},
+		{
+			name:        "[異常系] 表にない pnpm より新しい lockfile",
+			pnpm:        "6.35.1",
+			lockfile:    "9.0",
+			want:        LockfileRejected,
+			wantMessage: "lockfileVersion 9.0 in pnpm-lock.yaml requires pnpm >= 9, but pnpm 6.35.1 is provided",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, msg := LockfileCompatibility(common.MustParseVersion(tt.pnpm), common.MustParseVersion(tt.lockfile))
+			if got != tt.want {
+				t.Errorf("LockfileCompatibility() = %d, want %d (%s)", got, tt.want, msg)
+			}
+
+			if tt.wantMessage != "" && msg != tt.wantMessage {
+				t.Errorf("LockfileCompatibility() message = %q, want %q", msg, tt.wantMessage)
+			}
+
+			if got == LockfileNative && msg != "" {
+				t.Errorf("LockfileCompatibility() message = %q, want empty", msg)
+			}
+		})
+	}
+}
+
+func Test_SettingsMismatches(t *testing.T) {
+	t.Parallel()
+
+	yes, no := true, false
+
+	tests := []struct {
+		name     string
+		pnpm     string
+		settings *lockfile.Settings
+		npmrc    map[string]string
+		want     []string
+	}{
+		{
+			name:     "[正常系] settings がない",
+			pnpm:     "9.15.0",
+			settings: nil,
+			want:     nil,
+		},
+		{
+			name:     "[正常系] デフォルトと一致する",
+			pnpm:     "9.15.0",
+			settings: &lockfile.Settings{AutoInstallPeers: &yes, ExcludeLinksFromLockfile: &no},
+			want:     nil,
+		},
+		{
+			name:     "[正常系] .npmrc で設定されている",
+			pnpm:     "9.15.0",
+			settings: &lockfile.Settings{AutoInstallPeers: &no},
+			npmrc:    map[string]string{"auto-install-peers": "false"},
+			want:     nil,
+		},
+		{
+			name:     "[異常系] pnpm のデフォルトと異なる",
+			pnpm:     "9.15.0",
+			settings: &lockfile.Settings{AutoInstallPeers: &no},
+			want: []string{
+				"pnpm-lock.yaml was resolved with autoInstallPeers: false, but pnpm 9.15.0 defaults to auto-install-peers=true; " +
+					"set auto-install-peers=false in .npmrc or with --pre-install-command",
+			},
+		},
+		{
+			name:     "[異常系] pnpm 7 のデフォルトと異なる",
+			pnpm:     "7.33.7",
+			settings: &lockfile.Settings{AutoInstallPeers: &yes},
+			want: []string{
+				"pnpm-lock.yaml was resolved with autoInstallPeers: true, but pnpm 7.33.7 defaults to auto-install-peers=false; " +
+					"set auto-install-peers=true in .npmrc or with --pre-install-command",
+			},
+		},
+		{
+			name:     "[異常系] .npmrc の設定と異なる",
+			pnpm:     "9.15.0",
+			settings: &lockfile.Settings{ExcludeLinksFromLockfile: &no},
+			npmrc:    map[string]string{"exclude-links-from-lockfile": "true"},
+			want: []string{
+				"pnpm-lock.yaml was resolved with excludeLinksFromLockfile: false, but .npmrc sets exclude-links-from-lockfile=true; " +
+					"set exclude-links-from-lockfile=false in .npmrc or with --pre-install-command",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := SettingsMismatches(common.MustParseVersion(tt.pnpm), tt.settings, tt.npmrc)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("SettingsMismatches() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package pnpm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson"
+	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
+)
+
+// Kind is how a pnpm executable is installed.
+type Kind int
+
+const (
+	// KindScript is pnpm run by Node.js, e.g. from nixpkgs or npm, including native
+	// wrappers that are not the @pnpm/exe binary.
+	KindScript Kind = iota
+	// KindStandalone is the @pnpm/exe binary, which bundles Node.js. It is installed
+	// by the standalone installation script into PNPM_HOME, or as the @pnpm/exe package.
+	KindStandalone
+	// KindCorepackShim is a corepack shim, which runs the pnpm version pinned by the
+	// packageManager field and downloads it on first use.
+	KindCorepackShim
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindStandalone:
+		return "@pnpm/exe standalone binary"
+	case KindCorepackShim:
+		return "corepack shim"
+	case KindScript:
+	}
+	return "pnpm script"
+}
+
+// installConfig contains the environment variables locating pnpm installations.
+type installConfig struct {
+	PnpmHome     string `env:"PNPM_HOME"`
+	CorepackHome string `env:"COREPACK_HOME"`
+	CacheHome    string `env:"XDG_CACHE_HOME"`
+	Home         string `env:"HOME"`
+}
+
+// corepackHome returns the directory corepack caches package managers in.
+func (c installConfig) corepackHome() string {
+	switch {
+	case c.CorepackHome != "":
+		return c.CorepackHome
+	case c.CacheHome != "":
+		return filepath.Join(c.CacheHome, "node", "corepack")
+	case c.Home != "":
+		return filepath.Join(c.Home, ".cache", "node", "corepack")
+	}
+	return ""
+}
+
+// headerSize is the number of bytes read from an executable to detect its kind.
+const headerSize = 512
+
+// executableMagics are the magic numbers of native executables (ELF, Mach-O and PE).
+var executableMagics = [][]byte{
+	[]byte("\x7fELF"),
+	{0xfe, 0xed, 0xfa, 0xce},
+	{0xfe, 0xed, 0xfa, 0xcf},
+	{0xce, 0xfa, 0xed, 0xfe},
+	{0xcf, 0xfa, 0xed, 0xfe},
+	[]byte("MZ"),
+}
+
+// detectKind returns how the pnpm executable at realPath is installed. A native
+// executable is only taken for the @pnpm/exe binary if isPnpmExe says so; other native
+// executables, such as wrappers compiled by other package managers, are pnpm scripts
+// that need Node.js.
+func detectKind(fs afero.Fs, realPath, pnpmHome string) Kind {
+	slashed := filepath.ToSlash(realPath)
+	if strings.Contains(slashed, "/corepack/") {
+		return KindCorepackShim
+	}
+	if strings.Contains(slashed, "/@pnpm/exe/") || strings.Contains(slashed, "/@pnpm+exe") {
+		return KindStandalone
+	}
+
+	f, err := fs.Open(realPath)
+	if err != nil {
+		return KindScript
+	}
+	defer f.Close()
+
+	header := make([]byte, headerSize)
+	n, _ := io.ReadFull(f, header)
+	header = header[:n]
+
+	for _, magic := range executableMagics {
+		if !bytes.HasPrefix(header, magic) {
+			continue
+		}
+		if isPnpmExe(fs, realPath, pnpmHome) {
+			return KindStandalone
+		}
+		return KindScript
+	}
+	if bytes.Contains(header, []byte("corepack")) {
+		return KindCorepackShim
+	}
+
+	return KindScript
+}
+
+// pnpmExePackage matches the names of @pnpm/exe and of the platform packages its
+// binary is taken from.
+var pnpmExePackage = regexp.MustCompile(`^@pnpm/(exe|(linux|linuxstatic|macos|win)-(x64|arm64))$`)
+
+// isPnpmExe reports whether the native executable at realPath is the @pnpm/exe
+// binary: it is installed in pnpmHome by the standalone installation script, or the
+// package.json of its package names @pnpm/exe or one of its platform packages.
+func isPnpmExe(fs afero.Fs, realPath, pnpmHome string) bool {
+	if pnpmHome != "" && isWithin(realPath, pnpmHome) {
+		return true
+	}
+
+	for _, dir := range []string{filepath.Dir(realPath), installDir(realPath)} {
+		manifest, err := packagejson.Load(fs, filepath.Join(dir, packagejson.FileName))
+		if err == nil && pnpmExePackage.MatchString(manifest.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scriptCandidates are the paths of the pnpm entry point relative to the package
+// directory of a pnpm executable that is not the script itself, such as the wrapper
+// scripts of nixpkgs.
+var scriptCandidates = []string{
+	filepath.Join("bin", "pnpm.cjs"),
+	filepath.Join("libexec", "pnpm", "bin", "pnpm.cjs"),
+	filepath.Join("lib", "node_modules", "pnpm", "bin", "pnpm.cjs"),
+}
+
+// resolveScript returns the JavaScript entry point of the pnpm script at realPath:
+// realPath itself if it is run by node, or else the first of scriptCandidates in its
+// package directory. It returns "" if there is none.
+func resolveScript(fs afero.Fs, realPath string) string {
+	if _, ok := nodeShebang(fs, realPath); ok {
+		return realPath
+	}
+
+	dir := installDir(realPath)
+	for _, candidate := range scriptCandidates {
+		script := filepath.Join(dir, candidate)
+		if info, err := fs.Stat(script); err == nil && !info.IsDir() {
+			return script
+		}
+	}
+
+	return ""
+}
+
+// nodeShebang reports whether the file at path starts with a shebang line running node.
+// It also returns the path of node if the shebang names it directly, or "" if node is
+// looked up on PATH as in "#!/usr/bin/env node".
+func nodeShebang(fs afero.Fs, path string) (string, bool) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	header := make([]byte, headerSize)
+	n, _ := io.ReadFull(f, header)
+
+	line, ok := bytes.CutPrefix(header[:n], []byte("#!"))
+	if !ok {
+		return "", false
+	}
+
+	line, _, _ = bytes.Cut(line, []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	if filepath.Base(fields[0]) == "env" {
+		for _, arg := range fields[1:] {
+			if !strings.HasPrefix(arg, "-") {
+				return "", filepath.Base(arg) == "node"
+			}
+		}
+		return "", false
+	}
+
+	if filepath.Base(fields[0]) == "node" {
+		return fields[0], true
+	}
+
+	return "", false
+}
+
+// realPath resolves the symlinks of path on the OS filesystem. Other filesystems have
+// no symlinks.
+func realPath(fs afero.Fs, path string) string {
+	if _, ok := fs.(*afero.OsFs); !ok {
+		return path
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+	return resolved
+}
+
+// newPnpm returns the pnpm executable at path, detecting how it is installed.
+func newPnpm(fs afero.Fs, logger logger.Logger, path string) *Pnpm {
+	real := realPath(fs, path)
+
+	var cfg installConfig
+	if err := env.Parse(&cfg); err == nil && cfg.PnpmHome != "" && isWithin(real, cfg.PnpmHome) {
+		logger.Debugf("pnpm at %s is installed in PNPM_HOME %s", path, cfg.PnpmHome)
+	}
+
+	p := &Pnpm{fs: fs, logger: logger, path: path, kind: detectKind(fs, real, cfg.PnpmHome)}
+	if p.kind == KindScript {
+		p.script = resolveScript(fs, real)
+		logger.Debugf("pnpm at %s runs the script %q with node", path, p.script)
+	}
+	logger.Debugf("pnpm at %s is a %s (real path: %s)", path, p.kind, real)
+
+	return p
+}
+
+// isWithin reports whether path is inside dir.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// ResolveShim resolves a corepack shim to the pnpm it runs for the packageManager
+// field pm, which may be nil, if corepack has already cached it. It returns p itself
+// if p is not a corepack shim, and a CorepackShimError if the pnpm is not cached,
+// as corepack would download it on first use.
+func ResolveShim(
+	fs afero.Fs,
+	logger logger.Logger,
+	p *Pnpm,
+	pm *packagejson.PackageManager,
+) (*Pnpm, pnpm_err.PnpmErrorIF) {
+	if p.kind != KindCorepackShim {
+		return p, nil
+	}
+
+	var cfg installConfig
+	if err := env.Parse(&cfg); err != nil {
+		return nil, pnpm_err.NewPnpmError(
+			&pnpm_err.OtherError{},
+			"failed to parse environment variables",
+			err,
+		)
+	}
+
+	return resolveCorepackShim(fs, logger, p, pm, cfg.corepackHome())
+}
+
+func resolveCorepackShim(
+	fs afero.Fs,
+	logger logger.Logger,
+	p *Pnpm,
+	pm *packagejson.PackageManager,
+	home string,
+) (*Pnpm, pnpm_err.PnpmErrorIF) {
+	version, source := corepackVersion(fs, home, pm)
+	if version == "" {
+		return nil, pnpm_err.NewPnpmError(
+			&pnpm_err.CorepackShimError{},
+			fmt.Sprintf(
+				"pnpm at %s is a corepack shim, and neither package.json nor the corepack cache at %s pins a pnpm version",
+				p.path,
+				home,
+			),
+			nil,
+		)
+	}
+	logger.Debugf("corepack shim at %s runs pnpm %s (%s)", p.path, version, source)
+
+	// corepack 0.20 and later cache package managers in v1.
+	for _, dir := range []string{filepath.Join(home, "v1", "pnpm", version), filepath.Join(home, "pnpm", version)} {
+		script := filepath.Join(dir, "bin", "pnpm.cjs")
+		if _, err := fs.Stat(script); err != nil {
+			continue
+		}
+
+		logger.Debugf("resolved corepack shim at %s to pnpm %s at %s", p.path, version, script)
+		return &Pnpm{fs: fs, logger: logger, path: script, kind: KindScript, script: script}, nil
+	}
+
+	return nil, pnpm_err.NewPnpmError(
+		&pnpm_err.CorepackShimError{},
+		fmt.Sprintf(
+			"pnpm at %s is a corepack shim, and pnpm %s is not in the corepack cache at %s",
+			p.path,
+			version,
+			home,
+		),
+		nil,
+	)
+}
+
+// corepackVersion returns the pnpm version a corepack shim runs, which is the version
+// pinned by the packageManager field, or else the last known good version of corepack.
+func corepackVersion(fs afero.Fs, home string, pm *packagejson.PackageManager) (string, string) {
+	if pm != nil && pm.Name == "pnpm" {
+		return pm.Version.String(), "pinned by the packageManager field"
+	}
+
+	data, err := afero.ReadFile(fs, filepath.Join(home, "lastKnownGood.json"))
+	if err != nil {
+		return "", ""
+	}
+
+	var lastKnownGood map[string]string
+	if err := json.Unmarshal(data, &lastKnownGood); err != nil {
+		return "", ""
+	}
+
+	return lastKnownGood["pnpm"], "the last known good version of corepack"
+}
@@ -0,0 +1,266 @@
+package pnpm
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson"
+	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
+)
+
+func Test_detectKind(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		files    map[string]string
+		pnpmHome string
+		want     Kind
+	}{
+		{
+			name:    "[正常系] nixpkgsのラッパースクリプト",
+			path:    "/nix/store/abc-pnpm-9.15.0/bin/pnpm",
+			content: "#!/nix/store/def-bash/bin/bash -e\nexec node /nix/store/abc-pnpm-9.15.0/libexec/pnpm/bin/pnpm.cjs \"$@\"\n",
+			want:    KindScript,
+		},
+		{
+			name:    "[正常系] corepackのディレクトリ",
+			path:    "/usr/lib/node_modules/corepack/shims/pnpm",
+			content: "#!/usr/bin/env node\n",
+			want:    KindCorepackShim,
+		},
+		{
+			name:    "[正常系] corepackを呼び出すスクリプト",
+			path:    "/usr/local/bin/pnpm",
+			content: "#!/usr/bin/env node\nrequire('./lib/corepack.cjs').runMain(['pnpm', ...process.argv.slice(2)]);\n",
+			want:    KindCorepackShim,
+		},
+		{
+			name:     "[正常系] PNPM_HOMEのELFバイナリ",
+			path:     "/home/user/.local/share/pnpm/pnpm",
+			content:  "\x7fELF\x02\x01\x01",
+			pnpmHome: "/home/user/.local/share/pnpm",
+			want:     KindStandalone,
+		},
+		{
+			name:     "[正常系] PNPM_HOMEのMach-Oバイナリ",
+			path:     "/Users/user/Library/pnpm/pnpm",
+			content:  "\xcf\xfa\xed\xfe",
+			pnpmHome: "/Users/user/Library/pnpm",
+			want:     KindStandalone,
+		},
+		{
+			name:    "[正常系] package.jsonが@pnpm/exeのELFバイナリ",
+			path:    "/opt/pnpm-exe/pnpm",
+			content: "\x7fELF\x02\x01\x01",
+			files:   map[string]string{"/opt/pnpm-exe/package.json": `{"name":"@pnpm/exe","version":"9.15.0"}`},
+			want:    KindStandalone,
+		},
+		{
+			name:    "[正常系] package.jsonがプラットフォームパッケージのELFバイナリ",
+			path:    "/opt/pnpm-linux-x64/pnpm",
+			content: "\x7fELF\x02\x01\x01",
+			files:   map[string]string{"/opt/pnpm-linux-x64/package.json": `{"name":"@pnpm/linux-x64","version":"9.15.0"}`},
+			want:    KindStandalone,
+		},
+		{
+			name:    "[正常系] @pnpm/exeのパッケージ",
+			path:    "/usr/lib/node_modules/@pnpm/exe/pnpm",
+			content: "",
+			want:    KindStandalone,
+		},
+		{
+			name:    "[正常系] @pnpm/exeでないネイティブのラッパー",
+			path:    "/usr/local/bin/pnpm",
+			content: "\x7fELF\x02\x01\x01",
+			files:   map[string]string{"/usr/local/package.json": `{"name":"pnpm-wrapper"}`},
+			want:    KindScript,
+		},
+		{
+			name:     "[正常系] PNPM_HOMEの外のネイティブのラッパー",
+			path:     "/usr/bin/pnpm",
+			content:  "MZ\x90\x00",
+			pnpmHome: "/home/user/.local/share/pnpm",
+			want:     KindScript,
+		},
+		{
+			name: "[正常系] 空のファイル",
+			path: "/usr/bin/pnpm",
+			want: KindScript,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, tt.path, []byte(tt.content), 0o755)
+			for path, content := range tt.files {
+				_ = afero.WriteFile(fs, path, []byte(content), 0o644)
+			}
+
+			if got := detectKind(fs, tt.path, tt.pnpmHome); got != tt.want {
+				t.Errorf("detectKind() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_resolveScript(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		files           map[string]string
+		path            string
+		wantScript      string
+		wantInterpreter string
+	}{
+		{
+			name: "[正常系] nixpkgsのshebangを書き換えたスクリプト",
+			files: map[string]string{
+				"/nix/store/abc-pnpm-9.15.0/libexec/pnpm/bin/pnpm.cjs": "#!/nix/store/def-nodejs-22/bin/node\n",
+			},
+			path:            "/nix/store/abc-pnpm-9.15.0/libexec/pnpm/bin/pnpm.cjs",
+			wantScript:      "/nix/store/abc-pnpm-9.15.0/libexec/pnpm/bin/pnpm.cjs",
+			wantInterpreter: "/nix/store/def-nodejs-22/bin/node",
+		},
+		{
+			name: "[正常系] nixpkgsのラッパースクリプト",
+			files: map[string]string{
+				"/nix/store/abc-pnpm-9.15.0/bin/pnpm":                  "#!/nix/store/def-bash/bin/bash -e\nexec node pnpm.cjs\n",
+				"/nix/store/abc-pnpm-9.15.0/libexec/pnpm/bin/pnpm.cjs": "#!/nix/store/def-nodejs-22/bin/node\n",
+			},
+			path:            "/nix/store/abc-pnpm-9.15.0/bin/pnpm",
+			wantScript:      "/nix/store/abc-pnpm-9.15.0/libexec/pnpm/bin/pnpm.cjs",
+			wantInterpreter: "/nix/store/def-nodejs-22/bin/node",
+		},
+		{
+			name: "[正常系] envでnodeを探すスクリプト",
+			files: map[string]string{
+				"/usr/lib/node_modules/pnpm/bin/pnpm.cjs": "#!/usr/bin/env -S node --no-warnings\n",
+			},
+			path:       "/usr/lib/node_modules/pnpm/bin/pnpm.cjs",
+			wantScript: "/usr/lib/node_modules/pnpm/bin/pnpm.cjs",
+		},
+		{
+			name: "[正常系] スクリプトが見つからないラッパー",
+			files: map[string]string{
+				"/opt/pnpm/pnpm": "#!/bin/sh\nexec /opt/pnpm/run \"$@\"\n",
+			},
+			path: "/opt/pnpm/pnpm",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+			for path, content := range tt.files {
+				_ = afero.WriteFile(fs, path, []byte(content), 0o755)
+			}
+
+			got := resolveScript(fs, tt.path)
+			if got != tt.wantScript {
+				t.Errorf("resolveScript() = %q, want %q", got, tt.wantScript)
+			}
+
+			p := &Pnpm{fs: fs, path: tt.path, kind: KindScript, script: got}
+			if interpreter := p.Interpreter(); interpreter != tt.wantInterpreter {
+				t.Errorf("Interpreter() = %q, want %q", interpreter, tt.wantInterpreter)
+			}
+		})
+	}
+}
+
+func Test_resolveCorepackShim(t *testing.T) {
+	t.Parallel()
+
+	const home = "/home/user/.cache/node/corepack"
+	shim := "/usr/lib/node_modules/corepack/shims/pnpm"
+
+	tests := []struct {
+		name           string
+		setupFs        func(fs afero.Fs)
+		packageManager string
+		wantPath       string
+		wantErr        pnpm_err.PnpmErrorIF
+	}{
+		{
+			name: "[正常系] packageManagerのバージョンがキャッシュされている",
+			setupFs: func(fs afero.Fs) {
+				_ = afero.WriteFile(fs, home+"/v1/pnpm/9.15.0/bin/pnpm.cjs", []byte{}, 0o644)
+			},
+			packageManager: "pnpm@9.15.0+sha512.abc",
+			wantPath:       home + "/v1/pnpm/9.15.0/bin/pnpm.cjs",
+		},
+		{
+			name: "[正常系] 古いcorepackのキャッシュ",
+			setupFs: func(fs afero.Fs) {
+				_ = afero.WriteFile(fs, home+"/pnpm/8.15.9/bin/pnpm.cjs", []byte{}, 0o644)
+			},
+			packageManager: "pnpm@8.15.9",
+			wantPath:       home + "/pnpm/8.15.9/bin/pnpm.cjs",
+		},
+		{
+			name: "[正常系] lastKnownGoodのバージョン",
+			setupFs: func(fs afero.Fs) {
+				_ = afero.WriteFile(fs, home+"/lastKnownGood.json", []byte(`{"pnpm":"10.2.1"}`), 0o644)
+				_ = afero.WriteFile(fs, home+"/v1/pnpm/10.2.1/bin/pnpm.cjs", []byte{}, 0o644)
+			},
+			wantPath: home + "/v1/pnpm/10.2.1/bin/pnpm.cjs",
+		},
+		{
+			name: "[異常系] キャッシュされていない",
+			setupFs: func(fs afero.Fs) {
+				_ = afero.WriteFile(fs, home+"/v1/pnpm/9.14.0/bin/pnpm.cjs", []byte{}, 0o644)
+			},
+			packageManager: "pnpm@9.15.0",
+			wantErr:        &pnpm_err.CorepackShimError{},
+		},
+		{
+			name:    "[異常系] バージョンが分からない",
+			setupFs: func(afero.Fs) {},
+			wantErr: &pnpm_err.CorepackShimError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+			tt.setupFs(fs)
+
+			l := logger.New(slog.LevelError, logger.Options{})
+			t.Cleanup(func() { l.Close() })
+
+			var pm *packagejson.PackageManager
+			if tt.packageManager != "" {
+				parsed, err := packagejson.ParsePackageManager(tt.packageManager)
+				if err != nil {
+					t.Fatalf("ParsePackageManager() error: %v", err)
+				}
+				pm = parsed
+			}
+
+			p := &Pnpm{fs: fs, logger: l, path: shim, kind: KindCorepackShim}
+			got, gotErr := resolveCorepackShim(fs, l, p, pm, home)
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("resolveCorepackShim() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+
+			if got != nil && (got.path != tt.wantPath || got.script != tt.wantPath || got.kind != KindScript) {
+				t.Errorf("resolveCorepackShim() = %+v, want a pnpm script at %s run with node", got, tt.wantPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package pnpm
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// allowedEnv are the variables of the host passed to the commands pnpm runs, close
+// to what the fixed-output derivation of the nixpkgs pnpm fetcher sees. Everything
+// else, e.g. npm_config_*, proxies, NODE_OPTIONS and the locale, is cleared unless
+// passed explicitly.
+var allowedEnv = []string{
+	"NIX_NPM_REGISTRY",
+	"NIX_SSL_CERT_FILE",
+	"SSL_CERT_FILE",
+	"SSL_CERT_DIR",
+	"NODE_EXTRA_CA_CERTS",
+	"TMPDIR",
+}
+
+// stdenvTools are the tools of the nixpkgs stdenv. Their directories on PATH of the
+// host are kept on PATH, so that pre-install commands can use them.
+var stdenvTools = []string{
+	"sh", "bash", "cat", "cp", "mv", "rm", "mkdir", "ls",
+	"sed", "grep", "awk", "find", "diff", "patch", "tar", "gzip", "xz",
+}
+
+// secretNameParts mark variables whose values are redacted in logs.
+var secretNameParts = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "AUTH", "CREDENTIAL", "KEY"}
+
+// SetPassEnv passes the variables names of the host to pnpm and the pre-install
+// commands in addition to the allowed ones. PATH is appended to the sanitized PATH.
+func (p *Pnpm) SetPassEnv(names []string) {
+	p.passEnv = names
+}
+
+// pathDirs returns the directories put first on PATH: those of node and pnpm.
+func (p *Pnpm) pathDirs() []string {
+	var dirs []string
+	if p.node != nil {
+		dirs = append(dirs, filepath.Dir(p.node.path))
+	}
+	return append(dirs, filepath.Dir(p.path))
+}
+
+// sanitizeEnv returns the environment of the commands pnpm runs from the host
+// environment host: the allowed variables, PATH with dirs and the directories of
+// the stdenv tools (and node if it is not in dirs), extra, and the passed variables.
+// Later variables take precedence over earlier ones of the same name.
+func sanitizeEnv(fs afero.Fs, host []string, dirs, passEnv, extra []string) []string {
+	vars := make(map[string]string, len(host))
+	for _, kv := range host {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			vars[name] = value
+		}
+	}
+
+	var env []string
+	for _, name := range allowedEnv {
+		if value, ok := vars[name]; ok {
+			env = append(env, name+"="+value)
+		}
+	}
+
+	hostPath := filepath.SplitList(vars["PATH"])
+	tools := stdenvTools
+	if !slices.ContainsFunc(dirs, func(dir string) bool { return isExecutable(fs, filepath.Join(dir, "node")) }) {
+		tools = append([]string{"node"}, tools...)
+	}
+	pathDirs := slices.Clone(dirs)
+	for _, tool := range tools {
+		if dir, ok := lookPath(fs, hostPath, tool); ok {
+			pathDirs = append(pathDirs, dir)
+		}
+	}
+	if slices.Contains(passEnv, "PATH") {
+		pathDirs = append(pathDirs, hostPath...)
+	}
+	env = append(env, "PATH="+strings.Join(uniqueDirs(pathDirs), string(os.PathListSeparator)))
+
+	env = append(env, extra...)
+	for _, name := range passEnv {
+		if value, ok := vars[name]; ok && name != "PATH" {
+			env = append(env, name+"="+value)
+		}
+	}
+
+	return dedupEnv(env)
+}
+
+// lookPath returns the directory in pathDirs containing the executable name.
+func lookPath(fs afero.Fs, pathDirs []string, name string) (string, bool) {
+	for _, dir := range pathDirs {
+		if dir != "" && isExecutable(fs, filepath.Join(dir, name)) {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+func isExecutable(fs afero.Fs, path string) bool {
+	problem, _ := checkExecutable(fs, path)
+	return problem == ""
+}
+
+// uniqueDirs returns dirs without empty and repeated entries.
+func uniqueDirs(dirs []string) []string {
+	result := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if dir != "" && !slices.Contains(result, dir) {
+			result = append(result, dir)
+		}
+	}
+	return result
+}
+
+// dedupEnv returns env with only the last variable of each name, at its position.
+func dedupEnv(env []string) []string {
+	last := make(map[string]int, len(env))
+	for i, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		last[name] = i
+	}
+
+	result := make([]string, 0, len(last))
+	for i, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if last[name] == i {
+			result = append(result, kv)
+		}
+	}
+	return result
+}
+
+// redactEnv returns env for logging, with the values of variables named like secrets
+// and the passwords of URLs, e.g. in proxies and registries, redacted.
+func redactEnv(env []string) []string {
+	result := make([]string, 0, len(env))
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		upper := strings.ToUpper(name)
+		switch {
+		case slices.ContainsFunc(secretNameParts, func(part string) bool { return strings.Contains(upper, part) }):
+			value = "<redacted>"
+		case strings.Contains(value, "://"):
+			if u, err := url.Parse(value); err == nil && u.User != nil {
+				// Tokens are also passed as user names.
+				u.User = url.User("xxxxx")
+				value = u.String()
+			}
+		}
+		result = append(result, name+"="+value)
+	}
+	return result
+}
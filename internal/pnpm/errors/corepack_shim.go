@@ -0,0 +1,41 @@
+package pnpm_err
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type CorepackShimError struct{ baseError }
+
+var _ PnpmErrorIF = (*CorepackShimError)(nil)
+
+func (e *CorepackShimError) Error() string {
+	errMsg := "pnpm is a corepack shim without a cached pnpm"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = fmt.Sprintf("%s\ncaused by: %s", errMsg, e.Cause.Error())
+	}
+	return errMsg
+}
+
+func (e *CorepackShimError) Code() errcode.Code {
+	return errcode.PnpmCorepackShim
+}
+
+func (e *CorepackShimError) Is(target error) bool {
+	_, ok := target.(*CorepackShimError)
+	return ok
+}
+
+func (e *CorepackShimError) As(target any) bool {
+	if t, ok := target.(**CorepackShimError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -1,8 +1,10 @@
 package pnpm_err
 
-import "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
 
-type FailedToExecuteError struct{ common.BaseError }
+type FailedToExecuteError struct{ baseError }
 
 var _ PnpmErrorIF = (*FailedToExecuteError)(nil)
 
@@ -19,6 +21,10 @@ func (e *FailedToExecuteError) Error() string {
 	return errMsg
 }
 
+func (e *FailedToExecuteError) Code() errcode.Code {
+	return errcode.PnpmExecuteFailed
+}
+
 func (e *FailedToExecuteError) Is(target error) bool {
 	_, ok := target.(*FailedToExecuteError)
 	return ok
@@ -1,8 +1,10 @@
 package pnpm_err
 
-import "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
 
-type FailedToParseError struct{ common.BaseError }
+type FailedToParseError struct{ baseError }
 
 var _ PnpmErrorIF = (*FailedToParseError)(nil)
 
@@ -19,6 +21,10 @@ func (e *FailedToParseError) Error() string {
 	return errMsg
 }
 
+func (e *FailedToParseError) Code() errcode.Code {
+	return errcode.PnpmParseFailed
+}
+
 func (e *FailedToParseError) Is(target error) bool {
 	_, ok := target.(*FailedToParseError)
 	return ok
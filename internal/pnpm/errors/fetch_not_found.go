@@ -0,0 +1,48 @@
+package pnpm_err
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type FetchNotFoundError struct{ baseError }
+
+var _ PnpmErrorIF = (*FetchNotFoundError)(nil)
+
+func (e *FetchNotFoundError) Error() string {
+	errMsg := "a package was not found in the registry"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	errMsg = fmt.Sprintf("%s\nhint: %s", errMsg, e.Hint())
+
+	if e.Cause != nil {
+		errMsg = fmt.Sprintf("%s\ncaused by: %s", errMsg, e.Cause.Error())
+	}
+	return errMsg
+}
+
+// Hint returns how the failure can usually be fixed.
+func (e *FetchNotFoundError) Hint() string {
+	return e.Code().Hint()
+}
+
+func (e *FetchNotFoundError) Code() errcode.Code {
+	return errcode.PnpmFetchNotFound
+}
+
+func (e *FetchNotFoundError) Is(target error) bool {
+	_, ok := target.(*FetchNotFoundError)
+	return ok
+}
+
+func (e *FetchNotFoundError) As(target any) bool {
+	if t, ok := target.(**FetchNotFoundError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package pnpm_err
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type LockfileConfigMismatchError struct{ baseError }
+
+var _ PnpmErrorIF = (*LockfileConfigMismatchError)(nil)
+
+func (e *LockfileConfigMismatchError) Error() string {
+	errMsg := "pnpm-lock.yaml was created with different settings"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	errMsg = fmt.Sprintf("%s\nhint: %s", errMsg, e.Hint())
+
+	if e.Cause != nil {
+		errMsg = fmt.Sprintf("%s\ncaused by: %s", errMsg, e.Cause.Error())
+	}
+	return errMsg
+}
+
+// Hint returns how the failure can usually be fixed.
+func (e *LockfileConfigMismatchError) Hint() string {
+	return e.Code().Hint()
+}
+
+func (e *LockfileConfigMismatchError) Code() errcode.Code {
+	return errcode.PnpmLockfileConfigMismatch
+}
+
+func (e *LockfileConfigMismatchError) Is(target error) bool {
+	_, ok := target.(*LockfileConfigMismatchError)
+	return ok
+}
+
+func (e *LockfileConfigMismatchError) As(target any) bool {
+	if t, ok := target.(**LockfileConfigMismatchError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -1,5 +1,7 @@
 package pnpm_err
 
+import "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+
 type PnpmErrorIF interface {
 	error
 	Unwrap() error
@@ -8,6 +10,10 @@ type PnpmErrorIF interface {
 
 	SetMessage(string)
 	SetCause(error)
+
+	// pnpmError marks the errors of this package, so that errors.As and type switches
+	// can tell them apart from the errors of other packages.
+	pnpmError()
 }
 
 func NewPnpmError(e PnpmErrorIF, message string, cause error) PnpmErrorIF {
@@ -15,3 +21,8 @@ func NewPnpmError(e PnpmErrorIF, message string, cause error) PnpmErrorIF {
 	e.SetCause(cause)
 	return e
 }
+
+// baseError is embedded in every error type of this package to implement PnpmErrorIF.
+type baseError struct{ common.BaseError }
+
+func (baseError) pnpmError() {}
@@ -0,0 +1,48 @@
+package pnpm_err
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type NoMatchingVersionError struct{ baseError }
+
+var _ PnpmErrorIF = (*NoMatchingVersionError)(nil)
+
+func (e *NoMatchingVersionError) Error() string {
+	errMsg := "no version in the registry matches the requested range"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	errMsg = fmt.Sprintf("%s\nhint: %s", errMsg, e.Hint())
+
+	if e.Cause != nil {
+		errMsg = fmt.Sprintf("%s\ncaused by: %s", errMsg, e.Cause.Error())
+	}
+	return errMsg
+}
+
+// Hint returns how the failure can usually be fixed.
+func (e *NoMatchingVersionError) Hint() string {
+	return e.Code().Hint()
+}
+
+func (e *NoMatchingVersionError) Code() errcode.Code {
+	return errcode.PnpmNoMatchingVersion
+}
+
+func (e *NoMatchingVersionError) Is(target error) bool {
+	_, ok := target.(*NoMatchingVersionError)
+	return ok
+}
+
+func (e *NoMatchingVersionError) As(target any) bool {
+	if t, ok := target.(**NoMatchingVersionError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package pnpm_err
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type NodeNotFoundError struct{ baseError }
+
+var _ PnpmErrorIF = (*NodeNotFoundError)(nil)
+
+func (e *NodeNotFoundError) Error() string {
+	errMsg := "Node.js not found"
+	if e.Message != "" {
+		errMsg = fmt.Sprintf("%s: %s", errMsg, e.Message)
+	}
+
+	if e.Cause != nil {
+		errMsg = fmt.Sprintf("%s\ncaused by: %s", errMsg, e.Cause.Error())
+	}
+	return errMsg
+}
+
+func (e *NodeNotFoundError) Code() errcode.Code {
+	return errcode.NodeNotFound
+}
+
+func (e *NodeNotFoundError) Is(target error) bool {
+	_, ok := target.(*NodeNotFoundError)
+	return ok
+}
+
+func (e *NodeNotFoundError) As(target any) bool {
+	if t, ok := target.(**NodeNotFoundError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -3,10 +3,10 @@ package pnpm_err
 import (
 	"fmt"
 
-	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
 )
 
-type PnpmNotFoundError struct{ common.BaseError }
+type PnpmNotFoundError struct{ baseError }
 
 var _ PnpmErrorIF = (*PnpmNotFoundError)(nil)
 
@@ -22,6 +22,10 @@ func (e *PnpmNotFoundError) Error() string {
 	return errMsg
 }
 
+func (e *PnpmNotFoundError) Code() errcode.Code {
+	return errcode.PnpmNotFound
+}
+
 func (e *PnpmNotFoundError) Is(target error) bool {
 	_, ok := target.(*PnpmNotFoundError)
 	return ok
@@ -3,10 +3,10 @@ package pnpm_err
 import (
 	"fmt"
 
-	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
 )
 
-type OtherError struct{ common.BaseError }
+type OtherError struct{ baseError }
 
 var _ PnpmErrorIF = (*OtherError)(nil)
 
@@ -23,6 +23,10 @@ func (e *OtherError) Error() string {
 	return errMsg
 }
 
+func (e *OtherError) Code() errcode.Code {
+	return errcode.PnpmOther
+}
+
 func (e *OtherError) Is(target error) bool {
 	_, ok := target.(*OtherError)
 	return ok
@@ -0,0 +1,48 @@
+package pnpm_err
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type OutdatedLockfileError struct{ baseError }
+
+var _ PnpmErrorIF = (*OutdatedLockfileError)(nil)
+
+func (e *OutdatedLockfileError) Error() string {
+	errMsg := "pnpm-lock.yaml is not up to date with package.json"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	errMsg = fmt.Sprintf("%s\nhint: %s", errMsg, e.Hint())
+
+	if e.Cause != nil {
+		errMsg = fmt.Sprintf("%s\ncaused by: %s", errMsg, e.Cause.Error())
+	}
+	return errMsg
+}
+
+// Hint returns how the failure can usually be fixed.
+func (e *OutdatedLockfileError) Hint() string {
+	return e.Code().Hint()
+}
+
+func (e *OutdatedLockfileError) Code() errcode.Code {
+	return errcode.PnpmOutdatedLockfile
+}
+
+func (e *OutdatedLockfileError) Is(target error) bool {
+	_, ok := target.(*OutdatedLockfileError)
+	return ok
+}
+
+func (e *OutdatedLockfileError) As(target any) bool {
+	if t, ok := target.(**OutdatedLockfileError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package pnpm_err
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type PackageManagerMismatchError struct{ baseError }
+
+var _ PnpmErrorIF = (*PackageManagerMismatchError)(nil)
+
+func (e *PackageManagerMismatchError) Error() string {
+	errMsg := "pnpm version does not match the packageManager field of package.json"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = fmt.Sprintf("%s\ncaused by: %s", errMsg, e.Cause.Error())
+	}
+	return errMsg
+}
+
+func (e *PackageManagerMismatchError) Code() errcode.Code {
+	return errcode.PnpmPackageManagerMismatch
+}
+
+func (e *PackageManagerMismatchError) Is(target error) bool {
+	_, ok := target.(*PackageManagerMismatchError)
+	return ok
+}
+
+func (e *PackageManagerMismatchError) As(target any) bool {
+	if t, ok := target.(**PackageManagerMismatchError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package pnpm_err
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type SandboxUnavailableError struct{ baseError }
+
+var _ PnpmErrorIF = (*SandboxUnavailableError)(nil)
+
+func (e *SandboxUnavailableError) Error() string {
+	errMsg := "the sandbox is not available"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = fmt.Sprintf("%s\ncaused by: %s", errMsg, e.Cause.Error())
+	}
+	return errMsg
+}
+
+func (e *SandboxUnavailableError) Code() errcode.Code {
+	return errcode.PnpmSandboxUnavailable
+}
+
+func (e *SandboxUnavailableError) Is(target error) bool {
+	_, ok := target.(*SandboxUnavailableError)
+	return ok
+}
+
+func (e *SandboxUnavailableError) As(target any) bool {
+	if t, ok := target.(**SandboxUnavailableError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package pnpm_err
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type TarballIntegrityError struct{ baseError }
+
+var _ PnpmErrorIF = (*TarballIntegrityError)(nil)
+
+func (e *TarballIntegrityError) Error() string {
+	errMsg := "a package tarball does not match its integrity in pnpm-lock.yaml"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	errMsg = fmt.Sprintf("%s\nhint: %s", errMsg, e.Hint())
+
+	if e.Cause != nil {
+		errMsg = fmt.Sprintf("%s\ncaused by: %s", errMsg, e.Cause.Error())
+	}
+	return errMsg
+}
+
+// Hint returns how the failure can usually be fixed.
+func (e *TarballIntegrityError) Hint() string {
+	return e.Code().Hint()
+}
+
+func (e *TarballIntegrityError) Code() errcode.Code {
+	return errcode.PnpmTarballIntegrity
+}
+
+func (e *TarballIntegrityError) Is(target error) bool {
+	_, ok := target.(*TarballIntegrityError)
+	return ok
+}
+
+func (e *TarballIntegrityError) As(target any) bool {
+	if t, ok := target.(**TarballIntegrityError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package pnpm_err
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type UnsupportedEngineError struct{ baseError }
+
+var _ PnpmErrorIF = (*UnsupportedEngineError)(nil)
+
+func (e *UnsupportedEngineError) Error() string {
+	errMsg := "the engines field of a project does not support the current environment"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	errMsg = fmt.Sprintf("%s\nhint: %s", errMsg, e.Hint())
+
+	if e.Cause != nil {
+		errMsg = fmt.Sprintf("%s\ncaused by: %s", errMsg, e.Cause.Error())
+	}
+	return errMsg
+}
+
+// Hint returns how the failure can usually be fixed.
+func (e *UnsupportedEngineError) Hint() string {
+	return e.Code().Hint()
+}
+
+func (e *UnsupportedEngineError) Code() errcode.Code {
+	return errcode.PnpmUnsupportedEngine
+}
+
+func (e *UnsupportedEngineError) Is(target error) bool {
+	_, ok := target.(*UnsupportedEngineError)
+	return ok
+}
+
+func (e *UnsupportedEngineError) As(target any) bool {
+	if t, ok := target.(**UnsupportedEngineError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
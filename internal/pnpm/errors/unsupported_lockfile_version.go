@@ -0,0 +1,41 @@
+package pnpm_err
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type UnsupportedLockfileVersionError struct{ baseError }
+
+var _ PnpmErrorIF = (*UnsupportedLockfileVersionError)(nil)
+
+func (e *UnsupportedLockfileVersionError) Error() string {
+	errMsg := "pnpm-lock.yaml is not supported by the provided pnpm version"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = fmt.Sprintf("%s\ncaused by: %s", errMsg, e.Cause.Error())
+	}
+	return errMsg
+}
+
+func (e *UnsupportedLockfileVersionError) Code() errcode.Code {
+	return errcode.PnpmUnsupportedLockfile
+}
+
+func (e *UnsupportedLockfileVersionError) Is(target error) bool {
+	_, ok := target.(*UnsupportedLockfileVersionError)
+	return ok
+}
+
+func (e *UnsupportedLockfileVersionError) As(target any) bool {
+	if t, ok := target.(**UnsupportedLockfileVersionError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package pnpm_err
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type UnsupportedNodeError struct{ baseError }
+
+var _ PnpmErrorIF = (*UnsupportedNodeError)(nil)
+
+func (e *UnsupportedNodeError) Error() string {
+	errMsg := "the Node.js version is not supported"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = fmt.Sprintf("%s\ncaused by: %s", errMsg, e.Cause.Error())
+	}
+	return errMsg
+}
+
+func (e *UnsupportedNodeError) Code() errcode.Code {
+	return errcode.NodeUnsupported
+}
+
+func (e *UnsupportedNodeError) Is(target error) bool {
+	_, ok := target.(*UnsupportedNodeError)
+	return ok
+}
+
+func (e *UnsupportedNodeError) As(target any) bool {
+	if t, ok := target.(**UnsupportedNodeError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
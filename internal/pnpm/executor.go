@@ -0,0 +1,40 @@
+package pnpm
+
+import (
+	"os/exec"
+)
+
+// Command is a command run by pnpm.Install, either pnpm or a pre-install command.
+type Command struct {
+	Path     string   // executable, looked up in PATH if it has no separator
+	Args     []string // arguments, without the executable
+	Dir      string   // working directory; inherited if empty
+	Env      []string // environment; inherited if nil
+	Writable []string // directories the command may write to
+	ReadOnly []string // directories the command needs to read, e.g. the pnpm package
+}
+
+// Executor creates the processes of commands.
+type Executor interface {
+	// Command returns the process running c.
+	Command(c Command) *exec.Cmd
+	// Sandboxed reports whether commands are isolated from the host, in which case
+	// pnpm.Install sets TMPDIR to the private /tmp of the sandbox unless a temp dir is given.
+	Sandboxed() bool
+}
+
+// DirectExecutor runs commands directly on the host. It is the default executor.
+type DirectExecutor struct{}
+
+var _ Executor = DirectExecutor{}
+
+func (DirectExecutor) Command(c Command) *exec.Cmd {
+	cmd := exec.Command(c.Path, c.Args...)
+	cmd.Dir = c.Dir
+	cmd.Env = c.Env
+	return cmd
+}
+
+func (DirectExecutor) Sandboxed() bool {
+	return false
+}
@@ -0,0 +1,121 @@
+package pnpm
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
+)
+
+// BwrapExecutor runs commands in a bubblewrap sandbox. The filesystem is mounted
+// read-only except for the writable directories of the command and a private /tmp,
+// and HOME is hidden. The network is shared, as pnpm install downloads packages.
+type BwrapExecutor struct {
+	path string // bwrap executable
+	home string // HOME of the host, hidden in the sandbox
+}
+
+var _ Executor = (*BwrapExecutor)(nil)
+
+// NewBwrapExecutor finds bwrap on PATH and checks that it can create a sandbox,
+// which fails e.g. if unprivileged user namespaces are disabled.
+func NewBwrapExecutor() (*BwrapExecutor, pnpm_err.PnpmErrorIF) {
+	path, err := exec.LookPath("bwrap")
+	if err != nil {
+		return nil, pnpm_err.NewPnpmError(
+			&pnpm_err.SandboxUnavailableError{},
+			"bwrap not found on PATH",
+			err,
+		)
+	}
+
+	e := &BwrapExecutor{path: path, home: os.Getenv("HOME")}
+
+	probe := e.Command(Command{Path: "true"})
+	if output, err := probe.CombinedOutput(); err != nil {
+		return nil, pnpm_err.NewPnpmError(
+			&pnpm_err.SandboxUnavailableError{},
+			"bwrap at "+path+" cannot create a sandbox: "+string(output),
+			err,
+		)
+	}
+
+	return e, nil
+}
+
+func (e *BwrapExecutor) Command(c Command) *exec.Cmd {
+	cmd := exec.Command(e.path, e.args(c)...)
+	cmd.Env = c.Env
+	return cmd
+}
+
+func (e *BwrapExecutor) Sandboxed() bool {
+	return true
+}
+
+// hides reports whether dir is inside HOME or /tmp, which are empty in the sandbox.
+func (e *BwrapExecutor) hides(dir string) bool {
+	for _, hidden := range []string{e.home, "/tmp"} {
+		if hidden != "" && dir != hidden && isWithin(dir, hidden) {
+			return true
+		}
+	}
+	return false
+}
+
+// args returns the arguments of bwrap running c. Later mounts are stacked on earlier
+// ones, so the writable directories are mounted last, even inside HOME or /tmp.
+// Paths are made absolute, as bwrap resolves them in the new root and after --chdir.
+func (e *BwrapExecutor) args(c Command) []string {
+	args := []string{
+		"--die-with-parent",
+		"--unshare-all",
+		"--share-net",
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+	}
+
+	if e.home != "" {
+		args = append(args, "--tmpfs", e.home)
+	}
+
+	// The root is already mounted read-only; only directories hidden by the tmpfs
+	// mounts have to be mounted again.
+	for _, dir := range c.ReadOnly {
+		if dir = absPath(dir); e.hides(dir) {
+			args = append(args, "--ro-bind-try", dir, dir)
+		}
+	}
+
+	for _, dir := range c.Writable {
+		dir = absPath(dir)
+		args = append(args, "--bind", dir, dir)
+	}
+
+	if c.Dir != "" {
+		args = append(args, "--chdir", absPath(c.Dir))
+	}
+
+	// Executables without a separator are looked up in PATH.
+	path := c.Path
+	if strings.ContainsRune(path, filepath.Separator) {
+		path = absPath(path)
+	}
+
+	args = append(args, "--", path)
+	return append(args, c.Args...)
+}
+
+// absPath returns path made absolute against the working directory, or path itself
+// if the working directory is unknown.
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	return abs
+}
@@ -0,0 +1,86 @@
+package pnpm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_BwrapExecutor_args(t *testing.T) {
+	t.Parallel()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd() error: %v", err)
+	}
+
+	base := []string{
+		"--die-with-parent",
+		"--unshare-all",
+		"--share-net",
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+	}
+
+	tests := []struct {
+		name    string
+		home    string
+		command Command
+		want    []string
+	}{
+		{
+			name: "[正常系] HOMEを隠し、隠れたインストール先と書き込み可能なディレクトリをbindする",
+			home: "/home/user",
+			command: Command{
+				Path:     "/usr/bin/pnpm",
+				Args:     []string{"install", "--frozen-lockfile"},
+				Dir:      "/src",
+				Writable: []string{"/src", "/tmp/store"},
+				ReadOnly: []string{"/home/user/.local/share/pnpm", "/usr", "/tmp/fp", "/tmp"},
+			},
+			want: append(append([]string{}, base...),
+				"--tmpfs", "/home/user",
+				"--ro-bind-try", "/home/user/.local/share/pnpm", "/home/user/.local/share/pnpm",
+				"--ro-bind-try", "/tmp/fp", "/tmp/fp",
+				"--bind", "/src", "/src",
+				"--bind", "/tmp/store", "/tmp/store",
+				"--chdir", "/src",
+				"--", "/usr/bin/pnpm", "install", "--frozen-lockfile",
+			),
+		},
+		{
+			name: "[正常系] 相対パスを絶対パスにする",
+			command: Command{
+				Path:     "./node_modules/.bin/pnpm",
+				Args:     []string{"install"},
+				Dir:      ".",
+				Writable: []string{"."},
+			},
+			want: append(append([]string{}, base...),
+				"--bind", wd, wd,
+				"--chdir", wd,
+				"--", filepath.Join(wd, "node_modules/.bin/pnpm"), "install",
+			),
+		},
+		{
+			name:    "[正常系] HOMEと作業ディレクトリがない",
+			command: Command{Path: "true"},
+			want:    append(append([]string{}, base...), "--", "true"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := &BwrapExecutor{path: "/usr/bin/bwrap", home: tt.home}
+			if diff := cmp.Diff(tt.want, e.args(tt.command)); diff != "" {
+				t.Errorf("args() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
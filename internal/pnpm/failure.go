@@ -0,0 +1,90 @@
+package pnpm
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+
+	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
+)
+
+// maxFailureOutput is the number of trailing output bytes of a pnpm command kept
+// to classify its failure. pnpm reports the error that made it exit last.
+const maxFailureOutput = 64 * 1024
+
+var (
+	errorCodePattern = regexp.MustCompile(`ERR_PNPM_[A-Z0-9_]+`)
+	ansiPattern      = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+)
+
+// knownErrorCodes maps pnpm error codes to the error types reporting them.
+var knownErrorCodes = map[string]func() pnpm_err.PnpmErrorIF{
+	"ERR_PNPM_OUTDATED_LOCKFILE":        func() pnpm_err.PnpmErrorIF { return &pnpm_err.OutdatedLockfileError{} },
+	"ERR_PNPM_LOCKFILE_CONFIG_MISMATCH": func() pnpm_err.PnpmErrorIF { return &pnpm_err.LockfileConfigMismatchError{} },
+	"ERR_PNPM_FETCH_404":                func() pnpm_err.PnpmErrorIF { return &pnpm_err.FetchNotFoundError{} },
+	"ERR_PNPM_TARBALL_INTEGRITY":        func() pnpm_err.PnpmErrorIF { return &pnpm_err.TarballIntegrityError{} },
+	"ERR_PNPM_NO_MATCHING_VERSION":      func() pnpm_err.PnpmErrorIF { return &pnpm_err.NoMatchingVersionError{} },
+	"ERR_PNPM_UNSUPPORTED_ENGINE":       func() pnpm_err.PnpmErrorIF { return &pnpm_err.UnsupportedEngineError{} },
+}
+
+// tailBuffer keeps the last limit bytes written to it.
+type tailBuffer struct {
+	buf   []byte
+	limit int
+}
+
+func newTailBuffer(limit int) *tailBuffer {
+	return &tailBuffer{limit: limit}
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.limit {
+		b.buf = b.buf[len(b.buf)-b.limit:]
+	}
+
+	return len(p), nil
+}
+
+func (b *tailBuffer) Bytes() []byte {
+	return b.buf
+}
+
+// classifyFailure maps the output of a failed pnpm command to a typed error.
+// The last ERR_PNPM_* code in the output selects the error type and the line
+// reporting it becomes the message. Unknown or missing codes result in a
+// FailedToExecuteError with the given message.
+func classifyFailure(output []byte, message string, cause error) pnpm_err.PnpmErrorIF {
+	code, line := lastErrorCode(output)
+	if code == "" {
+		return pnpm_err.NewPnpmError(&pnpm_err.FailedToExecuteError{}, message, cause)
+	}
+
+	detail := message + ": " + line
+
+	newErr, ok := knownErrorCodes[code]
+	if !ok {
+		return pnpm_err.NewPnpmError(&pnpm_err.FailedToExecuteError{}, detail, cause)
+	}
+
+	return pnpm_err.NewPnpmError(newErr(), detail, cause)
+}
+
+// lastErrorCode returns the last ERR_PNPM_* code in output and the text of its line.
+func lastErrorCode(output []byte) (string, string) {
+	var code, line string
+
+	scanner := bufio.NewScanner(bytes.NewReader(ansiPattern.ReplaceAll(output, nil)))
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxFailureOutput)
+
+	for scanner.Scan() {
+		text := scanner.Text()
+		if c := errorCodePattern.FindString(text); c != "" {
+			code = c
+			line = strings.Join(strings.Fields(text), " ")
+		}
+	}
+
+	return code, line
+}
@@ -0,0 +1,105 @@
+package pnpm
+
+import (
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
+)
+
+func Test_classifyFailure(t *testing.T) {
+	t.Parallel()
+
+	cause := &exec.ExitError{}
+
+	tests := []struct {
+		name        string
+		output      string
+		wantErr     pnpm_err.PnpmErrorIF
+		wantMessage string
+	}{
+		{
+			name: "[正常系] ERR_PNPM_OUTDATED_LOCKFILE",
+			output: "Lockfile is up to date, resolution step is skipped\n" +
+				" ERR_PNPM_OUTDATED_LOCKFILE  Cannot install with \"frozen-lockfile\" because pnpm-lock.yaml is not up to date with package.json\n" +
+				"\nNote that in CI environments this setting is true by default.\n",
+			wantErr: &pnpm_err.OutdatedLockfileError{},
+			wantMessage: "failed to execute pnpm install: ERR_PNPM_OUTDATED_LOCKFILE Cannot install with " +
+				"\"frozen-lockfile\" because pnpm-lock.yaml is not up to date with package.json",
+		},
+		{
+			name:        "[正常系] 色付きのERR_PNPM_LOCKFILE_CONFIG_MISMATCH",
+			output:      "\x1b[31m\x1b[1m ERR_PNPM_LOCKFILE_CONFIG_MISMATCH \x1b[22m\x1b[39m Cannot proceed\n",
+			wantErr:     &pnpm_err.LockfileConfigMismatchError{},
+			wantMessage: "failed to execute pnpm install: ERR_PNPM_LOCKFILE_CONFIG_MISMATCH Cannot proceed",
+		},
+		{
+			name:    "[正常系] ERR_PNPM_FETCH_404",
+			output:  " ERR_PNPM_FETCH_404  GET https://registry.npmjs.org/missing: Not Found - 404\n",
+			wantErr: &pnpm_err.FetchNotFoundError{},
+		},
+		{
+			name:    "[正常系] ndjsonレポーターのERR_PNPM_TARBALL_INTEGRITY",
+			output:  `{"name":"pnpm","err":{"code":"ERR_PNPM_TARBALL_INTEGRITY","message":"Got unexpected checksum"}}` + "\n",
+			wantErr: &pnpm_err.TarballIntegrityError{},
+		},
+		{
+			name:    "[正常系] ERR_PNPM_NO_MATCHING_VERSION",
+			output:  " ERR_PNPM_NO_MATCHING_VERSION  No matching version found for foo@^9.0.0\n",
+			wantErr: &pnpm_err.NoMatchingVersionError{},
+		},
+		{
+			name: "[正常系] 最後のコードが使われる",
+			output: " WARN  ERR_PNPM_FETCH_404 retrying\n" +
+				" ERR_PNPM_UNSUPPORTED_ENGINE  Unsupported environment (bad pnpm and/or Node.js version)\n",
+			wantErr: &pnpm_err.UnsupportedEngineError{},
+		},
+		{
+			name:        "[異常系] 未知のコード",
+			output:      " ERR_PNPM_SOMETHING_NEW  unexpected\n",
+			wantErr:     &pnpm_err.FailedToExecuteError{},
+			wantMessage: "failed to execute pnpm install: ERR_PNPM_SOMETHING_NEW unexpected",
+		},
+		{
+			name:        "[異常系] コードがない",
+			output:      "Segmentation fault\n",
+			wantErr:     &pnpm_err.FailedToExecuteError{},
+			wantMessage: "failed to execute pnpm install",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := classifyFailure([]byte(tt.output), "failed to execute pnpm install", cause)
+
+			if reflect.TypeOf(got) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("classifyFailure() = %T, want %T", got, tt.wantErr)
+			}
+
+			if !errors.Is(got, cause) {
+				t.Errorf("classifyFailure() does not wrap the cause")
+			}
+
+			if tt.wantMessage != "" && !strings.HasPrefix(got.Error(), tt.wantMessage+"\n") {
+				t.Errorf("classifyFailure().Error() = %q, want prefix %q", got.Error(), tt.wantMessage)
+			}
+		})
+	}
+}
+
+func Test_tailBuffer(t *testing.T) {
+	t.Parallel()
+
+	b := newTailBuffer(4)
+	b.Write([]byte("abc"))
+	b.Write([]byte("def"))
+
+	if got := string(b.Bytes()); got != "cdef" {
+		t.Errorf("Bytes() = %q, want %q", got, "cdef")
+	}
+}
@@ -3,11 +3,16 @@ package pnpm
 import (
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"math/rand/v2"
 	"os/exec"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/afero"
 
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
 	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
 )
 
@@ -19,15 +24,23 @@ type InstallOptions struct {
 	ExtraFlags         []string // additional flags passed to pnpm install
 	PreInstallCommands []string // shell commands to run before pnpm install (after config)
 	WorkingDir         string   // directory containing pnpm-lock.yaml
+	ShuffleConfig      bool     // apply pnpm config settings in random order
+	TempDir            string   // TMPDIR for pnpm and pre-install commands (inherited if empty)
+}
+
+// configSetting is a single pnpm config key/value pair.
+type configSetting struct {
+	key   string
+	value string
 }
 
 // Install runs pnpm install with the specified options.
 // It configures pnpm settings and runs install with --force, --ignore-scripts, --frozen-lockfile.
+// Failures reported with a known ERR_PNPM_* code are returned as the matching
+// error type (e.g. *pnpm_err.OutdatedLockfileError) with a remediation hint.
 //
 //nolint:funlen,cyclop // sequential steps (configure → pre-install commands → install) kept together for readability
 func (p *Pnpm) Install(fs afero.Fs, opts InstallOptions) pnpm_err.PnpmErrorIF {
-	cmdLogger := p.logger.CommandLogger(slog.LevelInfo, "pnpm install")
-
 	// Disable manage-package-manager-versions first, from a temporary directory.
 	// If package.json contains a "packageManager" field, pnpm checks it on every command.
 	// Running this config set in the source directory would fail because pnpm tries to
@@ -43,32 +56,69 @@ func (p *Pnpm) Install(fs afero.Fs, opts InstallOptions) pnpm_err.PnpmErrorIF {
 	}
 	defer func() { _ = fs.RemoveAll(tmpDir) }()
 
-	if err := p.configSet("manage-package-manager-versions", "false", tmpDir); err != nil {
+	// pnpm keeps its global config, e.g. store-dir, in HOME, which has to persist
+	// between the commands below without touching the HOME of the user.
+	homeDir, homeErr := afero.TempDir(fs, "", "pnpm-home-")
+	if homeErr != nil {
+		return pnpm_err.NewPnpmError(
+			&pnpm_err.FailedToExecuteError{},
+			"failed to create temp HOME directory for pnpm",
+			homeErr,
+		)
+	}
+	defer func() { _ = fs.RemoveAll(homeDir) }()
+
+	env := p.commandEnv(installVars(opts, homeDir, p.sandboxed())...)
+	p.logger.Debugf("environment of pnpm: %s", strings.Join(redactEnv(env), " "))
+
+	writable := []string{opts.WorkingDir, opts.StorePath, tmpDir, homeDir}
+	if opts.TempDir != "" {
+		writable = append(writable, opts.TempDir)
+	}
+
+	// Every command below reports its output and failure to cmdLogger, so that the
+	// full output of a failed command is shown.
+	cmdLogger := p.logger.CommandLogger(slog.LevelInfo, "pnpm install")
+
+	if err := p.configSet(cmdLogger, "manage-package-manager-versions", "false", tmpDir, env, writable); err != nil {
 		return err
 	}
 
 	// Configure remaining pnpm settings in the source directory
-	configSettings := map[string]string{
-		"store-dir":          opts.StorePath,
-		"side-effects-cache": "false",
-		"update-notifier":    "false",
+	configSettings := []configSetting{
+		{key: "store-dir", value: opts.StorePath},
+		{key: "side-effects-cache", value: "false"},
+		{key: "update-notifier", value: "false"},
 	}
 
-	for key, value := range configSettings {
-		if err := p.configSet(key, value, opts.WorkingDir); err != nil {
+	if opts.ShuffleConfig {
+		rand.Shuffle(len(configSettings), func(i, j int) {
+			configSettings[i], configSettings[j] = configSettings[j], configSettings[i]
+		})
+	}
+
+	for _, setting := range configSettings {
+		if err := p.configSet(cmdLogger, setting.key, setting.value, opts.WorkingDir, env, writable); err != nil {
 			return err
 		}
 	}
 
 	// Run pre-install commands (equivalent to nixpkgs' prePnpmInstall)
 	for _, command := range opts.PreInstallCommands {
-		cmd := exec.Command("sh", "-c", command)
-		cmd.Dir = opts.WorkingDir
+		cmd := p.run(Command{
+			Path:     "sh",
+			Args:     []string{"-c", command},
+			Dir:      opts.WorkingDir,
+			Env:      env,
+			Writable: writable,
+			ReadOnly: p.installDirs(),
+		})
 		cmd.Stdout = cmdLogger
 		cmd.Stderr = cmdLogger
 
 		err := cmd.Run()
 		if err != nil {
+			cmdLogger.Fail(exitCode(err))
 			return pnpm_err.NewPnpmError(
 				&pnpm_err.FailedToExecuteError{},
 				"failed to execute pre-install command: "+command,
@@ -83,6 +133,7 @@ func (p *Pnpm) Install(fs afero.Fs, opts InstallOptions) pnpm_err.PnpmErrorIF {
 		"--force",
 		"--ignore-scripts",
 		"--frozen-lockfile",
+		ndjsonReporterFlag,
 	}
 
 	// Add registry if specified
@@ -98,25 +149,26 @@ func (p *Pnpm) Install(fs afero.Fs, opts InstallOptions) pnpm_err.PnpmErrorIF {
 	// Add extra flags
 	args = append(args, opts.ExtraFlags...)
 
-	// Run pnpm install
-	cmd := exec.Command(p.path, args...)
-	cmd.Dir = opts.WorkingDir
-	cmd.Stdout = cmdLogger
-	cmd.Stderr = cmdLogger
-
-	if err := cmd.Run(); err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			cmdLogger.Fail(exitErr.ExitCode())
-		} else {
-			cmdLogger.Fail(-1)
-		}
+	// Run pnpm install with the ndjson reporter for progress updates, keeping the end
+	// of its readable output to classify failures
+	output := newTailBuffer(maxFailureOutput)
+	reporter := newNDJSONReporter(cmdLogger, io.MultiWriter(cmdLogger, output))
 
-		return pnpm_err.NewPnpmError(
-			&pnpm_err.FailedToExecuteError{},
-			"failed to execute pnpm install",
-			err,
-		)
+	c := p.command(args...)
+	c.Dir = opts.WorkingDir
+	c.Env = env
+	c.Writable = writable
+
+	cmd := p.run(c)
+	cmd.Stdout = reporter
+	cmd.Stderr = reporter
+
+	err := cmd.Run()
+	reporter.Flush()
+
+	if err != nil {
+		cmdLogger.Fail(exitCode(err))
+		return classifyFailure(output.Bytes(), "failed to execute pnpm install", err)
 	}
 
 	cmdLogger.Done()
@@ -124,17 +176,59 @@ func (p *Pnpm) Install(fs afero.Fs, opts InstallOptions) pnpm_err.PnpmErrorIF {
 	return nil
 }
 
-// configSet runs pnpm config set <key> <value>.
-func (p *Pnpm) configSet(key, value, workingDir string) pnpm_err.PnpmErrorIF {
-	cmd := exec.Command(p.path, "config", "set", key, value)
-	cmd.Dir = workingDir
+// installVars returns the variables Install adds to the environment: HOME and the
+// XDG base directories in homeDir, the registry, and TMPDIR, which is the private
+// /tmp of a sandbox unless opts.TempDir is set.
+func installVars(opts InstallOptions, homeDir string, sandboxed bool) []string {
+	vars := []string{
+		"HOME=" + homeDir,
+		"XDG_CONFIG_HOME=" + filepath.Join(homeDir, ".config"),
+		"XDG_CACHE_HOME=" + filepath.Join(homeDir, ".cache"),
+		"XDG_DATA_HOME=" + filepath.Join(homeDir, ".local", "share"),
+		"XDG_STATE_HOME=" + filepath.Join(homeDir, ".local", "state"),
+	}
 
-	if err := cmd.Run(); err != nil {
-		return pnpm_err.NewPnpmError(
-			&pnpm_err.FailedToExecuteError{},
-			fmt.Sprintf("failed to set pnpm config %s=%s", key, value),
-			err,
-		)
+	// Also used by pnpm commands of the pre-install commands.
+	if opts.Registry != "" {
+		vars = append(vars, "npm_config_registry="+opts.Registry)
+	}
+
+	switch {
+	case opts.TempDir != "":
+		vars = append(vars, "TMPDIR="+opts.TempDir)
+	case sandboxed:
+		vars = append(vars, "TMPDIR=/tmp")
+	}
+
+	return vars
+}
+
+// exitCode returns the exit code of a command that failed with err, or -1 if it did
+// not run or was killed.
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// configSet runs pnpm config set <key> <value>. On failure, its output is written to
+// cmdLogger, which is failed.
+func (p *Pnpm) configSet(
+	cmdLogger logger.CommandLogger,
+	key, value, workingDir string,
+	env, writable []string,
+) pnpm_err.PnpmErrorIF {
+	c := p.command("config", "set", key, value)
+	c.Dir = workingDir
+	c.Env = env
+	c.Writable = writable
+
+	if output, err := p.run(c).CombinedOutput(); err != nil {
+		_, _ = cmdLogger.Write(output)
+		cmdLogger.Fail(exitCode(err))
+		return classifyFailure(output, fmt.Sprintf("failed to set pnpm config %s=%s", key, value), err)
 	}
 
 	return nil
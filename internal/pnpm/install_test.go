@@ -0,0 +1,54 @@
+package pnpm
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_installVars(t *testing.T) {
+	t.Parallel()
+
+	home := []string{
+		"HOME=/tmp/home",
+		"XDG_CONFIG_HOME=/tmp/home/.config",
+		"XDG_CACHE_HOME=/tmp/home/.cache",
+		"XDG_DATA_HOME=/tmp/home/.local/share",
+		"XDG_STATE_HOME=/tmp/home/.local/state",
+	}
+
+	tests := []struct {
+		name      string
+		opts      InstallOptions
+		sandboxed bool
+		want      []string
+	}{
+		{
+			name: "[正常系] HOMEとXDGディレクトリを一時HOMEにする",
+			want: home,
+		},
+		{
+			name: "[正常系] レジストリとTMPDIRが指定されている",
+			opts: InstallOptions{Registry: "https://registry.example.com/", TempDir: "/tmp/run-1"},
+			want: append(append([]string{}, home...),
+				"npm_config_registry=https://registry.example.com/",
+				"TMPDIR=/tmp/run-1",
+			),
+		},
+		{
+			name:      "[正常系] サンドボックスではTMPDIRを/tmpにする",
+			sandboxed: true,
+			want:      append(append([]string{}, home...), "TMPDIR=/tmp"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.want, installVars(tt.opts, "/tmp/home", tt.sandboxed)); diff != "" {
+				t.Errorf("installVars() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
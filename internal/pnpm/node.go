@@ -0,0 +1,185 @@
+package pnpm
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/path"
+	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
+)
+
+// Node is a Node.js executable to run pnpm with.
+type Node struct {
+	path    string
+	version common.Version
+}
+
+// nodeRequirements are the engines.node ranges of the package.json of pnpm versions,
+// for pnpm whose package.json cannot be found.
+var nodeRequirements = []struct {
+	pnpm versionRange
+	node common.Range
+}{
+	{pnpm: newVersionRange("7.0.0", "8.0.0"), node: common.MustParseRange(">=14.6")},
+	{pnpm: newVersionRange("8.0.0", "9.0.0"), node: common.MustParseRange(">=16.14")},
+	{pnpm: newVersionRange("9.0.0", "10.0.0"), node: common.MustParseRange(">=18.12")},
+	{pnpm: newVersionRange("10.0.0", "11.0.0"), node: common.MustParseRange(">=18.12")},
+}
+
+// NewNode returns the Node.js executable at path with its version.
+func NewNode(fs afero.Fs, logger logger.Logger, path string) (*Node, pnpm_err.PnpmErrorIF) {
+	if problem, cause := checkExecutable(fs, path); problem != "" {
+		return nil, pnpm_err.NewPnpmError(&pnpm_err.NodeNotFoundError{}, "node "+problem, cause)
+	}
+
+	v, err := nodeVersion(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	logger.Debugf("found Node.js %s at path: %s", v, path)
+
+	return &Node{path: path, version: v}, nil
+}
+
+// NodeWithPathEnvVar returns the first Node.js executable in the PATH environment
+// variable. If none is found, it returns a NodeNotFoundError.
+func NodeWithPathEnvVar(fs afero.Fs, logger logger.Logger) (*Node, pnpm_err.PnpmErrorIF) {
+	var cfg config
+	if err := env.Parse(&cfg); err != nil {
+		return nil, pnpm_err.NewPnpmError(
+			&pnpm_err.OtherError{},
+			"failed to parse environment variables",
+			err,
+		)
+	}
+
+	for _, p := range cfg.Paths {
+		if !path.IsPath(p) {
+			continue
+		}
+
+		nodePath := p + string(os.PathSeparator) + "node"
+		if problem, _ := checkExecutable(fs, nodePath); problem != "" {
+			continue
+		}
+
+		return NewNode(fs, logger, nodePath)
+	}
+
+	logger.Debugf("node executable not found in any of the paths in PATH environment variable")
+	return nil, pnpm_err.NewPnpmError(&pnpm_err.NodeNotFoundError{}, "", nil)
+}
+
+// nodeVersion runs node --version in the sanitized environment pnpm runs in, as e.g.
+// NODE_OPTIONS can make node fail.
+func nodeVersion(fs afero.Fs, nodePath string) (common.Version, pnpm_err.PnpmErrorIF) {
+	cmd := exec.Command(nodePath, "--version")
+	cmd.Env = sanitizeEnv(fs, os.Environ(), []string{filepath.Dir(nodePath)}, nil, nil)
+
+	o, err := cmd.Output()
+	if err != nil {
+		return common.Version{}, pnpm_err.NewPnpmError(
+			&pnpm_err.NodeNotFoundError{},
+			"failed to execute node to get version: "+nodePath,
+			err,
+		)
+	}
+
+	v, parseErr := common.ParseVersion(string(o))
+	if parseErr != nil {
+		return common.Version{}, pnpm_err.NewPnpmError(
+			&pnpm_err.FailedToParseError{},
+			"invalid node version format: "+strings.TrimSpace(string(o)),
+			parseErr,
+		)
+	}
+
+	return v, nil
+}
+
+func (n *Node) Path() string {
+	return n.path
+}
+
+func (n *Node) Version() common.Version {
+	return n.version
+}
+
+// NodeRequirement returns the engines.node range of pnpm, read from the package.json
+// of its script. For pnpm without one, such as native wrappers, it falls back to the
+// range of its version in nodeRequirements. It returns false if neither is known.
+func (p *Pnpm) NodeRequirement() (common.Range, bool, pnpm_err.PnpmErrorIF) {
+	if required, ok := scriptNodeRequirement(p.fs, p.logger, p.script); ok {
+		return required, true, nil
+	}
+
+	pnpmVersion, err := p.SemVer()
+	if err != nil {
+		return common.Range{}, false, err
+	}
+	required, ok := versionNodeRequirement(pnpmVersion)
+	return required, ok, nil
+}
+
+// scriptNodeRequirement returns the engines.node range of the package.json of the pnpm
+// package that script belongs to. It returns false if script is empty or the
+// package.json has no valid engines.node.
+func scriptNodeRequirement(fs afero.Fs, logger logger.Logger, script string) (common.Range, bool) {
+	if script == "" {
+		return common.Range{}, false
+	}
+
+	manifestPath := filepath.Join(installDir(script), packagejson.FileName)
+	manifest, err := packagejson.Load(fs, manifestPath)
+	if err != nil || manifest.Engines["node"] == "" {
+		logger.Debugf("no engines.node in %s, falling back to the known ranges of pnpm versions", manifestPath)
+		return common.Range{}, false
+	}
+
+	required, parseErr := common.ParseRange(manifest.Engines["node"])
+	if parseErr != nil {
+		logger.Debugf("skipping engines.node of %s: %v", manifestPath, parseErr)
+		return common.Range{}, false
+	}
+	logger.Debugf("pnpm requires Node.js %s by engines.node of %s", required, manifestPath)
+
+	return required, true
+}
+
+// versionNodeRequirement returns the engines.node range of pnpm of pnpmVersion. It
+// returns false for pnpm versions missing from the table.
+func versionNodeRequirement(pnpmVersion common.Version) (common.Range, bool) {
+	for _, r := range nodeRequirements {
+		if r.pnpm.contains(pnpmVersion) {
+			return r.node, true
+		}
+	}
+	return common.Range{}, false
+}
+
+// CheckNode checks that p can run on n.
+// It returns an UnsupportedNodeError naming the required range if it cannot.
+func CheckNode(n *Node, p *Pnpm) pnpm_err.PnpmErrorIF {
+	required, ok, err := p.NodeRequirement()
+	if err != nil {
+		return err
+	}
+	if !ok || required.Contains(n.version) {
+		return nil
+	}
+
+	return pnpm_err.NewPnpmError(
+		&pnpm_err.UnsupportedNodeError{},
+		"pnpm at "+p.path+" requires Node.js "+required.String()+
+			", but Node.js "+n.version.String()+" at "+n.path+" is provided",
+		nil,
+	)
+}
@@ -0,0 +1,223 @@
+package pnpm
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
+)
+
+func Test_NewNode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		setupFs func() afero.Fs
+		path    string
+		wantErr pnpm_err.PnpmErrorIF
+	}{
+		{
+			name:    "[異常系] nodeの実行ファイルが存在しない",
+			setupFs: afero.NewMemMapFs,
+			path:    "/invalid/bin/node",
+			wantErr: &pnpm_err.NodeNotFoundError{},
+		},
+		{
+			name: "[異常系] 実行可能ではない",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				afero.WriteFile(fs, "/usr/bin/node", []byte{}, 0644)
+				return fs
+			},
+			path:    "/usr/bin/node",
+			wantErr: &pnpm_err.NodeNotFoundError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			l := logger.New(slog.LevelError, logger.Options{})
+			t.Cleanup(func() { l.Close() })
+
+			_, gotErr := NewNode(tt.setupFs(), l, tt.path)
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Errorf("NewNode() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_CheckNode(t *testing.T) {
+	t.Parallel()
+
+	const script = "/nix/store/xxx-pnpm-9.15.0/libexec/pnpm/bin/pnpm.cjs"
+
+	tests := []struct {
+		name     string
+		manifest string
+		node     string
+		wantErr  pnpm_err.PnpmErrorIF
+	}{
+		{
+			name:     "[正常系] package.json の engines.node を満たす",
+			manifest: `{"name":"pnpm","version":"9.15.0","engines":{"node":">=18.12"}}`,
+			node:     "20.11.1",
+		},
+		{
+			name:     "[正常系] 表より新しい pnpm も package.json で判定する",
+			manifest: `{"name":"pnpm","version":"11.0.0","engines":{"node":">=22.13"}}`,
+			node:     "22.13.0",
+		},
+		{
+			name:     "[異常系] package.json の engines.node を満たさない",
+			manifest: `{"name":"pnpm","version":"11.0.0","engines":{"node":">=22.13"}}`,
+			node:     "20.11.1",
+			wantErr:  &pnpm_err.UnsupportedNodeError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, script, []byte{}, 0o755)
+			_ = afero.WriteFile(fs, "/nix/store/xxx-pnpm-9.15.0/libexec/pnpm/package.json", []byte(tt.manifest), 0o644)
+			p := &Pnpm{
+				fs:     fs,
+				logger: logger.New(slog.LevelError, logger.Options{}),
+				path:   "/nix/store/xxx-pnpm-9.15.0/bin/pnpm",
+				kind:   KindScript,
+				script: script,
+			}
+
+			n := &Node{path: "/usr/bin/node", version: common.MustParseVersion(tt.node)}
+			gotErr := CheckNode(n, p)
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Errorf("CheckNode() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_scriptNodeRequirement(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		script string
+		files  map[string]string
+		want   string
+		wantOk bool
+	}{
+		{
+			name:   "[正常系] bin の親の package.json",
+			script: "/pnpm/bin/pnpm.cjs",
+			files:  map[string]string{"/pnpm/package.json": `{"engines":{"node":">=18.12"}}`},
+			want:   ">=18.12",
+			wantOk: true,
+		},
+		{
+			name:   "[異常系] スクリプトなし",
+			script: "",
+		},
+		{
+			name:   "[異常系] package.json なし",
+			script: "/pnpm/bin/pnpm.cjs",
+		},
+		{
+			name:   "[異常系] engines.node なし",
+			script: "/pnpm/bin/pnpm.cjs",
+			files:  map[string]string{"/pnpm/package.json": `{"name":"pnpm"}`},
+		},
+		{
+			name:   "[異常系] engines.node が不正",
+			script: "/pnpm/bin/pnpm.cjs",
+			files:  map[string]string{"/pnpm/package.json": `{"engines":{"node":"not a range"}}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+			for path, content := range tt.files {
+				_ = afero.WriteFile(fs, path, []byte(content), 0o644)
+			}
+
+			got, gotOk := scriptNodeRequirement(fs, logger.New(slog.LevelError, logger.Options{}), tt.script)
+			if gotOk != tt.wantOk {
+				t.Fatalf("scriptNodeRequirement() ok = %v, want %v", gotOk, tt.wantOk)
+			}
+			if gotOk && got.String() != tt.want {
+				t.Errorf("scriptNodeRequirement() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_versionNodeRequirement(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		pnpm      string
+		node      string
+		wantOk    bool
+		satisfied bool
+	}{
+		{
+			name:      "[正常系] pnpm 9 と Node.js 20",
+			pnpm:      "9.15.0",
+			node:      "20.11.1",
+			wantOk:    true,
+			satisfied: true,
+		},
+		{
+			name:      "[正常系] 下限ちょうど",
+			pnpm:      "8.15.9",
+			node:      "16.14.0",
+			wantOk:    true,
+			satisfied: true,
+		},
+		{
+			name: "[正常系] 表にない pnpm",
+			pnpm: "11.0.0",
+			node: "12.0.0",
+		},
+		{
+			name:   "[異常系] pnpm 9 と Node.js 16",
+			pnpm:   "9.15.0",
+			node:   "16.20.2",
+			wantOk: true,
+		},
+		{
+			name:   "[異常系] pnpm 10 と Node.js 18.11",
+			pnpm:   "10.2.1",
+			node:   "18.11.0",
+			wantOk: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			required, gotOk := versionNodeRequirement(common.MustParseVersion(tt.pnpm))
+			if gotOk != tt.wantOk {
+				t.Fatalf("versionNodeRequirement() ok = %v, want %v", gotOk, tt.wantOk)
+			}
+			if gotOk && required.Contains(common.MustParseVersion(tt.node)) != tt.satisfied {
+				t.Errorf("%v contains %s = %v, want %v", required, tt.node, !tt.satisfied, tt.satisfied)
+			}
+		})
+	}
+}
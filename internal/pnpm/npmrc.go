@@ -0,0 +1,41 @@
+package pnpm
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// ReadNpmrc returns the settings of the .npmrc in dir. A missing .npmrc has no settings.
+// Sections and environment variable references are not interpreted.
+func ReadNpmrc(afs afero.Fs, dir string) (map[string]string, error) {
+	data, err := afero.ReadFile(afs, filepath.Join(dir, ".npmrc"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	settings := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		settings[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+
+	return settings, scanner.Err()
+}
@@ -0,0 +1,57 @@
+package pnpm
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+)
+
+func Test_ReadNpmrc(t *testing.T) {
+	t.Parallel()
+
+	content := "# comment\n; comment\n\nauto-install-peers = false\n" +
+		"registry=\"https://registry.example.com/\"\ninvalid\n"
+
+	tests := []struct {
+		name    string
+		content *string
+		want    map[string]string
+	}{
+		{
+			name:    "[正常系] .npmrc がない",
+			content: nil,
+			want:    map[string]string{},
+		},
+		{
+			name:    "[正常系] コメントと空行を無視する",
+			content: &content,
+			want: map[string]string{
+				"auto-install-peers": "false",
+				"registry":           "https://registry.example.com/",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			afs := afero.NewMemMapFs()
+			if tt.content != nil {
+				if err := afero.WriteFile(afs, "/src/.npmrc", []byte(*tt.content), 0o644); err != nil {
+					t.Fatalf("WriteFile() error: %v", err)
+				}
+			}
+
+			got, err := ReadNpmrc(afs, "/src")
+			if err != nil {
+				t.Fatalf("ReadNpmrc() error: %v", err)
+			}
+
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("ReadNpmrc() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package pnpm
+
+import (
+	"fmt"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson"
+)
+
+// PackageManagerMismatch describes how pnpm of pnpmVersion differs from the package
+// manager pinned by the packageManager field of package.json. It returns an empty
+// string if pm is pnpm of the same major and minor version, as patch releases do
+// not change the lockfile or the store.
+func PackageManagerMismatch(pnpmVersion common.Version, pm *packagejson.PackageManager) string {
+	if pm.Name != "pnpm" {
+		return fmt.Sprintf("package.json pins %s as its package manager, not pnpm", pm)
+	}
+
+	if pnpmVersion.Major == pm.Version.Major && pnpmVersion.Minor == pm.Version.Minor {
+		return ""
+	}
+
+	return fmt.Sprintf(
+		"package.json pins pnpm %s in its packageManager field, but pnpm %s is provided",
+		pm.Version,
+		pnpmVersion,
+	)
+}
@@ -0,0 +1,66 @@
+package pnpm
+
+import (
+	"testing"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson"
+)
+
+func Test_PackageManagerMismatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		pnpm           string
+		packageManager string
+		want           string
+	}{
+		{
+			name:           "[正常系] 同じバージョン",
+			pnpm:           "9.15.0",
+			packageManager: "pnpm@9.15.0+sha512.abc",
+			want:           "",
+		},
+		{
+			name:           "[正常系] パッチバージョンのみ異なる",
+			pnpm:           "9.15.4",
+			packageManager: "pnpm@9.15.0",
+			want:           "",
+		},
+		{
+			name:           "[異常系] マイナーバージョンが異なる",
+			pnpm:           "9.14.2",
+			packageManager: "pnpm@9.15.0",
+			want:           "package.json pins pnpm 9.15.0 in its packageManager field, but pnpm 9.14.2 is provided",
+		},
+		{
+			name:           "[異常系] メジャーバージョンが異なる",
+			pnpm:           "10.2.1",
+			packageManager: "pnpm@9.15.0",
+			want:           "package.json pins pnpm 9.15.0 in its packageManager field, but pnpm 10.2.1 is provided",
+		},
+		{
+			name:           "[異常系] pnpm以外のパッケージマネージャ",
+			pnpm:           "9.15.0",
+			packageManager: "yarn@4.5.0",
+			want:           "package.json pins yarn@4.5.0 as its package manager, not pnpm",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pm, err := packagejson.ParsePackageManager(tt.packageManager)
+			if err != nil {
+				t.Fatalf("ParsePackageManager() error: %v", err)
+			}
+
+			got := PackageManagerMismatch(common.MustParseVersion(tt.pnpm), pm)
+			if got != tt.want {
+				t.Errorf("PackageManagerMismatch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
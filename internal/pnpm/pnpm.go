@@ -2,6 +2,9 @@ package pnpm
 
 import (
 	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/spf13/afero"
@@ -19,6 +22,15 @@ type Pnpm struct {
 	fs     afero.Fs
 	logger logger.Logger
 	path   string
+	node   *Node // Node.js put first on PATH of pnpm; PATH is inherited if nil
+	kind   Kind
+	// script is the JavaScript entry point of pnpm scripts, which is run with node so
+	// that pnpm runs on the Node.js that was checked instead of its shebang interpreter.
+	// pnpm is run directly if it is empty.
+	script string
+	// executor runs pnpm and the pre-install commands; DirectExecutor if nil.
+	executor Executor
+	passEnv  []string // variables of the host passed in addition to allowedEnv
 }
 
 func New(fs afero.Fs, logger logger.Logger, path string) (*Pnpm, pnpm_err.PnpmErrorIF) {
@@ -27,13 +39,13 @@ func New(fs afero.Fs, logger logger.Logger, path string) (*Pnpm, pnpm_err.PnpmEr
 	}
 	logger.Debugf("found pnpm executable at path: %s", path)
 
-	return &Pnpm{fs, logger, path}, nil
+	return newPnpm(fs, logger, path), nil
 }
 
-// WithPathEnvVar searches for the pnpm executable in the PATH environment variable
-// and returns a Pnpm instance if found.
-// If not found, it returns a PnpmNotFoundError.
-func WithPathEnvVar(fs afero.Fs, logger logger.Logger) (*Pnpm, pnpm_err.PnpmErrorIF) {
+// WithPathEnvVar searches for pnpm executables in the PATH environment variable
+// and returns a Pnpm instance for every one found, in the order of PATH.
+// If none is found, it returns a PnpmNotFoundError.
+func WithPathEnvVar(fs afero.Fs, logger logger.Logger) ([]*Pnpm, pnpm_err.PnpmErrorIF) {
 	var cfg config
 
 	// Parse PATH environment variable
@@ -48,6 +60,7 @@ func WithPathEnvVar(fs afero.Fs, logger logger.Logger) (*Pnpm, pnpm_err.PnpmErro
 	logger.Debugf("parsed PATH environment variable: %v", cfg.Paths)
 
 	// Search executable pnpm in each path in PATH environment variable
+	var found []*Pnpm
 	for _, p := range cfg.Paths {
 		logger.Debugf("checking for pnpm executable in path: %s", p)
 
@@ -60,41 +73,223 @@ func WithPathEnvVar(fs afero.Fs, logger logger.Logger) (*Pnpm, pnpm_err.PnpmErro
 
 		pnpmPath := p + string(os.PathSeparator) + "pnpm"
 		err := validatePnpmExecutable(fs, pnpmPath)
-		if err == nil {
-			// Found valid pnpm executable
-			logger.Debugf("found pnpm executable at path: %s", pnpmPath)
-			return &Pnpm{fs, logger, pnpmPath}, nil
+		if err != nil {
+			logger.Debugf("pnpm executable not found at path: %s", pnpmPath)
+			continue
+		}
+
+		if slices.ContainsFunc(found, func(f *Pnpm) bool { return f.path == pnpmPath }) {
+			logger.Debugf("skipping duplicate PATH entry: %s", p)
+			continue
 		}
 
-		logger.Debugf("pnpm executable not found at path: %s", pnpmPath)
+		logger.Debugf("found pnpm executable at path: %s", pnpmPath)
+		found = append(found, newPnpm(fs, logger, pnpmPath))
 	}
 
-	// If pnpm executable is not found in any path, return an error
-	logger.Debugf("pnpm executable not found in any of the paths in PATH environment variable")
-	return nil, pnpm_err.NewPnpmError(&pnpm_err.PnpmNotFoundError{}, "", nil)
-}
+	if len(found) == 0 {
+		// If pnpm executable is not found in any path, return an error
+		logger.Debugf("pnpm executable not found in any of the paths in PATH environment variable")
+		return nil, pnpm_err.NewPnpmError(&pnpm_err.PnpmNotFoundError{}, "", nil)
+	}
 
-func (p *Pnpm) Path() string {
-	return p.path
+	return found, nil
 }
 
-func validatePnpmExecutable(fs afero.Fs, path string) pnpm_err.PnpmErrorIF {
-	f, err := fs.Stat(path)
+// InDirectory searches dir for pnpm executables and returns a Pnpm instance for every
+// one found. dir itself, its bin directory and the bin directories of its
+// subdirectories are searched, so that a directory of pnpm packages such as
+// pnpm_8, pnpm_9 and pnpm_10 can be searched at once.
+func InDirectory(fs afero.Fs, logger logger.Logger, dir string) ([]*Pnpm, pnpm_err.PnpmErrorIF) {
+	searchDirs := []string{dir, filepath.Join(dir, "bin")}
+
+	entries, err := afero.ReadDir(fs, dir)
 	if err != nil {
-		return pnpm_err.NewPnpmError(
+		return nil, pnpm_err.NewPnpmError(
 			&pnpm_err.PnpmNotFoundError{},
-			"pnpm executable not found at path: "+path,
+			"failed to read pnpm search directory: "+dir,
 			err,
 		)
 	}
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != "bin" {
+			searchDirs = append(searchDirs, filepath.Join(dir, entry.Name(), "bin"))
+		}
+	}
 
-	if !f.Mode().IsRegular() || (f.Mode().Perm()&0111 == 0) {
-		return pnpm_err.NewPnpmError(
+	var found []*Pnpm
+	for _, d := range searchDirs {
+		pnpmPath := filepath.Join(d, "pnpm")
+		if err := validatePnpmExecutable(fs, pnpmPath); err != nil {
+			continue
+		}
+
+		logger.Debugf("found pnpm executable at path: %s", pnpmPath)
+		found = append(found, newPnpm(fs, logger, pnpmPath))
+	}
+
+	if len(found) == 0 {
+		return nil, pnpm_err.NewPnpmError(
 			&pnpm_err.PnpmNotFoundError{},
-			"pnpm executable is not a executable file at path: "+path,
+			"no pnpm executable in directory: "+dir,
 			nil,
 		)
 	}
 
+	return found, nil
+}
+
+// Discover returns the pnpm installations at paths, which are pnpm executables or
+// directories searched with InDirectory. Without paths, PATH is searched.
+func Discover(fs afero.Fs, logger logger.Logger, paths []string) ([]*Pnpm, pnpm_err.PnpmErrorIF) {
+	if len(paths) == 0 {
+		return WithPathEnvVar(fs, logger)
+	}
+
+	var found []*Pnpm
+	for _, p := range paths {
+		var pnpms []*Pnpm
+		if info, err := fs.Stat(p); err == nil && info.IsDir() {
+			inDir, dirErr := InDirectory(fs, logger, p)
+			if dirErr != nil {
+				return nil, dirErr
+			}
+			pnpms = inDir
+		} else {
+			single, newErr := New(fs, logger, p)
+			if newErr != nil {
+				return nil, newErr
+			}
+			pnpms = []*Pnpm{single}
+		}
+
+		for _, candidate := range pnpms {
+			if !slices.ContainsFunc(found, func(f *Pnpm) bool { return f.path == candidate.path }) {
+				found = append(found, candidate)
+			}
+		}
+	}
+
+	return found, nil
+}
+
+func (p *Pnpm) Path() string {
+	return p.path
+}
+
+// Kind returns how pnpm is installed.
+func (p *Pnpm) Kind() Kind {
+	return p.kind
+}
+
+// BundlesNode reports whether pnpm runs on its own Node.js rather than on node from PATH.
+func (p *Pnpm) BundlesNode() bool {
+	return p.kind == KindStandalone
+}
+
+// Interpreter returns the node of the shebang line of the pnpm script if it is an
+// absolute path, such as the Node.js nixpkgs builds pnpm with, or "" otherwise.
+func (p *Pnpm) Interpreter() string {
+	if p.script == "" {
+		return ""
+	}
+
+	interpreter, _ := nodeShebang(p.fs, p.script)
+	return interpreter
+}
+
+// command returns the command running pnpm with args.
+func (p *Pnpm) command(args ...string) Command {
+	c := Command{Path: p.path, Args: args, ReadOnly: p.installDirs()}
+	if p.script == "" {
+		return c
+	}
+
+	c.Path = "node"
+	if p.node != nil {
+		c.Path = p.node.path
+	}
+	c.Args = append([]string{p.script}, args...)
+	return c
+}
+
+// installDirs returns the directories pnpm and node are installed in, which have to
+// be readable in a sandbox hiding HOME.
+func (p *Pnpm) installDirs() []string {
+	dirs := []string{installDir(realPath(p.fs, p.path))}
+	if p.script != "" && !slices.Contains(dirs, installDir(p.script)) {
+		dirs = append(dirs, installDir(p.script))
+	}
+	if p.node != nil {
+		dirs = append(dirs, installDir(realPath(p.fs, p.node.path)))
+	}
+	return dirs
+}
+
+// installDir returns the package directory of the executable at path: the parent of
+// its bin directory, or the directory of the executable if it is not in one.
+func installDir(path string) string {
+	dir := filepath.Dir(path)
+	if filepath.Base(dir) == "bin" {
+		return filepath.Dir(dir)
+	}
+	return dir
+}
+
+// run returns the process running c with the executor of p.
+func (p *Pnpm) run(c Command) *exec.Cmd {
+	if p.executor == nil {
+		return DirectExecutor{}.Command(c)
+	}
+	return p.executor.Command(c)
+}
+
+// SetExecutor makes pnpm and the pre-install commands run with e.
+func (p *Pnpm) SetExecutor(e Executor) {
+	p.executor = e
+}
+
+// sandboxed reports whether the executor of p isolates commands from the host.
+func (p *Pnpm) sandboxed() bool {
+	return p.executor != nil && p.executor.Sandboxed()
+}
+
+// SetNode makes pnpm scripts run with n, and pnpm and the pre-install commands run
+// with n first on PATH.
+func (p *Pnpm) SetNode(n *Node) {
+	p.node = n
+}
+
+// Node returns the Node.js set with SetNode, or nil.
+func (p *Pnpm) Node() *Node {
+	return p.node
+}
+
+// commandEnv returns the sanitized environment of the commands pnpm runs with extra
+// variables added.
+func (p *Pnpm) commandEnv(extra ...string) []string {
+	return sanitizeEnv(p.fs, os.Environ(), p.pathDirs(), p.passEnv, extra)
+}
+
+func validatePnpmExecutable(fs afero.Fs, path string) pnpm_err.PnpmErrorIF {
+	if problem, cause := checkExecutable(fs, path); problem != "" {
+		return pnpm_err.NewPnpmError(&pnpm_err.PnpmNotFoundError{}, "pnpm "+problem, cause)
+	}
+
 	return nil
 }
+
+// checkExecutable describes why path is not an executable file, with the cause if
+// any. It returns an empty string if path is an executable file.
+func checkExecutable(fs afero.Fs, path string) (string, error) {
+	f, err := fs.Stat(path)
+	if err != nil {
+		return "executable not found at path: " + path, err
+	}
+
+	if !f.Mode().IsRegular() || (f.Mode().Perm()&0111 == 0) {
+		return "executable is not a executable file at path: " + path, nil
+	}
+
+	return "", nil
+}
@@ -3,11 +3,13 @@ package pnpm
 import (
 	"log/slog"
 	"reflect"
+	"slices"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/spf13/afero"
 
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
 	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
 	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
 )
@@ -51,7 +53,7 @@ func Test_New(t *testing.T) {
 			t.Parallel()
 			fs := tt.setupFs()
 
-			l := logger.New(slog.LevelError)
+			l := logger.New(slog.LevelError, logger.Options{})
 			t.Cleanup(func() { l.Close() })
 
 			got, gotErr := New(fs, l, tt.path)
@@ -72,7 +74,7 @@ func Test_WithPathEnvVar(t *testing.T) {
 		name       string
 		setupFs    func() afero.Fs
 		pathEnvVar string
-		wantPath   string
+		wantPaths  []string
 		wantErr    pnpm_err.PnpmErrorIF
 	}{
 		{
@@ -83,7 +85,7 @@ func Test_WithPathEnvVar(t *testing.T) {
 				return fs
 			},
 			pathEnvVar: "/path/to/pnpm/bin",
-			wantPath:   "/path/to/pnpm/bin/pnpm",
+			wantPaths:  []string{"/path/to/pnpm/bin/pnpm"},
 		},
 		{
 			name: "[正常系] PATHに複数のパスが含まれている場合",
@@ -93,7 +95,19 @@ func Test_WithPathEnvVar(t *testing.T) {
 				return fs
 			},
 			pathEnvVar: "/some/other/path:/path/to/pnpm/bin",
-			wantPath:   "/path/to/pnpm/bin/pnpm",
+			wantPaths:  []string{"/path/to/pnpm/bin/pnpm"},
+		},
+		{
+			name: "[正常系] PATHに複数のpnpmが含まれている場合",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				afero.WriteFile(fs, "/pnpm_9/bin/pnpm", []byte{}, 0755)
+				afero.WriteFile(fs, "/pnpm_10/bin/pnpm", []byte{}, 0755)
+				afero.WriteFile(fs, "/not/executable/pnpm", []byte{}, 0644)
+				return fs
+			},
+			pathEnvVar: "/pnpm_10/bin:/not/executable:/pnpm_9/bin:/pnpm_10/bin",
+			wantPaths:  []string{"/pnpm_10/bin/pnpm", "/pnpm_9/bin/pnpm"},
 		},
 		{
 			name:       "[異常系] PATHにpnpmが含まれていない場合",
@@ -114,14 +128,12 @@ func Test_WithPathEnvVar(t *testing.T) {
 			t.Setenv("PATH", tt.pathEnvVar)
 			fs := tt.setupFs()
 
-			l := logger.New(slog.LevelError)
+			l := logger.New(slog.LevelError, logger.Options{})
 			t.Cleanup(func() { l.Close() })
 
 			got, gotErr := WithPathEnvVar(fs, l)
-			if got != nil {
-				if d := cmp.Diff(tt.wantPath, got.path); d != "" {
-					t.Errorf("WithPathEnvVar() mismatch (-want +got):\n%s", d)
-				}
+			if d := cmp.Diff(tt.wantPaths, pnpmPaths(got)); d != "" {
+				t.Errorf("WithPathEnvVar() mismatch (-want +got):\n%s", d)
 			}
 			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
 				t.Errorf("WithPathEnvVar() error = %v, wantErr %v", gotErr, tt.wantErr)
@@ -129,3 +141,135 @@ func Test_WithPathEnvVar(t *testing.T) {
 		})
 	}
 }
+
+func Test_Discover(t *testing.T) {
+	t.Parallel()
+
+	setupFs := func() afero.Fs {
+		fs := afero.NewMemMapFs()
+		afero.WriteFile(fs, "/opt/pnpm/pnpm_8/bin/pnpm", []byte{}, 0755)
+		afero.WriteFile(fs, "/opt/pnpm/pnpm_9/bin/pnpm", []byte{}, 0755)
+		afero.WriteFile(fs, "/opt/pnpm/pnpm_10/bin/pnpm", []byte{}, 0755)
+		afero.WriteFile(fs, "/opt/pnpm/docs/README", []byte{}, 0644)
+		afero.WriteFile(fs, "/opt/profile/bin/pnpm", []byte{}, 0755)
+		afero.WriteFile(fs, "/usr/bin/pnpm", []byte{}, 0755)
+		fs.MkdirAll("/empty", 0755)
+		return fs
+	}
+
+	tests := []struct {
+		name      string
+		paths     []string
+		wantPaths []string
+		wantErr   pnpm_err.PnpmErrorIF
+	}{
+		{
+			name:      "[正常系] 実行ファイルのパス",
+			paths:     []string{"/usr/bin/pnpm"},
+			wantPaths: []string{"/usr/bin/pnpm"},
+		},
+		{
+			name:  "[正常系] サブディレクトリのbinを探索する",
+			paths: []string{"/opt/pnpm"},
+			wantPaths: []string{
+				"/opt/pnpm/pnpm_10/bin/pnpm",
+				"/opt/pnpm/pnpm_8/bin/pnpm",
+				"/opt/pnpm/pnpm_9/bin/pnpm",
+			},
+		},
+		{
+			name:      "[正常系] ディレクトリのbinを探索する",
+			paths:     []string{"/opt/profile"},
+			wantPaths: []string{"/opt/profile/bin/pnpm"},
+		},
+		{
+			name:      "[正常系] 重複するパスを除く",
+			paths:     []string{"/usr/bin/pnpm", "/usr/bin", "/opt/profile/bin/pnpm"},
+			wantPaths: []string{"/usr/bin/pnpm", "/opt/profile/bin/pnpm"},
+		},
+		{
+			name:    "[異常系] pnpmがないディレクトリ",
+			paths:   []string{"/empty"},
+			wantErr: &pnpm_err.PnpmNotFoundError{},
+		},
+		{
+			name:    "[異常系] 存在しないパス",
+			paths:   []string{"/usr/bin/pnpm", "/invalid/pnpm"},
+			wantErr: &pnpm_err.PnpmNotFoundError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			l := logger.New(slog.LevelError, logger.Options{})
+			t.Cleanup(func() { l.Close() })
+
+			got, gotErr := Discover(setupFs(), l, tt.paths)
+			if d := cmp.Diff(tt.wantPaths, pnpmPaths(got)); d != "" {
+				t.Errorf("Discover() mismatch (-want +got):\n%s", d)
+			}
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Errorf("Discover() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func pnpmPaths(pnpms []*Pnpm) []string {
+	var paths []string
+	for _, p := range pnpms {
+		paths = append(paths, p.path)
+	}
+	return paths
+}
+
+func Test_command(t *testing.T) {
+	t.Parallel()
+
+	node := &Node{path: "/opt/node-20/bin/node", version: common.MustParseVersion("20.11.1")}
+
+	tests := []struct {
+		name     string
+		pnpm     *Pnpm
+		wantPath string
+		wantArgs []string
+	}{
+		{
+			name: "[正常系] スクリプトは選択したnodeで実行する",
+			pnpm: &Pnpm{
+				path:   "/nix/store/abc-pnpm/bin/pnpm",
+				script: "/nix/store/abc-pnpm/libexec/pnpm/bin/pnpm.cjs",
+				node:   node,
+			},
+			wantPath: "/opt/node-20/bin/node",
+			wantArgs: []string{"/nix/store/abc-pnpm/libexec/pnpm/bin/pnpm.cjs", "--version"},
+		},
+		{
+			name:     "[正常系] nodeが未設定ならPATHのnodeで実行する",
+			pnpm:     &Pnpm{path: "/usr/bin/pnpm", script: "/usr/lib/node_modules/pnpm/bin/pnpm.cjs"},
+			wantPath: "node",
+			wantArgs: []string{"/usr/lib/node_modules/pnpm/bin/pnpm.cjs", "--version"},
+		},
+		{
+			name:     "[正常系] スタンドアロンバイナリは直接実行する",
+			pnpm:     &Pnpm{path: "/home/user/.local/share/pnpm/pnpm", kind: KindStandalone, node: node},
+			wantPath: "/home/user/.local/share/pnpm/pnpm",
+			wantArgs: []string{"--version"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.pnpm.fs = afero.NewMemMapFs()
+
+			got := tt.pnpm.command("--version")
+			if got.Path != tt.wantPath || !slices.Equal(got.Args, tt.wantArgs) {
+				t.Errorf("command() = %s %v, want %s %v", got.Path, got.Args, tt.wantPath, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package pnpm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+)
+
+// ndjsonReporterFlag makes pnpm report progress and logs as JSON lines.
+const ndjsonReporterFlag = "--reporter=ndjson"
+
+// reporterEvent is the part of a pnpm ndjson log event used for progress and messages.
+// See https://github.com/pnpm/pnpm/tree/main/packages/core-loggers
+type reporterEvent struct {
+	Name       string `json:"name"`
+	Level      string `json:"level"`
+	Message    string `json:"message"`
+	Status     string `json:"status"`
+	Stage      string `json:"stage"`
+	PackageID  string `json:"packageId"`
+	Downloaded int64  `json:"downloaded"`
+	Added      int    `json:"added"`
+	Code       string `json:"code"`
+	Err        *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"err"`
+}
+
+// ndjsonReporter turns the output of pnpm --reporter=ndjson into progress updates
+// and readable lines. Raw lines are passed to the command logger's debug output,
+// log messages and errors are written to out, and lines that are not JSON
+// (e.g. output of pnpm itself before the reporter starts) are written to out as is.
+type ndjsonReporter struct {
+	cmdLogger logger.CommandLogger
+	out       io.Writer
+	now       func() time.Time
+
+	buf        []byte
+	start      time.Time
+	progress   logger.Progress
+	downloaded map[string]int64 // bytes downloaded per package
+	resolved   bool             // resolution is done and Total is known
+}
+
+func newNDJSONReporter(cmdLogger logger.CommandLogger, out io.Writer) *ndjsonReporter {
+	return &ndjsonReporter{
+		cmdLogger:  cmdLogger,
+		out:        out,
+		now:        time.Now,
+		downloaded: make(map[string]int64),
+	}
+}
+
+func (r *ndjsonReporter) Write(p []byte) (int, error) {
+	r.buf = append(r.buf, p...)
+
+	for {
+		i := bytes.IndexByte(r.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		r.handleLine(string(r.buf[:i]))
+		r.buf = r.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush handles an incomplete last line.
+func (r *ndjsonReporter) Flush() {
+	if len(r.buf) > 0 {
+		r.handleLine(string(r.buf))
+		r.buf = nil
+	}
+}
+
+func (r *ndjsonReporter) handleLine(line string) {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+
+	var event reporterEvent
+	if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), &event) != nil {
+		_, _ = io.WriteString(r.out, line+"\n")
+		return
+	}
+
+	r.cmdLogger.Debug(line)
+
+	if r.updateProgress(&event) {
+		r.cmdLogger.Progress(r.progress)
+	}
+
+	if text := eventText(&event); text != "" {
+		_, _ = io.WriteString(r.out, text+"\n")
+	}
+}
+
+// updateProgress applies a progress event and reports whether the progress changed.
+//
+//nolint:cyclop // one case per pnpm event
+func (r *ndjsonReporter) updateProgress(event *reporterEvent) bool {
+	if r.start.IsZero() {
+		r.start = r.now()
+	}
+
+	switch event.Name {
+	case "pnpm:progress":
+		switch event.Status {
+		case "resolved":
+			r.progress.Resolved++
+		case "fetched", "found_in_store":
+			r.progress.Fetched++
+		case "imported":
+			r.progress.Added++
+		default:
+			return false
+		}
+	case "pnpm:stage":
+		if event.Stage != "resolution_done" {
+			return false
+		}
+
+		r.resolved = true
+	case "pnpm:fetching-progress":
+		if event.Status != "in_progress" {
+			return false
+		}
+
+		r.progress.Downloaded += event.Downloaded - r.downloaded[event.PackageID]
+		r.downloaded[event.PackageID] = event.Downloaded
+	case "pnpm:stats":
+		if event.Added <= r.progress.Added {
+			return false
+		}
+
+		r.progress.Added = event.Added
+	default:
+		return false
+	}
+
+	if r.resolved {
+		r.progress.Total = r.progress.Resolved
+	}
+
+	r.progress.ETA = r.eta()
+
+	return true
+}
+
+// eta estimates the time until all packages are fetched from the fetch rate so far.
+func (r *ndjsonReporter) eta() time.Duration {
+	if r.progress.Total == 0 || r.progress.Fetched == 0 || r.progress.Fetched >= r.progress.Total {
+		return 0
+	}
+
+	elapsed := r.now().Sub(r.start)
+	remaining := r.progress.Total - r.progress.Fetched
+
+	return elapsed * time.Duration(remaining) / time.Duration(r.progress.Fetched)
+}
+
+// eventText returns the readable line of a log message or error event, or "" for
+// events that only carry progress.
+func eventText(event *reporterEvent) string {
+	if event.Err != nil && event.Err.Code != "" {
+		return " " + event.Err.Code + "  " + event.Err.Message
+	}
+
+	if event.Message == "" {
+		return ""
+	}
+
+	switch event.Level {
+	case "warn":
+		return " WARN  " + event.Message
+	case "error":
+		if event.Code != "" {
+			return " " + event.Code + "  " + event.Message
+		}
+
+		return " ERROR  " + event.Message
+	case "info":
+		return event.Message
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,111 @@
+package pnpm
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+)
+
+// recordingCommandLogger records what a command reports.
+type recordingCommandLogger struct {
+	bytes.Buffer
+	progress []logger.Progress
+	debug    []string
+}
+
+func (c *recordingCommandLogger) Progress(p logger.Progress) { c.progress = append(c.progress, p) }
+func (c *recordingCommandLogger) Debug(line string)          { c.debug = append(c.debug, line) }
+func (c *recordingCommandLogger) Done()                      {}
+func (c *recordingCommandLogger) Fail(_ int)                 {}
+
+func Test_ndjsonReporter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		chunks       []string
+		wantOut      string
+		wantProgress logger.Progress
+		wantDebug    int
+	}{
+		{
+			name: "[正常系] 進捗イベントが集計される",
+			chunks: []string{
+				`{"name":"pnpm:progress","status":"resolved","packageId":"a"}` + "\n" +
+					`{"name":"pnpm:progress","status":"resolved","packageId":"b"}` + "\n" +
+					`{"name":"pnpm:stage","stage":"resolution_done"}` + "\n",
+				`{"name":"pnpm:fetching-progress","status":"in_progress","packageId":"a","downloaded":100}` + "\n",
+				`{"name":"pnpm:fetching-progress","status":"in_progress","packageId":"a","downloaded":300}` + "\n",
+				`{"name":"pnpm:progress","status":"fetched","packageId":"a"}` + "\n",
+				`{"name":"pnpm:stats","added":2}` + "\n",
+			},
+			wantProgress: logger.Progress{
+				Resolved:   2,
+				Fetched:    1,
+				Added:      2,
+				Total:      2,
+				Downloaded: 300,
+				ETA:        20 * time.Second,
+			},
+			wantDebug: 7,
+		},
+		{
+			name: "[正常系] 行をまたぐ書き込みとメッセージ",
+			chunks: []string{
+				`{"name":"pnpm","level":"info","mess`,
+				`age":"Lockfile is up to date"}` + "\n" + `{"name":"pnpm:deprecation","level":"warn","message":"old@1.0.0 is deprecated"}` + "\n",
+				"not json\n",
+				`{"name":"pnpm","level":"error","err":{"code":"ERR_PNPM_FETCH_404","message":"GET /missing: Not Found"}}`,
+			},
+			wantOut: "Lockfile is up to date\n" +
+				" WARN  old@1.0.0 is deprecated\n" +
+				"not json\n" +
+				" ERR_PNPM_FETCH_404  GET /missing: Not Found\n",
+			wantDebug: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmdLogger := &recordingCommandLogger{}
+			var out bytes.Buffer
+
+			// Every call advances the clock by 10 seconds: the first fetch is reported after 10s
+			// and the last event after 20s, leaving one of two packages to fetch.
+			clock := time.Unix(0, 0)
+			r := newNDJSONReporter(cmdLogger, &out)
+			r.now = func() time.Time {
+				clock = clock.Add(10 * time.Second)
+				return clock
+			}
+
+			for _, chunk := range tt.chunks {
+				r.Write([]byte(chunk))
+			}
+			r.Flush()
+
+			if diff := cmp.Diff(tt.wantOut, out.String()); diff != "" {
+				t.Errorf("output mismatch (-want +got):\n%s", diff)
+			}
+
+			var gotProgress logger.Progress
+			if len(cmdLogger.progress) > 0 {
+				gotProgress = cmdLogger.progress[len(cmdLogger.progress)-1]
+			}
+
+			if diff := cmp.Diff(tt.wantProgress, gotProgress); diff != "" {
+				t.Errorf("progress mismatch (-want +got):\n%s", diff)
+			}
+
+			if len(cmdLogger.debug) != tt.wantDebug {
+				t.Errorf("debug lines = %d, want %d", len(cmdLogger.debug), tt.wantDebug)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package pnpm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson"
+	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
+)
+
+// candidate is a pnpm installation with its version.
+type candidate struct {
+	pnpm    *Pnpm
+	version common.Version
+}
+
+func (c candidate) String() string {
+	return fmt.Sprintf("pnpm %s at %s", c.version, c.pnpm.path)
+}
+
+// Ranks of the fields of candidateRank.
+const (
+	rankLow = iota
+	rankMedium
+	rankHigh
+)
+
+// candidateRank orders the candidates of Select. The packageManager field takes
+// precedence over the lockfile version, as it pins the version the project uses.
+type candidateRank struct {
+	packageManager int // rankHigh for the pinned version, rankMedium for its minor version
+	lockfile       int // rankHigh for LockfileNative, rankMedium for LockfileUnknown
+	reasons        []string
+}
+
+func (r candidateRank) better(o candidateRank) bool {
+	if r.packageManager != o.packageManager {
+		return r.packageManager > o.packageManager
+	}
+	return r.lockfile > o.lockfile
+}
+
+// rankCandidate ranks c for the lockfile and package manager. It returns false with
+// the reason if c cannot install from the lockfile.
+func rankCandidate(
+	c candidate,
+	lockfileVersion common.Version,
+	pm *packagejson.PackageManager,
+) (candidateRank, bool) {
+	var r candidateRank
+
+	switch support, msg := LockfileCompatibility(c.version, lockfileVersion); support {
+	case LockfileRejected:
+		return candidateRank{reasons: []string{msg}}, false
+	case LockfileNative:
+		r.lockfile = rankHigh
+		r.reasons = append(r.reasons, fmt.Sprintf(
+			"it writes lockfileVersion %d.%d",
+			lockfileVersion.Major,
+			lockfileVersion.Minor,
+		))
+	case LockfileUnknown:
+		r.lockfile = rankMedium
+		r.reasons = append(r.reasons, "it is newer than the lockfile compatibility table")
+	case LockfileConverted:
+		r.lockfile = rankLow
+		r.reasons = append(r.reasons, fmt.Sprintf(
+			"it can install from lockfileVersion %d.%d, but writes another version",
+			lockfileVersion.Major,
+			lockfileVersion.Minor,
+		))
+	}
+
+	if pm != nil && PackageManagerMismatch(c.version, pm) == "" {
+		if c.version.Compare(pm.Version) == 0 {
+			r.packageManager = rankHigh
+			r.reasons = append(r.reasons, "it is the version pinned by the packageManager field")
+		} else {
+			r.packageManager = rankMedium
+			r.reasons = append(r.reasons, fmt.Sprintf(
+				"it has the minor version of %s pinned by the packageManager field",
+				pm,
+			))
+		}
+	}
+
+	return r, true
+}
+
+// selectCandidate returns the best-ranked candidate that can install from the
+// lockfile, preferring earlier candidates among equally ranked ones, with the
+// reasons for selecting it.
+func selectCandidate(
+	candidates []candidate,
+	lockfileVersion common.Version,
+	pm *packagejson.PackageManager,
+	logger logger.Logger,
+) (candidate, []string, bool) {
+	var (
+		best     candidate
+		bestRank candidateRank
+		found    bool
+	)
+
+	for _, c := range candidates {
+		r, ok := rankCandidate(c, lockfileVersion, pm)
+		if !ok {
+			logger.Debugf("%s cannot be used: %s", c, strings.Join(r.reasons, "; "))
+			continue
+		}
+		logger.Debugf("%s can be used: %s", c, strings.Join(r.reasons, " and "))
+
+		if !found || r.better(bestRank) {
+			best, bestRank, found = c, r, true
+		}
+	}
+
+	return best, bestRank.reasons, found
+}
+
+// Select returns the pnpm installation best suited for a lockfile of lockfileVersion
+// and the packageManager field pm, which may be nil. pnpm versions pinned by pm are
+// preferred over pnpm versions writing lockfileVersion, and earlier installations
+// over later ones. It fails with an UnsupportedLockfileVersionError if none of
+// them can install from the lockfile.
+func Select(
+	logger logger.Logger,
+	pnpms []*Pnpm,
+	lockfileVersion common.Version,
+	pm *packagejson.PackageManager,
+) (*Pnpm, pnpm_err.PnpmErrorIF) {
+	candidates := make([]candidate, 0, len(pnpms))
+	var versionErr pnpm_err.PnpmErrorIF
+	for _, p := range pnpms {
+		v, err := p.SemVer()
+		if err != nil {
+			logger.Warnf("skipping pnpm at %s: %v", p.path, err)
+			versionErr = err
+			continue
+		}
+		candidates = append(candidates, candidate{pnpm: p, version: v})
+	}
+
+	if len(candidates) == 0 && versionErr != nil {
+		return nil, versionErr
+	}
+
+	selected, reasons, ok := selectCandidate(candidates, lockfileVersion, pm, logger)
+	if !ok && len(candidates) == 1 {
+		_, msg := LockfileCompatibility(candidates[0].version, lockfileVersion)
+		return nil, pnpm_err.NewPnpmError(&pnpm_err.UnsupportedLockfileVersionError{}, msg, nil)
+	}
+	if !ok {
+		found := make([]string, len(candidates))
+		for i, c := range candidates {
+			found[i] = c.String()
+		}
+
+		return nil, pnpm_err.NewPnpmError(
+			&pnpm_err.UnsupportedLockfileVersionError{},
+			fmt.Sprintf(
+				"none of the pnpm installations can install from lockfileVersion %d.%d in pnpm-lock.yaml (found %s); use %s",
+				lockfileVersion.Major,
+				lockfileVersion.Minor,
+				strings.Join(found, ", "),
+				lockfileWriters(lockfileVersion.Major),
+			),
+			nil,
+		)
+	}
+
+	if len(candidates) > 1 {
+		logger.Infof("using %s, as %s", selected, strings.Join(reasons, " and "))
+	}
+
+	return selected.pnpm, nil
+}
@@ -0,0 +1,132 @@
+package pnpm
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/logger"
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/packagejson"
+)
+
+func Test_selectCandidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		versions       []string
+		lockfile       string
+		packageManager string
+		want           string // version of the selected candidate
+		wantOK         bool
+	}{
+		{
+			name:     "[正常系] lockfileを書き出すバージョンを選ぶ",
+			versions: []string{"8.15.9", "9.15.0", "10.2.1"},
+			lockfile: "6.0",
+			want:     "8.15.9",
+			wantOK:   true,
+		},
+		{
+			name:     "[正常系] 同順位なら先のものを選ぶ",
+			versions: []string{"10.2.1", "9.15.0"},
+			lockfile: "9.0",
+			want:     "10.2.1",
+			wantOK:   true,
+		},
+		{
+			name:     "[正常系] 変換するバージョンより表にないバージョンを選ぶ",
+			versions: []string{"9.15.0", "11.0.0"},
+			lockfile: "6.0",
+			want:     "11.0.0",
+			wantOK:   true,
+		},
+		{
+			name:     "[正常系] lockfileを読めないpnpmを除く",
+			versions: []string{"8.15.9", "11.0.0"},
+			lockfile: "9.0",
+			want:     "11.0.0",
+			wantOK:   true,
+		},
+		{
+			name:           "[正常系] packageManagerのバージョンを優先する",
+			versions:       []string{"10.2.1", "9.14.2", "9.15.4", "9.15.0"},
+			lockfile:       "9.0",
+			packageManager: "pnpm@9.15.0",
+			want:           "9.15.0",
+			wantOK:         true,
+		},
+		{
+			name:           "[正常系] packageManagerのマイナーバージョンを優先する",
+			versions:       []string{"10.2.1", "9.15.4"},
+			lockfile:       "9.0",
+			packageManager: "pnpm@9.15.0",
+			want:           "9.15.4",
+			wantOK:         true,
+		},
+		{
+			name:           "[正常系] packageManagerに一致するものがない",
+			versions:       []string{"8.15.9", "10.2.1"},
+			lockfile:       "9.0",
+			packageManager: "pnpm@9.15.0",
+			want:           "10.2.1",
+			wantOK:         true,
+		},
+		{
+			name:           "[正常系] lockfileを読めないpnpmはpackageManagerに一致しても選ばない",
+			versions:       []string{"8.15.9", "9.15.0"},
+			lockfile:       "9.0",
+			packageManager: "pnpm@8.15.9",
+			want:           "9.15.0",
+			wantOK:         true,
+		},
+		{
+			name:     "[異常系] lockfileを読めるpnpmがない",
+			versions: []string{"9.15.0", "10.2.1"},
+			lockfile: "5.4",
+			wantOK:   false,
+		},
+		{
+			name:     "[異常系] 候補がない",
+			versions: nil,
+			lockfile: "9.0",
+			wantOK:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			l := logger.New(slog.LevelError, logger.Options{})
+			t.Cleanup(func() { l.Close() })
+
+			candidates := make([]candidate, len(tt.versions))
+			for i, v := range tt.versions {
+				candidates[i] = candidate{pnpm: &Pnpm{path: "/pnpm/" + v}, version: common.MustParseVersion(v)}
+			}
+
+			var pm *packagejson.PackageManager
+			if tt.packageManager != "" {
+				parsed, err := packagejson.ParsePackageManager(tt.packageManager)
+				if err != nil {
+					t.Fatalf("ParsePackageManager() error: %v", err)
+				}
+				pm = parsed
+			}
+
+			got, reasons, ok := selectCandidate(candidates, common.MustParseVersion(tt.lockfile), pm, l)
+			if ok != tt.wantOK {
+				t.Fatalf("selectCandidate() ok = %t, want %t", ok, tt.wantOK)
+			}
+
+			if ok && got.version.String() != tt.want {
+				t.Errorf("selectCandidate() = %s, want %s", got.version, tt.want)
+			}
+
+			if ok && len(reasons) == 0 {
+				t.Errorf("selectCandidate() returned no reasons")
+			}
+		})
+	}
+}
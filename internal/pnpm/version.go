@@ -1,41 +1,55 @@
 package pnpm
 
 import (
-	"os/exec"
+	"fmt"
+	"strings"
 
 	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
 	pnpm_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/pnpm/errors"
 )
 
+// Version returns the output of pnpm --version without surrounding whitespace.
 func (p *Pnpm) Version() (string, pnpm_err.PnpmErrorIF) {
-	cmd := exec.Command(p.path, "--version")
+	c := p.command("--version")
+	c.Env = p.commandEnv()
 
-	o, err := cmd.Output()
+	o, err := p.run(c).Output()
 	if err != nil {
-		return "", pnpm_err.NewPnpmError(
-			&pnpm_err.FailedToExecuteError{},
-			"failed to execute pnpm to get version",
-			err,
-		)
+		msg := "failed to execute pnpm to get version"
+		if p.node != nil {
+			// A Node.js version too old for pnpm makes it crash before printing its version.
+			msg += fmt.Sprintf(" with Node.js %s at %s", p.node.version, p.node.path)
+		}
+		return "", pnpm_err.NewPnpmError(&pnpm_err.FailedToExecuteError{}, msg, err)
 	}
 
-	return string(o), nil
+	return strings.TrimSpace(string(o)), nil
 }
 
-func (p *Pnpm) MajorVersion() (int, pnpm_err.PnpmErrorIF) {
+// SemVer returns the parsed version of pnpm.
+func (p *Pnpm) SemVer() (common.Version, pnpm_err.PnpmErrorIF) {
 	versionStr, err := p.Version()
 	if err != nil {
-		return 0, err
+		return common.Version{}, err
 	}
 
-	major, majorVerErr := common.MajorVersion(versionStr)
-	if majorVerErr != nil {
-		return 0, pnpm_err.NewPnpmError(
+	v, parseErr := common.ParseVersion(versionStr)
+	if parseErr != nil {
+		return common.Version{}, pnpm_err.NewPnpmError(
 			&pnpm_err.FailedToParseError{},
 			"invalid pnpm version format: "+versionStr,
-			majorVerErr,
+			parseErr,
 		)
 	}
 
-	return major, nil
+	return v, nil
+}
+
+func (p *Pnpm) MajorVersion() (int, pnpm_err.PnpmErrorIF) {
+	v, err := p.SemVer()
+	if err != nil {
+		return 0, err
+	}
+
+	return v.Major, nil
 }
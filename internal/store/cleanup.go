@@ -0,0 +1,49 @@
+package store
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/spf13/afero"
+
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+// RemoveAll removes path and everything below it.
+// Normalized stores have read-only (0555) directories, which would make a plain
+// RemoveAll fail, so owner write permission is restored on every directory first.
+// A path that does not exist is not an error.
+func RemoveAll(afs afero.Fs, path string) store_err.StoreErrorIF {
+	if _, err := afs.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	walkErr := afero.Walk(afs, path, func(p string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return afs.Chmod(p, info.Mode().Perm()|0o700)
+		}
+
+		return nil
+	})
+	if walkErr != nil {
+		return store_err.NewStoreError(
+			&store_err.FailedToCleanupError{},
+			path,
+			walkErr,
+		)
+	}
+
+	if err := afs.RemoveAll(path); err != nil {
+		return store_err.NewStoreError(
+			&store_err.FailedToCleanupError{},
+			path,
+			err,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+// storeV10IndexHexLen is the length of the package integrity digest in store v10 index file names.
+const storeV10IndexHexLen = 64
+
+// IndexedPackage is a package with an index file in the store.
+type IndexedPackage struct {
+	ID           string // name@version, or the index file path if the index does not record it
+	IndexPath    string // path of the index file, relative to the store root
+	IntegrityHex string // hex digest of the package integrity (truncated by store v10)
+}
+
+// ListIndexedPackages returns the packages that have an index file in the store.
+func ListIndexedPackages(afs afero.Fs, storePath string) ([]IndexedPackage, store_err.StoreErrorIF) {
+	var packages []IndexedPackage
+
+	walkErr := walkIndexFiles(afs, storePath, func(indexPath string) error {
+		data, err := afero.ReadFile(afs, filepath.Join(storePath, filepath.FromSlash(indexPath)))
+		if err != nil {
+			return err
+		}
+
+		// Unreadable index files are reported by Verify; they still identify a package here.
+		var index PackageIndexFile
+		_ = json.Unmarshal(data, &index)
+
+		packages = append(packages, IndexedPackage{
+			ID:           packageID(indexPath, &index),
+			IndexPath:    indexPath,
+			IntegrityHex: indexIntegrityHex(indexPath),
+		})
+
+		return nil
+	})
+	if walkErr != nil {
+		return nil, walkErr
+	}
+
+	return packages, nil
+}
+
+// CompletenessReport lists the differences between the expected and the indexed packages.
+type CompletenessReport struct {
+	Missing    []string // expected packages without an index file, sorted
+	Unexpected []string // indexed packages that were not expected, sorted
+}
+
+// CheckCompleteness compares the packages indexed in a store with the expected packages
+// (name@version to integrity, e.g. the dependency closure of the lockfile).
+// Packages are matched by the integrity their index file is stored under, or by
+// name@version if the expected package has no sha512 integrity.
+func CheckCompleteness(indexed []IndexedPackage, expected map[string]string) CompletenessReport {
+	byHex := make(map[string][]int)
+	byID := make(map[string][]int)
+
+	for i, pkg := range indexed {
+		byHex[pkg.IntegrityHex] = append(byHex[pkg.IntegrityHex], i)
+		byID[pkg.ID] = append(byID[pkg.ID], i)
+	}
+
+	matched := make([]bool, len(indexed))
+
+	var report CompletenessReport
+
+	for id, integrity := range expected {
+		var found []int
+
+		if h := integrityHex(integrity); h != "" {
+			found = slices.Concat(byHex[h], byHex[h[:min(len(h), storeV10IndexHexLen)]])
+		} else {
+			found = byID[id]
+		}
+
+		if len(found) == 0 {
+			report.Missing = append(report.Missing, id)
+			continue
+		}
+
+		for _, i := range found {
+			matched[i] = true
+		}
+	}
+
+	for i, pkg := range indexed {
+		if !matched[i] {
+			report.Unexpected = append(report.Unexpected, pkg.ID)
+		}
+	}
+
+	slices.Sort(report.Missing)
+	slices.Sort(report.Unexpected)
+	report.Unexpected = slices.Compact(report.Unexpected)
+
+	return report
+}
+
+// integrityHex returns the hex digest of a sha512 integrity, or "" for other integrities.
+func integrityHex(integrity string) string {
+	digest, ok := strings.CutPrefix(integrity, "sha512-")
+	if !ok {
+		return ""
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(digest)
+	if err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(raw)
+}
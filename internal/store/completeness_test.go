@@ -0,0 +1,63 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+func Test_CheckCompleteness(t *testing.T) {
+	t.Parallel()
+
+	fooIntegrity, _ := sha512Integrity("foo-1.0.0.tgz")
+	barIntegrity, _ := sha512Integrity("bar-2.0.0.tgz")
+	bazIntegrity, _ := sha512Integrity("baz-3.0.0.tgz")
+
+	afs := afero.NewMemMapFs()
+	writeStorePackage(t, afs, "foo", "1.0.0", fooIntegrity, "foo")
+	writeStorePackage(t, afs, "baz", "3.0.0", bazIntegrity, "baz")
+
+	indexed, err := store.ListIndexedPackages(afs, "/store")
+	if err != nil {
+		t.Fatalf("ListIndexedPackages() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected map[string]string
+		want     store.CompletenessReport
+	}{
+		{
+			name:     "[正常系] integrityで一致したパッケージは欠落扱いにならない",
+			expected: map[string]string{"foo@1.0.0": fooIntegrity, "baz@3.0.0": bazIntegrity},
+			want:     store.CompletenessReport{},
+		},
+		{
+			name:     "[正常系] 欠落と想定外のパッケージが報告される",
+			expected: map[string]string{"foo@1.0.0": fooIntegrity, "bar@2.0.0": barIntegrity},
+			want: store.CompletenessReport{
+				Missing:    []string{"bar@2.0.0"},
+				Unexpected: []string{"baz@3.0.0"},
+			},
+		},
+		{
+			name:     "[正常系] integrityのないパッケージはname@versionで一致する",
+			expected: map[string]string{"foo@1.0.0": ""},
+			want:     store.CompletenessReport{Unexpected: []string{"baz@3.0.0"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := store.CheckCompleteness(indexed, tt.expected)
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("CheckCompleteness() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+// Copy recursively copies the tree at src to dst, which must not exist.
+// Permissions are preserved except that owner write permission is added,
+// so the copy can be normalized even if src is a read-only Nix store path.
+// Symlinks are copied as symlinks.
+func Copy(afs afero.Fs, src string, dst string) store_err.StoreErrorIF {
+	if _, err := afs.Stat(dst); !errors.Is(err, fs.ErrNotExist) {
+		return store_err.NewStoreError(
+			&store_err.FailedToCopyError{},
+			"destination already exists: "+dst,
+			err,
+		)
+	}
+
+	walkErr := afero.Walk(afs, src, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, relErr := filepath.Rel(src, path)
+		if relErr != nil {
+			return relErr
+		}
+
+		return copyEntry(afs, path, filepath.Join(dst, relPath), info)
+	})
+	if walkErr != nil {
+		var storeErr store_err.StoreErrorIF
+		if errors.As(walkErr, &storeErr) {
+			return storeErr
+		}
+
+		return store_err.NewStoreError(
+			&store_err.FailedToCopyError{},
+			src,
+			walkErr,
+		)
+	}
+
+	return nil
+}
+
+// copyEntry copies a single file, directory or symlink.
+func copyEntry(afs afero.Fs, src string, dst string, info fs.FileInfo) store_err.StoreErrorIF {
+	mode := info.Mode().Perm() | 0o200
+
+	switch {
+	case info.IsDir():
+		if err := afs.MkdirAll(dst, mode); err != nil {
+			return store_err.NewStoreError(&store_err.FailedToCopyError{}, dst, err)
+		}
+
+		// MkdirAll is subject to umask, so set the permissions explicitly.
+		if err := afs.Chmod(dst, mode); err != nil {
+			return store_err.NewStoreError(&store_err.FailedToCopyError{}, dst, err)
+		}
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := readSymlinkTarget(afs, src)
+		if err != nil {
+			return store_err.NewStoreError(&store_err.FailedToCopyError{}, src, err)
+		}
+
+		linker, ok := afs.(afero.Linker)
+		if !ok {
+			return store_err.NewStoreError(&store_err.FailedToCopyError{}, dst, fs.ErrInvalid)
+		}
+
+		if err := linker.SymlinkIfPossible(target, dst); err != nil {
+			return store_err.NewStoreError(&store_err.FailedToCopyError{}, dst, err)
+		}
+	default:
+		return copyFile(afs, src, dst, mode)
+	}
+
+	return nil
+}
+
+// copyFile copies the contents of a regular file and sets its permissions.
+func copyFile(afs afero.Fs, src string, dst string, mode fs.FileMode) store_err.StoreErrorIF {
+	in, err := afs.Open(src)
+	if err != nil {
+		return store_err.NewStoreError(&store_err.FailedToCopyError{}, src, err)
+	}
+	defer in.Close()
+
+	out, err := afs.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, mode)
+	if err != nil {
+		return store_err.NewStoreError(&store_err.FailedToCopyError{}, dst, err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return store_err.NewStoreError(&store_err.FailedToCopyError{}, dst, err)
+	}
+
+	if err := afs.Chmod(dst, mode); err != nil {
+		return store_err.NewStoreError(&store_err.FailedToCopyError{}, dst, err)
+	}
+
+	return nil
+}
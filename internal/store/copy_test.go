@@ -0,0 +1,74 @@
+package store_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+func Test_Copy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		setupFs func() afero.Fs
+		wantErr store_err.StoreErrorIF
+		verify  func(t *testing.T, afs afero.Fs)
+	}{
+		{
+			name: "[正常系] 内容がコピーされ書き込み権限が付与される",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				fs.MkdirAll("/src/v10/files/ab", 0o555)
+				afero.WriteFile(fs, "/src/v10/files/ab/cdef", []byte("content"), 0o444)
+				afero.WriteFile(fs, "/src/v10/files/ab/0123-exec", []byte("#!"), 0o555)
+				return fs
+			},
+			verify: func(t *testing.T, afs afero.Fs) {
+				t.Helper()
+				verifyFileContent(t, afs, "/dst/v10/files/ab/cdef", "content")
+				verifyPermissions(t, afs, []permCheck{
+					{path: "/dst/v10/files/ab", wantPerm: 0o755},
+					{path: "/dst/v10/files/ab/cdef", wantPerm: 0o644},
+					{path: "/dst/v10/files/ab/0123-exec", wantPerm: 0o755},
+				})
+			},
+		},
+		{
+			name: "[異常系] コピー先が既に存在する",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				fs.MkdirAll("/src/v10", 0o755)
+				fs.MkdirAll("/dst", 0o755)
+				return fs
+			},
+			wantErr: &store_err.FailedToCopyError{},
+		},
+		{
+			name:    "[異常系] コピー元が存在しない",
+			setupFs: afero.NewMemMapFs,
+			wantErr: &store_err.FailedToCopyError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			afs := tt.setupFs()
+
+			gotErr := store.Copy(afs, "/src", "/dst")
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("Copy() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+
+			if tt.verify != nil {
+				tt.verify(t, afs)
+			}
+		})
+	}
+}
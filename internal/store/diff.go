@@ -0,0 +1,240 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nix-community/go-nix/pkg/nar"
+)
+
+// DiffKind classifies a difference between two store trees.
+type DiffKind string
+
+const (
+	DiffAdded    DiffKind = "added"
+	DiffRemoved  DiffKind = "removed"
+	DiffModified DiffKind = "modified"
+)
+
+// DiffEntry is a single path that differs between two store trees.
+type DiffEntry struct {
+	Kind        DiffKind
+	Path        string
+	Old         *TreeEntry // nil for added paths
+	New         *TreeEntry // nil for removed paths
+	Changes     []string   // attribute changes of modified paths (type, mode, size, digest, target)
+	JSONChanges []string   // semantic changes of modified JSON files
+}
+
+// Diff compares two store trees and returns the differing paths sorted by path.
+func Diff(oldEntries, newEntries []TreeEntry) []DiffEntry {
+	oldByPath := make(map[string]*TreeEntry, len(oldEntries))
+	for i := range oldEntries {
+		oldByPath[oldEntries[i].Path] = &oldEntries[i]
+	}
+
+	newByPath := make(map[string]*TreeEntry, len(newEntries))
+	for i := range newEntries {
+		newByPath[newEntries[i].Path] = &newEntries[i]
+	}
+
+	var diffs []DiffEntry
+
+	for path, o := range oldByPath {
+		n, ok := newByPath[path]
+		if !ok {
+			diffs = append(diffs, DiffEntry{Kind: DiffRemoved, Path: path, Old: o})
+			continue
+		}
+
+		changes := compareEntries(o, n)
+		if len(changes) == 0 {
+			continue
+		}
+
+		d := DiffEntry{Kind: DiffModified, Path: path, Old: o, New: n, Changes: changes}
+		if o.Content != nil && n.Content != nil {
+			d.JSONChanges = DiffJSON(o.Content, n.Content)
+		}
+
+		diffs = append(diffs, d)
+	}
+
+	for path, n := range newByPath {
+		if _, ok := oldByPath[path]; !ok {
+			diffs = append(diffs, DiffEntry{Kind: DiffAdded, Path: path, New: n})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+
+	return diffs
+}
+
+// compareEntries describes the attribute changes between two entries of the same path.
+func compareEntries(o, n *TreeEntry) []string {
+	if o.Type != n.Type {
+		return []string{fmt.Sprintf("type: %s -> %s", o.Type, n.Type)}
+	}
+
+	var changes []string
+
+	switch o.Type {
+	case nar.TypeRegular:
+		if o.Executable != n.Executable {
+			changes = append(changes, fmt.Sprintf("mode: %s -> %s", modeString(o), modeString(n)))
+		}
+
+		if o.Size != n.Size {
+			changes = append(changes, fmt.Sprintf("size: %d -> %d", o.Size, n.Size))
+		}
+
+		if o.SHA256 != n.SHA256 {
+			changes = append(changes, fmt.Sprintf("sha256: %s -> %s", o.SHA256, n.SHA256))
+		}
+	case nar.TypeSymlink:
+		if o.Target != n.Target {
+			changes = append(changes, fmt.Sprintf("target: %s -> %s", o.Target, n.Target))
+		}
+	case nar.TypeDirectory:
+	}
+
+	return changes
+}
+
+// modeString describes the mode of a regular file as far as it is preserved by NAR.
+func modeString(e *TreeEntry) string {
+	if e.Executable {
+		return "executable"
+	}
+
+	return "regular"
+}
+
+// DiffJSON returns the semantic differences between two JSON documents, one line per
+// changed value, addressed with jq-style paths. Formatting and key order are ignored.
+// If either document is not valid JSON, nil is returned.
+func DiffJSON(oldData, newData []byte) []string {
+	oldValue, oldErr := decodeJSON(oldData)
+	newValue, newErr := decodeJSON(newData)
+
+	if oldErr != nil || newErr != nil {
+		return nil
+	}
+
+	var changes []string
+	diffJSONValue("", oldValue, newValue, &changes)
+
+	return changes
+}
+
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// diffJSONValue appends the changes between o and n found below path to changes.
+func diffJSONValue(path string, o, n any, changes *[]string) {
+	switch ov := o.(type) {
+	case map[string]any:
+		nv, ok := n.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(ov)+len(nv))
+		for k := range ov {
+			keys = append(keys, k)
+		}
+
+		for k := range nv {
+			if _, exists := ov[k]; !exists {
+				keys = append(keys, k)
+			}
+		}
+
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			childPath := path + jsonKeyPath(k)
+			oc, inOld := ov[k]
+			nc, inNew := nv[k]
+
+			switch {
+			case !inNew:
+				*changes = append(*changes, fmt.Sprintf("%s: removed (was %s)", childPath, jsonString(oc)))
+			case !inOld:
+				*changes = append(*changes, fmt.Sprintf("%s: added %s", childPath, jsonString(nc)))
+			default:
+				diffJSONValue(childPath, oc, nc, changes)
+			}
+		}
+
+		return
+	case []any:
+		nv, ok := n.([]any)
+		if !ok {
+			break
+		}
+
+		for i := range max(len(ov), len(nv)) {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+
+			switch {
+			case i >= len(nv):
+				*changes = append(*changes, fmt.Sprintf("%s: removed (was %s)", childPath, jsonString(ov[i])))
+			case i >= len(ov):
+				*changes = append(*changes, fmt.Sprintf("%s: added %s", childPath, jsonString(nv[i])))
+			default:
+				diffJSONValue(childPath, ov[i], nv[i], changes)
+			}
+		}
+
+		return
+	}
+
+	if oldStr, newStr := jsonString(o), jsonString(n); oldStr != newStr {
+		if path == "" {
+			path = "."
+		}
+
+		*changes = append(*changes, fmt.Sprintf("%s: %s -> %s", path, oldStr, newStr))
+	}
+}
+
+var jsonIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// jsonKeyPath formats an object key as a jq path segment.
+func jsonKeyPath(key string) string {
+	if jsonIdentifier.MatchString(key) {
+		return "." + key
+	}
+
+	return "[" + jsonString(key) + "]"
+}
+
+// jsonString formats a decoded JSON value compactly.
+func jsonString(v any) string {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,135 @@
+package store_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nix-community/go-nix/pkg/nar"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+func Test_Diff(t *testing.T) {
+	t.Parallel()
+
+	base := []store.TreeEntry{
+		{Path: "", Type: nar.TypeDirectory},
+		{Path: "a", Type: nar.TypeRegular, Size: 1, SHA256: "aa"},
+		{Path: "b", Type: nar.TypeRegular, Size: 1, SHA256: "bb"},
+		{Path: "l", Type: nar.TypeSymlink, Target: "a"},
+	}
+
+	tests := []struct {
+		name       string
+		newEntries []store.TreeEntry
+		want       []store.DiffEntry
+	}{
+		{
+			name:       "[正常系] 差分がない",
+			newEntries: base,
+			want:       nil,
+		},
+		{
+			name: "[正常系] 追加・削除・変更が検出される",
+			newEntries: []store.TreeEntry{
+				{Path: "", Type: nar.TypeDirectory},
+				{Path: "a", Type: nar.TypeRegular, Size: 2, SHA256: "cc", Executable: true},
+				{Path: "c", Type: nar.TypeRegular, Size: 1, SHA256: "bb"},
+				{Path: "l", Type: nar.TypeSymlink, Target: "c"},
+			},
+			want: []store.DiffEntry{
+				{
+					Kind: store.DiffModified,
+					Path: "a",
+					Changes: []string{
+						"mode: regular -> executable",
+						"size: 1 -> 2",
+						"sha256: aa -> cc",
+					},
+				},
+				{Kind: store.DiffRemoved, Path: "b"},
+				{Kind: store.DiffAdded, Path: "c"},
+				{Kind: store.DiffModified, Path: "l", Changes: []string{"target: a -> c"}},
+			},
+		},
+		{
+			name: "[正常系] 種類が変わった場合は種類のみ報告される",
+			newEntries: []store.TreeEntry{
+				{Path: "", Type: nar.TypeDirectory},
+				{Path: "a", Type: nar.TypeDirectory},
+				{Path: "b", Type: nar.TypeRegular, Size: 1, SHA256: "bb"},
+				{Path: "l", Type: nar.TypeSymlink, Target: "a"},
+			},
+			want: []store.DiffEntry{
+				{Kind: store.DiffModified, Path: "a", Changes: []string{"type: regular -> directory"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := store.Diff(base, tt.newEntries)
+			for i := range got {
+				got[i].Old, got[i].New = nil, nil
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_DiffJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		oldData string
+		newData string
+		want    []string
+	}{
+		{
+			name:    "[正常系] キー順と整形の違いは無視される",
+			oldData: `{"a":1,"b":[1,2]}`,
+			newData: "{\n  \"b\": [1, 2],\n  \"a\": 1\n}",
+			want:    nil,
+		},
+		{
+			name:    "[正常系] 値の変更・追加・削除がjqパスで報告される",
+			oldData: `{"files":{"package.json":{"size":1}},"name":"a","tags":["x"]}`,
+			newData: `{"files":{"package.json":{"size":2}},"version":"1.0.0","tags":["x","y"]}`,
+			want: []string{
+				`.files["package.json"].size: 1 -> 2`,
+				`.name: removed (was "a")`,
+				`.tags[1]: added "y"`,
+				`.version: added "1.0.0"`,
+			},
+		},
+		{
+			name:    "[正常系] 型の変更は値の変更として報告される",
+			oldData: `{"a":{"b":1}}`,
+			newData: `{"a":[1]}`,
+			want:    []string{`.a: {"b":1} -> [1]`},
+		},
+		{
+			name:    "[異常系] 不正なJSONはnilを返す",
+			oldData: `{`,
+			newData: `{}`,
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := store.DiffJSON([]byte(tt.oldData), []byte(tt.newData))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffJSON() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package store_err
 
-import "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
 
-type FailedToCleanupError struct{ common.BaseError }
+type FailedToCleanupError struct{ baseError }
 
 var _ StoreErrorIF = (*FailedToCleanupError)(nil)
 
@@ -19,6 +21,10 @@ func (e *FailedToCleanupError) Error() string {
 	return errMsg
 }
 
+func (e *FailedToCleanupError) Code() errcode.Code {
+	return errcode.StoreCleanupFailed
+}
+
 func (e *FailedToCleanupError) Is(target error) bool {
 	_, ok := target.(*FailedToCleanupError)
 	return ok
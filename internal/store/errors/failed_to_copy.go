@@ -0,0 +1,39 @@
+package store_err
+
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type FailedToCopyError struct{ baseError }
+
+var _ StoreErrorIF = (*FailedToCopyError)(nil)
+
+func (e *FailedToCopyError) Error() string {
+	errMsg := "failed to copy store"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = errMsg + "\ncaused by: " + e.Cause.Error()
+	}
+	return errMsg
+}
+
+func (e *FailedToCopyError) Code() errcode.Code {
+	return errcode.StoreCopyFailed
+}
+
+func (e *FailedToCopyError) Is(target error) bool {
+	_, ok := target.(*FailedToCopyError)
+	return ok
+}
+
+func (e *FailedToCopyError) As(target any) bool {
+	if t, ok := target.(**FailedToCopyError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
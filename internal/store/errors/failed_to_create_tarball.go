@@ -1,8 +1,10 @@
 package store_err
 
-import "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
 
-type FailedToCreateTarballError struct{ common.BaseError }
+type FailedToCreateTarballError struct{ baseError }
 
 var _ StoreErrorIF = (*FailedToCreateTarballError)(nil)
 
@@ -19,6 +21,10 @@ func (e *FailedToCreateTarballError) Error() string {
 	return errMsg
 }
 
+func (e *FailedToCreateTarballError) Code() errcode.Code {
+	return errcode.TarballCreateFailed
+}
+
 func (e *FailedToCreateTarballError) Is(target error) bool {
 	_, ok := target.(*FailedToCreateTarballError)
 	return ok
@@ -1,8 +1,10 @@
 package store_err
 
-import "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
 
-type FailedToHashError struct{ common.BaseError }
+type FailedToHashError struct{ baseError }
 
 var _ StoreErrorIF = (*FailedToHashError)(nil)
 
@@ -19,6 +21,10 @@ func (e *FailedToHashError) Error() string {
 	return errMsg
 }
 
+func (e *FailedToHashError) Code() errcode.Code {
+	return errcode.StoreHashFailed
+}
+
 func (e *FailedToHashError) Is(target error) bool {
 	_, ok := target.(*FailedToHashError)
 	return ok
@@ -1,8 +1,10 @@
 package store_err
 
-import "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
 
-type FailedToNormalizeJSONError struct{ common.BaseError }
+type FailedToNormalizeJSONError struct{ baseError }
 
 var _ StoreErrorIF = (*FailedToNormalizeJSONError)(nil)
 
@@ -19,6 +21,10 @@ func (e *FailedToNormalizeJSONError) Error() string {
 	return errMsg
 }
 
+func (e *FailedToNormalizeJSONError) Code() errcode.Code {
+	return errcode.NormalizeJSONFailed
+}
+
 func (e *FailedToNormalizeJSONError) Is(target error) bool {
 	_, ok := target.(*FailedToNormalizeJSONError)
 	return ok
@@ -0,0 +1,39 @@
+package store_err
+
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type FailedToReadStoreError struct{ baseError }
+
+var _ StoreErrorIF = (*FailedToReadStoreError)(nil)
+
+func (e *FailedToReadStoreError) Error() string {
+	errMsg := "failed to read store"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = errMsg + "\ncaused by: " + e.Cause.Error()
+	}
+	return errMsg
+}
+
+func (e *FailedToReadStoreError) Code() errcode.Code {
+	return errcode.StoreReadFailed
+}
+
+func (e *FailedToReadStoreError) Is(target error) bool {
+	_, ok := target.(*FailedToReadStoreError)
+	return ok
+}
+
+func (e *FailedToReadStoreError) As(target any) bool {
+	if t, ok := target.(**FailedToReadStoreError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package store_err
+
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type FailedToReadTarballError struct{ baseError }
+
+var _ StoreErrorIF = (*FailedToReadTarballError)(nil)
+
+func (e *FailedToReadTarballError) Error() string {
+	errMsg := "failed to read tarball"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = errMsg + "\ncaused by: " + e.Cause.Error()
+	}
+	return errMsg
+}
+
+func (e *FailedToReadTarballError) Code() errcode.Code {
+	return errcode.TarballReadFailed
+}
+
+func (e *FailedToReadTarballError) Is(target error) bool {
+	_, ok := target.(*FailedToReadTarballError)
+	return ok
+}
+
+func (e *FailedToReadTarballError) As(target any) bool {
+	if t, ok := target.(**FailedToReadTarballError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -1,8 +1,10 @@
 package store_err
 
-import "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
 
-type FailedToSetPermissionsError struct{ common.BaseError }
+type FailedToSetPermissionsError struct{ baseError }
 
 var _ StoreErrorIF = (*FailedToSetPermissionsError)(nil)
 
@@ -19,6 +21,10 @@ func (e *FailedToSetPermissionsError) Error() string {
 	return errMsg
 }
 
+func (e *FailedToSetPermissionsError) Code() errcode.Code {
+	return errcode.SetPermissionsFailed
+}
+
 func (e *FailedToSetPermissionsError) Is(target error) bool {
 	_, ok := target.(*FailedToSetPermissionsError)
 	return ok
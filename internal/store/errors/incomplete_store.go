@@ -0,0 +1,39 @@
+package store_err
+
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type IncompleteStoreError struct{ baseError }
+
+var _ StoreErrorIF = (*IncompleteStoreError)(nil)
+
+func (e *IncompleteStoreError) Error() string {
+	errMsg := "pnpm store is incomplete"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = errMsg + "\ncaused by: " + e.Cause.Error()
+	}
+	return errMsg
+}
+
+func (e *IncompleteStoreError) Code() errcode.Code {
+	return errcode.IncompleteStore
+}
+
+func (e *IncompleteStoreError) Is(target error) bool {
+	_, ok := target.(*IncompleteStoreError)
+	return ok
+}
+
+func (e *IncompleteStoreError) As(target any) bool {
+	if t, ok := target.(**IncompleteStoreError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
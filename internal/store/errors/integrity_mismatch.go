@@ -0,0 +1,39 @@
+package store_err
+
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type IntegrityMismatchError struct{ baseError }
+
+var _ StoreErrorIF = (*IntegrityMismatchError)(nil)
+
+func (e *IntegrityMismatchError) Error() string {
+	errMsg := "store integrity verification failed"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = errMsg + "\ncaused by: " + e.Cause.Error()
+	}
+	return errMsg
+}
+
+func (e *IntegrityMismatchError) Code() errcode.Code {
+	return errcode.IntegrityMismatch
+}
+
+func (e *IntegrityMismatchError) Is(target error) bool {
+	_, ok := target.(*IntegrityMismatchError)
+	return ok
+}
+
+func (e *IntegrityMismatchError) As(target any) bool {
+	if t, ok := target.(**IntegrityMismatchError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package store_err
+
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type InvalidTarballError struct{ baseError }
+
+var _ StoreErrorIF = (*InvalidTarballError)(nil)
+
+func (e *InvalidTarballError) Error() string {
+	errMsg := "invalid tarball"
+
+	if e.Message != "" {
+		errMsg = e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = errMsg + "\ncaused by: " + e.Cause.Error()
+	}
+	return errMsg
+}
+
+func (e *InvalidTarballError) Code() errcode.Code {
+	return errcode.InvalidTarball
+}
+
+func (e *InvalidTarballError) Is(target error) bool {
+	_, ok := target.(*InvalidTarballError)
+	return ok
+}
+
+func (e *InvalidTarballError) As(target any) bool {
+	if t, ok := target.(**InvalidTarballError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
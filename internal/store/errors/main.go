@@ -1,6 +1,10 @@
 package store_err
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/common"
+)
 
 type StoreErrorIF interface {
 	error
@@ -10,6 +14,10 @@ type StoreErrorIF interface {
 
 	SetMessage(string)
 	SetCause(error)
+
+	// storeError marks the errors of this package, so that errors.As and type switches
+	// can tell them apart from the errors of other packages.
+	storeError()
 }
 
 func NewStoreError(e StoreErrorIF, message string, cause error) StoreErrorIF {
@@ -22,3 +30,8 @@ func NewStoreError(e StoreErrorIF, message string, cause error) StoreErrorIF {
 func AsStoreError(err error, target *StoreErrorIF) bool {
 	return errors.As(err, target)
 }
+
+// baseError is embedded in every error type of this package to implement StoreErrorIF.
+type baseError struct{ common.BaseError }
+
+func (baseError) storeError() {}
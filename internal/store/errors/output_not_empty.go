@@ -0,0 +1,39 @@
+package store_err
+
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type OutputNotEmptyError struct{ baseError }
+
+var _ StoreErrorIF = (*OutputNotEmptyError)(nil)
+
+func (e *OutputNotEmptyError) Error() string {
+	errMsg := "output directory is not empty"
+
+	if e.Message != "" {
+		errMsg = errMsg + ": " + e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = errMsg + "\ncaused by: " + e.Cause.Error()
+	}
+	return errMsg
+}
+
+func (e *OutputNotEmptyError) Code() errcode.Code {
+	return errcode.OutputNotEmpty
+}
+
+func (e *OutputNotEmptyError) Is(target error) bool {
+	_, ok := target.(*OutputNotEmptyError)
+	return ok
+}
+
+func (e *OutputNotEmptyError) As(target any) bool {
+	if t, ok := target.(**OutputNotEmptyError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package store_err
+
+import (
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/errcode"
+)
+
+type UnsupportedStoreLayoutError struct{ baseError }
+
+var _ StoreErrorIF = (*UnsupportedStoreLayoutError)(nil)
+
+func (e *UnsupportedStoreLayoutError) Error() string {
+	errMsg := "unsupported store layout"
+
+	if e.Message != "" {
+		errMsg = errMsg + " " + e.Message
+	}
+
+	if e.Cause != nil {
+		errMsg = errMsg + "\ncaused by: " + e.Cause.Error()
+	}
+	return errMsg
+}
+
+func (e *UnsupportedStoreLayoutError) Code() errcode.Code {
+	return errcode.UnsupportedStoreLayout
+}
+
+func (e *UnsupportedStoreLayoutError) Is(target error) bool {
+	_, ok := target.(*UnsupportedStoreLayoutError)
+	return ok
+}
+
+func (e *UnsupportedStoreLayoutError) As(target any) bool {
+	if t, ok := target.(**UnsupportedStoreLayoutError); ok {
+		*t = e
+		return true
+	}
+	return false
+}
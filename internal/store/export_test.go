@@ -0,0 +1,82 @@
+package store
+
+import (
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// Tar entry types for WriteRawTarball.
+const (
+	TarTypeFile    = tarTypeFile
+	TarTypeDir     = tarTypeDir
+	TarTypeSymlink = tarTypeSymlink
+)
+
+// WriteZstdFile writes data zstd-compressed to path, to craft tarballs from raw blocks.
+func WriteZstdFile(afs afero.Fs, path string, data []byte) error {
+	f, err := afs.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw, err := newZstdWriter(f)
+	if err != nil {
+		return err
+	}
+
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// RawTarEntry is an entry written verbatim by WriteRawTarball.
+type RawTarEntry struct {
+	Name     string
+	Typeflag byte
+	Linkname string
+	Data     string
+}
+
+// WriteRawTarball writes entries as a zstd-compressed tarball to tarballPath without
+// any of the checks and normalization of CreateTarball, to craft malicious tarballs.
+func WriteRawTarball(afs afero.Fs, tarballPath string, entries []RawTarEntry) error {
+	f, err := afs.Create(tarballPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw, err := newZstdWriter(f)
+	if err != nil {
+		return err
+	}
+
+	tw := newGNUTarWriter(zw)
+	for _, e := range entries {
+		mode, size := int64(0o644), int64(len(e.Data))
+		if e.Typeflag != tarTypeFile {
+			mode, size = 0o755, 0
+		}
+
+		if err := tw.writeEntry(e.Name, tarEntryInfo{
+			mode:       mode,
+			size:       size,
+			mtime:      sourceDateEpoch,
+			typeflag:   e.Typeflag,
+			linkname:   e.Linkname,
+			dataReader: strings.NewReader(e.Data),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.close(); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
@@ -3,6 +3,8 @@ package store
 import (
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"hash"
 	"io"
 	"io/fs"
 	"path/filepath"
@@ -13,10 +15,51 @@ import (
 	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
 )
 
+// HashOptions are the options of HashTree.
+type HashOptions struct {
+	Manifest bool         // also build the manifest of the hashed tree
+	Progress ProgressFunc // receives the file and byte progress if not nil
+}
+
 // Hash computes the NAR hash of the store directory and returns it in SRI format (sha256-<base64>).
 // The store must be normalized before hashing to produce a reproducible result.
 // This is equivalent to running "nix hash path --type sha256" on the store directory.
 func Hash(afs afero.Fs, storePath string) (string, store_err.StoreErrorIF) {
+	narHash, _, err := HashTree(afs, storePath, HashOptions{})
+	return narHash, err
+}
+
+// HashTree computes the NAR hash like Hash. The manifest of the hashed tree is built during
+// the same walk if opts.Manifest is set, and is nil otherwise.
+func HashTree(afs afero.Fs, storePath string, opts HashOptions) (string, *Manifest, store_err.StoreErrorIF) {
+	var (
+		manifest *Manifest
+		node     *ManifestNode
+	)
+
+	if opts.Manifest {
+		manifest = &Manifest{Version: manifestVersion}
+		node = &manifest.Root
+	}
+
+	tracker := startPhase(afs, storePath, opts.Progress, PhaseHash, nil, true)
+
+	narHash, err := hashTree(afs, storePath, node, tracker)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return narHash, manifest, nil
+}
+
+// hashTree computes the NAR hash of storePath. If node is not nil, it is filled with
+// the manifest of the tree.
+func hashTree(
+	afs afero.Fs,
+	storePath string,
+	node *ManifestNode,
+	tracker *progressTracker,
+) (string, store_err.StoreErrorIF) {
 	h := sha256.New()
 
 	nw, err := nar.NewWriter(h)
@@ -28,7 +71,7 @@ func Hash(afs afero.Fs, storePath string) (string, store_err.StoreErrorIF) {
 		)
 	}
 
-	if hashErr := writeNarEntry(afs, nw, storePath, "/"); hashErr != nil {
+	if hashErr := writeNarEntry(afs, nw, storePath, "/", node, tracker); hashErr != nil {
 		return "", hashErr
 	}
 
@@ -46,14 +89,17 @@ func Hash(afs afero.Fs, storePath string) (string, store_err.StoreErrorIF) {
 	return sriHash, nil
 }
 
-// writeNarEntry writes a single filesystem entry (file or directory) to the NAR writer.
+// writeNarEntry writes a single filesystem entry (file, directory or symlink) to the NAR writer.
 // For directories, it reads entries sorted by name (afero.ReadDir returns sorted results)
 // and recursively writes each child, matching the NAR spec's requirement for lexicographic order.
+// If node is not nil, it is filled with the manifest of the entry.
 func writeNarEntry(
 	afs afero.Fs,
 	nw *nar.Writer,
 	fsPath string,
 	narPath string,
+	node *ManifestNode,
+	tracker *progressTracker,
 ) store_err.StoreErrorIF {
 	info, err := afs.Stat(fsPath)
 	if err != nil {
@@ -65,18 +111,22 @@ func writeNarEntry(
 	}
 
 	if info.IsDir() {
-		return writeNarDir(afs, nw, fsPath, narPath)
+		return writeNarDir(afs, nw, fsPath, narPath, node, tracker)
 	}
 
-	return writeNarFile(afs, nw, fsPath, narPath, info)
+	return writeNarFile(afs, nw, fsPath, narPath, info, node, tracker)
 }
 
 // writeNarDir writes a directory and all its children to the NAR writer.
+//
+//nolint:cyclop // one branch per entry type
 func writeNarDir(
 	afs afero.Fs,
 	nw *nar.Writer,
 	fsPath string,
 	narPath string,
+	node *ManifestNode,
+	tracker *progressTracker,
 ) store_err.StoreErrorIF {
 	if err := nw.WriteHeader(&nar.Header{
 		Path: narPath,
@@ -99,17 +149,33 @@ func writeNarDir(
 		)
 	}
 
+	if node != nil {
+		node.Type = nar.TypeDirectory
+		node.Entries = make(map[string]*ManifestNode, len(entries))
+	}
+
 	for _, entry := range entries {
 		childFsPath := filepath.Join(fsPath, entry.Name())
 
 		childNarPath := narPath + "/" + entry.Name()
 
-		if entry.IsDir() {
-			if dirErr := writeNarDir(afs, nw, childFsPath, childNarPath); dirErr != nil {
+		var child *ManifestNode
+		if node != nil {
+			child = &ManifestNode{}
+			node.Entries[entry.Name()] = child
+		}
+
+		switch {
+		case entry.IsDir():
+			if dirErr := writeNarDir(afs, nw, childFsPath, childNarPath, child, tracker); dirErr != nil {
 				return dirErr
 			}
-		} else {
-			if fileErr := writeNarFile(afs, nw, childFsPath, childNarPath, entry); fileErr != nil {
+		case entry.Mode()&fs.ModeSymlink != 0:
+			if linkErr := writeNarSymlink(afs, nw, childFsPath, childNarPath, child, tracker); linkErr != nil {
+				return linkErr
+			}
+		default:
+			if fileErr := writeNarFile(afs, nw, childFsPath, childNarPath, entry, child, tracker); fileErr != nil {
 				return fileErr
 			}
 		}
@@ -125,13 +191,16 @@ func writeNarFile(
 	fsPath string,
 	narPath string,
 	info fs.FileInfo,
+	node *ManifestNode,
+	tracker *progressTracker,
 ) store_err.StoreErrorIF {
-	if err := nw.WriteHeader(&nar.Header{
+	header := &nar.Header{
 		Path:       narPath,
 		Type:       nar.TypeRegular,
 		Size:       info.Size(),
 		Executable: info.Mode()&0o111 != 0,
-	}); err != nil {
+	}
+	if err := nw.WriteHeader(header); err != nil {
 		return store_err.NewStoreError(
 			&store_err.FailedToHashError{},
 			fsPath,
@@ -149,7 +218,15 @@ func writeNarFile(
 	}
 	defer f.Close()
 
-	if _, copyErr := io.Copy(nw, f); copyErr != nil {
+	var w io.Writer = nw
+
+	var fileHash hash.Hash
+	if node != nil {
+		fileHash = sha256.New()
+		w = io.MultiWriter(nw, fileHash)
+	}
+
+	if _, copyErr := io.Copy(w, f); copyErr != nil {
 		return store_err.NewStoreError(
 			&store_err.FailedToHashError{},
 			fsPath,
@@ -157,5 +234,55 @@ func writeNarFile(
 		)
 	}
 
+	if node != nil {
+		size := header.Size
+		node.Type = nar.TypeRegular
+		node.Size = &size
+		node.Executable = header.Executable
+		node.SHA256 = hex.EncodeToString(fileHash.Sum(nil))
+	}
+
+	tracker.add(header.Size)
+
+	return nil
+}
+
+// writeNarSymlink writes a symlink to the NAR writer without following it.
+func writeNarSymlink(
+	afs afero.Fs,
+	nw *nar.Writer,
+	fsPath string,
+	narPath string,
+	node *ManifestNode,
+	tracker *progressTracker,
+) store_err.StoreErrorIF {
+	target, err := readSymlinkTarget(afs, fsPath)
+	if err != nil {
+		return store_err.NewStoreError(
+			&store_err.FailedToHashError{},
+			fsPath,
+			err,
+		)
+	}
+
+	if err := nw.WriteHeader(&nar.Header{
+		Path:       narPath,
+		Type:       nar.TypeSymlink,
+		LinkTarget: target,
+	}); err != nil {
+		return store_err.NewStoreError(
+			&store_err.FailedToHashError{},
+			fsPath,
+			err,
+		)
+	}
+
+	if node != nil {
+		node.Type = nar.TypeSymlink
+		node.Target = target
+	}
+
+	tracker.add(0)
+
 	return nil
 }
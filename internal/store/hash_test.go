@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/nix-community/go-nix/pkg/nar"
 	"github.com/spf13/afero"
 
 	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
@@ -169,3 +170,80 @@ func Test_Hash(t *testing.T) {
 		})
 	}
 }
+
+func Test_HashTree(t *testing.T) {
+	t.Parallel()
+
+	size := func(n int64) *int64 { return &n }
+
+	tests := []struct {
+		name    string
+		setupFs func() afero.Fs
+		want    *store.Manifest
+		wantErr store_err.StoreErrorIF
+	}{
+		{
+			name: "[正常系] 全てのパスが種類・サイズ・実行可能フラグ・sha256付きで列挙される",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				fs.MkdirAll("/store/sub", 0o555)
+				afero.WriteFile(fs, "/store/a.txt", []byte("hello"), 0o444)
+				afero.WriteFile(fs, "/store/sub/run", []byte(""), 0o555)
+				return fs
+			},
+			want: &store.Manifest{
+				Version: 1,
+				Root: store.ManifestNode{
+					Type: nar.TypeDirectory,
+					Entries: map[string]*store.ManifestNode{
+						"a.txt": {
+							Type:   nar.TypeRegular,
+							Size:   size(5),
+							SHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+						},
+						"sub": {
+							Type: nar.TypeDirectory,
+							Entries: map[string]*store.ManifestNode{
+								"run": {
+									Type:       nar.TypeRegular,
+									Size:       size(0),
+									Executable: true,
+									SHA256:     "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "[異常系] 存在しないパス",
+			setupFs: afero.NewMemMapFs,
+			wantErr: &store_err.FailedToHashError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			afs := tt.setupFs()
+			gotHash, got, gotErr := store.HashTree(afs, "/store", store.HashOptions{Manifest: true})
+
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("HashTree() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HashTree() manifest = %+v, want %+v", got, tt.want)
+			}
+
+			if tt.wantErr == nil {
+				wantHash, _ := store.Hash(afs, "/store")
+				if gotHash != wantHash {
+					t.Errorf("HashTree() hash = %q, want %q", gotHash, wantHash)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+// PackageIndexFile is the part of a pnpm store index file that describes the
+// content files of a package.
+type PackageIndexFile struct {
+	Name    string                       `json:"name"`
+	Version string                       `json:"version"`
+	Files   map[string]PackageIndexEntry `json:"files"`
+}
+
+// PackageIndexEntry describes a single file of a package in a store index file.
+type PackageIndexEntry struct {
+	Integrity string `json:"integrity"`
+	Mode      uint32 `json:"mode"`
+	Size      int64  `json:"size"`
+}
+
+var storeVersionDirPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// IsIndexFile reports whether p, relative to the store root, is a package index file.
+// Store v10 keeps them in vN/index, store v3 next to the content as "*-index.json".
+func IsIndexFile(p string) bool {
+	parts := strings.SplitN(p, "/", 3) //nolint:mnd // version dir, kind, rest
+	if len(parts) != 3 || !storeVersionDirPattern.MatchString(parts[0]) {
+		return false
+	}
+
+	switch parts[1] {
+	case "index":
+		return strings.HasSuffix(p, ".json")
+	case "files":
+		return strings.HasSuffix(p, "-index.json")
+	default:
+		return false
+	}
+}
+
+// ContentPath returns the path of the content file described by entry, relative to
+// the store root. versionDir is the store version directory holding the index file.
+func ContentPath(versionDir string, entry PackageIndexEntry) (string, error) {
+	algo, digest, ok := strings.Cut(entry.Integrity, "-")
+	if !ok || algo != "sha512" {
+		return "", fmt.Errorf("unsupported integrity %q", entry.Integrity)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(digest)
+	if err != nil {
+		return "", fmt.Errorf("invalid integrity %q: %w", entry.Integrity, err)
+	}
+
+	h := hex.EncodeToString(raw)
+	p := path.Join(versionDir, "files", h[:2], h[2:])
+
+	if entry.Mode&0o111 != 0 {
+		p += "-exec"
+	}
+
+	return p, nil
+}
+
+// PackageOwners maps the index and content files of a store tree to the package
+// (name@version) that owns them, using the store index files in entries.
+func PackageOwners(entries []TreeEntry) map[string]string {
+	owners := make(map[string]string)
+
+	for _, e := range entries {
+		if e.Content == nil || !IsIndexFile(e.Path) {
+			continue
+		}
+
+		var index PackageIndexFile
+		if err := json.Unmarshal(e.Content, &index); err != nil {
+			continue
+		}
+
+		pkg := packageID(e.Path, &index)
+		owners[e.Path] = pkg
+
+		versionDir, _, _ := strings.Cut(e.Path, "/")
+		for _, f := range index.Files {
+			if p, err := ContentPath(versionDir, f); err == nil {
+				owners[p] = pkg
+			}
+		}
+	}
+
+	return owners
+}
+
+// packageID returns name@version for an index file. Older index files do not record
+// the package, so the name is taken from the v10 file name ("<hash>-<name>@<version>.json",
+// with "/" of scoped names replaced by "+") or, failing that, the index file path is used.
+func packageID(indexPath string, index *PackageIndexFile) string {
+	if index.Name != "" && index.Version != "" {
+		return index.Name + "@" + index.Version
+	}
+
+	if strings.Contains(indexPath, "/index/") {
+		base := strings.TrimSuffix(path.Base(indexPath), ".json")
+		if _, name, ok := strings.Cut(base, "-"); ok && strings.Contains(name, "@") {
+			if strings.HasPrefix(name, "@") {
+				name = strings.Replace(name, "+", "/", 1)
+			}
+
+			return name
+		}
+	}
+
+	return indexPath
+}
+
+// walkIndexFiles calls fn with the path (relative to storePath, slash-separated) of
+// every package index file in the store version directories.
+func walkIndexFiles(afs afero.Fs, storePath string, fn func(indexPath string) error) store_err.StoreErrorIF {
+	versionDirs, err := detectStoreVersionDirs(afs, storePath)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range versionDirs {
+		versionPath := filepath.Join(storePath, dir)
+		walkErr := afero.Walk(afs, versionPath, func(p string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			relPath, relErr := filepath.Rel(storePath, p)
+			if relErr != nil {
+				return relErr
+			}
+
+			relPath = filepath.ToSlash(relPath)
+			if info.IsDir() || !IsIndexFile(relPath) {
+				return nil
+			}
+
+			return fn(relPath)
+		})
+		if walkErr != nil {
+			return store_err.NewStoreError(&store_err.FailedToReadStoreError{}, versionPath, walkErr)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package store_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nix-community/go-nix/pkg/nar"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+// integrityAB is a sha512 integrity whose hex digest starts with "ab".
+const integrityAB = "sha512-q80=" // hex: ab cd
+
+func Test_ContentPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		entry   store.PackageIndexEntry
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "[正常系] 通常ファイル",
+			entry: store.PackageIndexEntry{Integrity: integrityAB, Mode: 0o644},
+			want:  "v10/files/ab/cd",
+		},
+		{
+			name:  "[正常系] 実行可能ファイルには-execが付く",
+			entry: store.PackageIndexEntry{Integrity: integrityAB, Mode: 0o755},
+			want:  "v10/files/ab/cd-exec",
+		},
+		{
+			name:    "[異常系] sha512以外のintegrity",
+			entry:   store.PackageIndexEntry{Integrity: "sha1-q80="},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := store.ContentPath("v10", tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ContentPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("ContentPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_PackageOwners(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		entries []store.TreeEntry
+		want    map[string]string
+	}{
+		{
+			name: "[正常系] v10のindexファイルからname@versionを取得する",
+			entries: []store.TreeEntry{
+				{
+					Path:    "v10/index/12/3456-foo@1.0.0.json",
+					Type:    nar.TypeRegular,
+					Content: []byte(`{"name":"foo","version":"1.0.0","files":{"index.js":{"integrity":"` + integrityAB + `","mode":420}}}`),
+				},
+			},
+			want: map[string]string{
+				"v10/index/12/3456-foo@1.0.0.json": "foo@1.0.0",
+				"v10/files/ab/cd":                  "foo@1.0.0",
+			},
+		},
+		{
+			name: "[正常系] nameがない場合はファイル名からスコープ付きパッケージ名を復元する",
+			entries: []store.TreeEntry{
+				{
+					Path:    "v10/index/12/3456-@scope+bar@2.0.0.json",
+					Type:    nar.TypeRegular,
+					Content: []byte(`{"files":{"bin":{"integrity":"` + integrityAB + `","mode":493}}}`),
+				},
+			},
+			want: map[string]string{
+				"v10/index/12/3456-@scope+bar@2.0.0.json": "@scope/bar@2.0.0",
+				"v10/files/ab/cd-exec":                    "@scope/bar@2.0.0",
+			},
+		},
+		{
+			name: "[正常系] v3のindexファイル",
+			entries: []store.TreeEntry{
+				{
+					Path:    "v3/files/12/3456-index.json",
+					Type:    nar.TypeRegular,
+					Content: []byte(`{"name":"baz","version":"3.0.0","files":{"a":{"integrity":"` + integrityAB + `","mode":420}}}`),
+				},
+				{
+					Path:    "v3/files/ab/cd",
+					Type:    nar.TypeRegular,
+					Content: nil,
+				},
+			},
+			want: map[string]string{
+				"v3/files/12/3456-index.json": "baz@3.0.0",
+				"v3/files/ab/cd":              "baz@3.0.0",
+			},
+		},
+		{
+			name: "[異常系] 不正なindexファイルは無視される",
+			entries: []store.TreeEntry{
+				{Path: "v10/index/12/3456-foo@1.0.0.json", Type: nar.TypeRegular, Content: []byte(`{`)},
+			},
+			want: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := store.PackageOwners(tt.entries)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PackageOwners() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+// FetcherVersionFileName is the name of the file recording the fetcher version
+// in fetcher v2+ outputs.
+const FetcherVersionFileName = ".fetcher-version"
+
+// Layout is the kind of directory or file holding a pnpm store.
+type Layout int
+
+const (
+	LayoutUnknown  Layout = iota
+	LayoutRawStore        // pnpm store as written by pnpm install
+	LayoutOutputV1        // fetcher v1 output: normalized store without .fetcher-version
+	LayoutOutputV2        // fetcher v2 output: normalized store with .fetcher-version
+	LayoutOutputV3        // fetcher v3 output: .fetcher-version and pnpm-store.tar.zst
+	LayoutTarball         // a bare pnpm-store.tar.zst
+)
+
+func (l Layout) String() string {
+	switch l {
+	case LayoutRawStore:
+		return "raw pnpm store"
+	case LayoutOutputV1:
+		return "fetcher v1 output"
+	case LayoutOutputV2:
+		return "fetcher v2 output"
+	case LayoutOutputV3:
+		return "fetcher v3 output"
+	case LayoutTarball:
+		return "store tarball"
+	case LayoutUnknown:
+		return "unknown"
+	default:
+		return "unknown"
+	}
+}
+
+// DetectLayout determines what kind of pnpm store is found at path.
+//   - a file ending with .tar.zst is a store tarball
+//   - a directory with .fetcher-version "3" and a tarball is a fetcher v3 output
+//   - a store directory with .fetcher-version "2" is a fetcher v2 output
+//   - a store directory still containing tmp or projects directories, or with a
+//     writable root, is a raw pnpm store
+//   - any other store directory is a fetcher v1 output
+func DetectLayout(afs afero.Fs, path string) (Layout, store_err.StoreErrorIF) {
+	info, err := afs.Stat(path)
+	if err != nil {
+		return LayoutUnknown, store_err.NewStoreError(
+			&store_err.UnsupportedStoreLayoutError{},
+			"at "+path,
+			err,
+		)
+	}
+
+	if !info.IsDir() {
+		if strings.HasSuffix(path, ".tar.zst") {
+			return LayoutTarball, nil
+		}
+
+		return LayoutUnknown, store_err.NewStoreError(
+			&store_err.UnsupportedStoreLayoutError{},
+			"at "+path+": expected a directory or a .tar.zst file",
+			nil,
+		)
+	}
+
+	version := readFetcherVersion(afs, path)
+
+	if version == "3" {
+		if ok, _ := afero.Exists(afs, filepath.Join(path, TarballFileName)); ok {
+			return LayoutOutputV3, nil
+		}
+	}
+
+	if !hasStoreVersionDir(afs, path) {
+		return LayoutUnknown, store_err.NewStoreError(
+			&store_err.UnsupportedStoreLayoutError{},
+			"at "+path+": no pnpm store found",
+			nil,
+		)
+	}
+
+	if version == "2" {
+		return LayoutOutputV2, nil
+	}
+
+	if hasTemporaryDirs(afs, path) || info.Mode().Perm()&0o200 != 0 {
+		return LayoutRawStore, nil
+	}
+
+	return LayoutOutputV1, nil
+}
+
+// readFetcherVersion returns the trimmed content of .fetcher-version, or "" if absent.
+func readFetcherVersion(afs afero.Fs, path string) string {
+	data, err := afero.ReadFile(afs, filepath.Join(path, FetcherVersionFileName))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+// hasStoreVersionDir reports whether path contains a pnpm store version directory.
+func hasStoreVersionDir(afs afero.Fs, path string) bool {
+	dirs, _ := findStoreVersionDirs(afs, path)
+
+	return len(dirs) > 0
+}
+
+// hasTemporaryDirs reports whether path contains directories that Normalize removes.
+func hasTemporaryDirs(afs afero.Fs, path string) bool {
+	dirs, _ := findStoreVersionDirs(afs, path)
+	for _, dir := range dirs {
+		for _, name := range []string{"tmp", "projects"} {
+			if ok, _ := afero.IsDir(afs, filepath.Join(path, dir, name)); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
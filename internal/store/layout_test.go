@@ -0,0 +1,109 @@
+package store_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+func Test_DetectLayout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		setupFs func() afero.Fs
+		path    string
+		want    store.Layout
+		wantErr store_err.StoreErrorIF
+	}{
+		{
+			name: "[正常系] tmpディレクトリを含むストア",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				fs.MkdirAll("/in/v10/tmp", 0o755)
+				return fs
+			},
+			path: "/in",
+			want: store.LayoutRawStore,
+		},
+		{
+			name: "[正常系] 読み取り専用の正規化済みストア",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				fs.MkdirAll("/in/v3/files", 0o555)
+				fs.Chmod("/in", 0o555)
+				return fs
+			},
+			path: "/in",
+			want: store.LayoutOutputV1,
+		},
+		{
+			name: "[正常系] .fetcher-versionが2のストア",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				fs.MkdirAll("/in/v10/files", 0o555)
+				afero.WriteFile(fs, "/in/.fetcher-version", []byte("2\n"), 0o444)
+				return fs
+			},
+			path: "/in",
+			want: store.LayoutOutputV2,
+		},
+		{
+			name: "[正常系] .fetcher-versionが3でtarballを含むディレクトリ",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				fs.MkdirAll("/in", 0o555)
+				afero.WriteFile(fs, "/in/.fetcher-version", []byte("3\n"), 0o444)
+				afero.WriteFile(fs, "/in/pnpm-store.tar.zst", []byte{}, 0o444)
+				return fs
+			},
+			path: "/in",
+			want: store.LayoutOutputV3,
+		},
+		{
+			name: "[正常系] tarballファイル",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				afero.WriteFile(fs, "/in/pnpm-store.tar.zst", []byte{}, 0o444)
+				return fs
+			},
+			path: "/in/pnpm-store.tar.zst",
+			want: store.LayoutTarball,
+		},
+		{
+			name: "[異常系] ストアを含まないディレクトリ",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				fs.MkdirAll("/in/node_modules", 0o755)
+				return fs
+			},
+			path:    "/in",
+			wantErr: &store_err.UnsupportedStoreLayoutError{},
+		},
+		{
+			name:    "[異常系] パスが存在しない",
+			setupFs: afero.NewMemMapFs,
+			path:    "/in",
+			wantErr: &store_err.UnsupportedStoreLayoutError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := store.DetectLayout(tt.setupFs(), tt.path)
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("DetectLayout() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("DetectLayout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
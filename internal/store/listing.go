@@ -0,0 +1,248 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/nix-community/go-nix/pkg/nar"
+	"github.com/spf13/afero"
+
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+// TreeEntry describes a single file, directory or symlink of a store tree
+// independently of whether it was read from a directory, a tarball or a NAR file.
+type TreeEntry struct {
+	Path       string       // slash-separated path relative to the tree root ("" for the root)
+	Type       nar.NodeType // regular, directory or symlink
+	Size       int64        // size of regular files
+	Executable bool         // executable bit of regular files
+	SHA256     string       // hex-encoded sha256 of regular file contents
+	Target     string       // target of symlinks
+	Content    []byte       // contents of .json files, kept for semantic comparison
+}
+
+// List reads the store tree at path. path may be a store directory, a fetcher v3
+// output directory or pnpm-store.tar.zst (the tarball contents are listed), or a .nar file.
+func List(afs afero.Fs, path string) ([]TreeEntry, store_err.StoreErrorIF) {
+	info, err := afs.Stat(path)
+	if err != nil {
+		return nil, store_err.NewStoreError(&store_err.FailedToReadStoreError{}, path, err)
+	}
+
+	switch {
+	case info.IsDir():
+		if readFetcherVersion(afs, path) == "3" {
+			tarballPath := filepath.Join(path, TarballFileName)
+			if ok, _ := afero.Exists(afs, tarballPath); ok {
+				return listTarball(afs, tarballPath)
+			}
+		}
+
+		return listDir(afs, path)
+	case strings.HasSuffix(path, ".tar.zst"):
+		return listTarball(afs, path)
+	case strings.HasSuffix(path, ".nar"):
+		return listNAR(afs, path)
+	default:
+		return nil, store_err.NewStoreError(
+			&store_err.UnsupportedStoreLayoutError{},
+			"at "+path+": expected a directory, a .tar.zst file or a .nar file",
+			nil,
+		)
+	}
+}
+
+// listDir lists a directory tree.
+func listDir(afs afero.Fs, root string) ([]TreeEntry, store_err.StoreErrorIF) {
+	var entries []TreeEntry
+
+	walkErr := afero.Walk(afs, root, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, relErr := filepath.Rel(root, path)
+		if relErr != nil {
+			return relErr
+		}
+
+		entry := TreeEntry{Path: filepath.ToSlash(relPath)}
+		if entry.Path == "." {
+			entry.Path = ""
+		}
+
+		switch {
+		case info.IsDir():
+			entry.Type = nar.TypeDirectory
+		case info.Mode()&fs.ModeSymlink != 0:
+			entry.Type = nar.TypeSymlink
+
+			target, linkErr := readSymlinkTarget(afs, path)
+			if linkErr != nil {
+				return linkErr
+			}
+
+			entry.Target = target
+		default:
+			entry.Type = nar.TypeRegular
+			entry.Executable = info.Mode()&0o111 != 0
+
+			if digestErr := digestFile(afs, &entry, path); digestErr != nil {
+				return digestErr
+			}
+		}
+
+		entries = append(entries, entry)
+
+		return nil
+	})
+	if walkErr != nil {
+		return nil, store_err.NewStoreError(&store_err.FailedToReadStoreError{}, root, walkErr)
+	}
+
+	return entries, nil
+}
+
+// listTarball lists the contents of a zstd-compressed tarball without extracting it.
+func listTarball(afs afero.Fs, tarballPath string) ([]TreeEntry, store_err.StoreErrorIF) {
+	f, err := afs.Open(tarballPath)
+	if err != nil {
+		return nil, store_err.NewStoreError(&store_err.FailedToReadTarballError{}, tarballPath, err)
+	}
+	defer f.Close()
+
+	zr, err := newZstdReader(f)
+	if err != nil {
+		return nil, store_err.NewStoreError(&store_err.FailedToReadTarballError{}, tarballPath, err)
+	}
+	defer zr.Close()
+
+	tr := newGNUTarReader(zr)
+
+	var entries []TreeEntry
+
+	for {
+		h, nextErr := tr.next()
+		if errors.Is(nextErr, io.EOF) {
+			break
+		}
+
+		if nextErr != nil {
+			return nil, store_err.NewStoreError(&store_err.FailedToReadTarballError{}, tarballPath, nextErr)
+		}
+
+		entry := TreeEntry{Path: tarEntryKey(h.name)}
+		if entry.Path == "." {
+			entry.Path = ""
+		}
+
+		switch h.typeflag {
+		case tarTypeDir:
+			entry.Type = nar.TypeDirectory
+		case tarTypeSymlink:
+			entry.Type = nar.TypeSymlink
+			entry.Target = h.linkname
+		default:
+			entry.Type = nar.TypeRegular
+			entry.Executable = h.mode&0o111 != 0
+
+			if digestErr := digestContent(&entry, tr); digestErr != nil {
+				return nil, store_err.NewStoreError(&store_err.FailedToReadTarballError{}, tarballPath, digestErr)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// listNAR lists the contents of a NAR file.
+func listNAR(afs afero.Fs, narPath string) ([]TreeEntry, store_err.StoreErrorIF) {
+	f, err := afs.Open(narPath)
+	if err != nil {
+		return nil, store_err.NewStoreError(&store_err.FailedToReadStoreError{}, narPath, err)
+	}
+	defer f.Close()
+
+	nr, err := nar.NewReader(f)
+	if err != nil {
+		return nil, store_err.NewStoreError(&store_err.FailedToReadStoreError{}, narPath, err)
+	}
+	defer nr.Close()
+
+	var entries []TreeEntry
+
+	for {
+		h, nextErr := nr.Next()
+		if errors.Is(nextErr, io.EOF) {
+			break
+		}
+
+		if nextErr != nil {
+			return nil, store_err.NewStoreError(&store_err.FailedToReadStoreError{}, narPath, nextErr)
+		}
+
+		entry := TreeEntry{
+			Path:       strings.TrimPrefix(h.Path, "/"),
+			Type:       h.Type,
+			Executable: h.Executable,
+			Target:     h.LinkTarget,
+		}
+
+		if h.Type == nar.TypeRegular {
+			if digestErr := digestContent(&entry, nr); digestErr != nil {
+				return nil, store_err.NewStoreError(&store_err.FailedToReadStoreError{}, narPath, digestErr)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// digestFile digests the regular file at path like digestContent. The file is closed
+// before returning, so walking a large store does not keep every content file open.
+func digestFile(afs afero.Fs, entry *TreeEntry, path string) error {
+	f, err := afs.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return digestContent(entry, f)
+}
+
+// digestContent reads r to the end, recording the size and sha256 of a regular file.
+// Contents of .json files are kept in the entry as well.
+func digestContent(entry *TreeEntry, r io.Reader) error {
+	h := sha256.New()
+
+	var w io.Writer = h
+
+	var buf strings.Builder
+	if strings.HasSuffix(entry.Path, ".json") {
+		w = io.MultiWriter(h, &buf)
+	}
+
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return err
+	}
+
+	entry.Size = n
+	entry.SHA256 = hex.EncodeToString(h.Sum(nil))
+
+	if buf.Len() > 0 {
+		entry.Content = []byte(buf.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package store_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+// openCountingFs counts the files that are open at the same time.
+type openCountingFs struct {
+	afero.Fs
+
+	open    int
+	maxOpen int
+}
+
+func (o *openCountingFs) Open(name string) (afero.File, error) {
+	f, err := o.Fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	o.open++
+	o.maxOpen = max(o.maxOpen, o.open)
+
+	return &countedFile{File: f, fs: o}, nil
+}
+
+type countedFile struct {
+	afero.File
+
+	fs     *openCountingFs
+	closed bool
+}
+
+func (c *countedFile) Close() error {
+	if !c.closed {
+		c.closed = true
+		c.fs.open--
+	}
+
+	return c.File.Close()
+}
+
+func Test_List(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		files       int
+		wantEntries int
+		wantMaxOpen int
+	}{
+		{
+			name:        "[正常系] 多数のファイルを同時に開かずに列挙する",
+			files:       2048,
+			wantEntries: 2048 + 3, // the root, v10 and v10/files
+			wantMaxOpen: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			afs := &openCountingFs{Fs: afero.NewMemMapFs()}
+			afs.MkdirAll("/store/v10/files", 0o755)
+			for i := range tt.files {
+				afero.WriteFile(afs, fmt.Sprintf("/store/v10/files/%04d", i), []byte{byte(i)}, 0o644)
+			}
+
+			got, gotErr := store.List(afs, "/store")
+			if gotErr != nil {
+				t.Fatalf("List() error = %v", gotErr)
+			}
+
+			if len(got) != tt.wantEntries {
+				t.Errorf("List() returned %d entries, want %d", len(got), tt.wantEntries)
+			}
+
+			if afs.maxOpen > tt.wantMaxOpen {
+				t.Errorf("List() kept %d files open at once, want at most %d", afs.maxOpen, tt.wantMaxOpen)
+			}
+
+			if afs.open != 0 {
+				t.Errorf("List() left %d files open", afs.open)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package store
+
+import "github.com/nix-community/go-nix/pkg/nar"
+
+// manifestVersion is the version of the manifest format, following Nix's .ls listings.
+const manifestVersion = 1
+
+// Manifest is a JSON listing of a hashed tree. Its format follows the .ls NAR listings
+// served by Nix binary caches, extended with the sha256 of every regular file.
+type Manifest struct {
+	Version int          `json:"version"`
+	Root    ManifestNode `json:"root"`
+}
+
+// ManifestNode describes a single file, directory or symlink of a Manifest.
+type ManifestNode struct {
+	Type       nar.NodeType             `json:"type"`
+	Entries    map[string]*ManifestNode `json:"entries,omitempty"`    // children of directories
+	Size       *int64                   `json:"size,omitempty"`       // size of regular files
+	Executable bool                     `json:"executable,omitempty"` // executable bit of regular files
+	SHA256     string                   `json:"sha256,omitempty"`     // hex-encoded sha256 of regular files
+	Target     string                   `json:"target,omitempty"`     // target of symlinks
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"io/fs"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/afero"
@@ -13,16 +14,24 @@ import (
 	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
 )
 
-var storeVersionDirs = []string{"v3", "v10"}
+// supportedStoreVersions are the pnpm store layouts Normalize knows how to clean up:
+// v3 (pnpm 7 to 9) and v10 (pnpm 10).
+var supportedStoreVersions = []string{"v3", "v10"}
 
 type NormalizeOptions struct {
 	StorePath      string
-	FetcherVersion int
+	SetPermissions bool         // make every file read-only and keep only the executable bit
+	Progress       ProgressFunc // receives the progress of the JSON and permission steps if not nil
 }
 
 func Normalize(afs afero.Fs, opts NormalizeOptions) store_err.StoreErrorIF {
+	versionDirs, err := detectStoreVersionDirs(afs, opts.StorePath)
+	if err != nil {
+		return err
+	}
+
 	// Step 1: Remove temporary directories
-	for _, dir := range storeVersionDirs {
+	for _, dir := range versionDirs {
 		tmpPath := filepath.Join(opts.StorePath, dir, "tmp")
 		if err := afs.RemoveAll(tmpPath); err != nil {
 			return store_err.NewStoreError(
@@ -34,12 +43,12 @@ func Normalize(afs afero.Fs, opts NormalizeOptions) store_err.StoreErrorIF {
 	}
 
 	// Step 2: Normalize JSON files
-	if err := normalizeJSONFiles(afs, opts.StorePath); err != nil {
+	if err := normalizeJSONFiles(afs, opts.StorePath, opts.Progress); err != nil {
 		return err
 	}
 
 	// Step 3: Remove projects directories
-	for _, dir := range storeVersionDirs {
+	for _, dir := range versionDirs {
 		projectsPath := filepath.Join(opts.StorePath, dir, "projects")
 		if err := afs.RemoveAll(projectsPath); err != nil {
 			return store_err.NewStoreError(
@@ -50,10 +59,9 @@ func Normalize(afs afero.Fs, opts NormalizeOptions) store_err.StoreErrorIF {
 		}
 	}
 
-	// Step 4: Set permissions (fetcherVersion >= 2 only)
-	//nolint:mnd // fetcherVersion 2+ requires permission normalization
-	if opts.FetcherVersion >= 2 {
-		if err := setPermissions(afs, opts.StorePath); err != nil {
+	// Step 4: Set permissions (fetcher v2 and later)
+	if opts.SetPermissions {
+		if err := setPermissions(afs, opts.StorePath, opts.Progress); err != nil {
 			return err
 		}
 	}
@@ -61,9 +69,56 @@ func Normalize(afs afero.Fs, opts NormalizeOptions) store_err.StoreErrorIF {
 	return nil
 }
 
+// findStoreVersionDirs returns the store version directories (vN) in storePath, sorted
+// by name. A missing storePath has none.
+func findStoreVersionDirs(afs afero.Fs, storePath string) ([]string, error) {
+	entries, err := afero.ReadDir(afs, storePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() && storeVersionDirPattern.MatchString(entry.Name()) {
+			dirs = append(dirs, entry.Name())
+		}
+	}
+
+	return dirs, nil
+}
+
+// detectStoreVersionDirs returns the store version directories in storePath.
+// Unknown layouts may keep temporary files in other places, so they are rejected
+// instead of being hashed as they are.
+func detectStoreVersionDirs(afs afero.Fs, storePath string) ([]string, store_err.StoreErrorIF) {
+	dirs, err := findStoreVersionDirs(afs, storePath)
+	if err != nil {
+		return nil, store_err.NewStoreError(&store_err.FailedToReadStoreError{}, storePath, err)
+	}
+
+	for _, dir := range dirs {
+		if !slices.Contains(supportedStoreVersions, dir) {
+			return nil, store_err.NewStoreError(&store_err.UnsupportedStoreLayoutError{}, dir, nil)
+		}
+	}
+
+	return dirs, nil
+}
+
+// isJSONFile reports whether a walked entry is a JSON file normalized by normalizeJSONFiles.
+func isJSONFile(path string, info fs.FileInfo) bool {
+	return !info.IsDir() && strings.HasSuffix(path, ".json")
+}
+
 // normalizeJSONFiles walks storePath, finds all .json files, and normalizes each one.
 // Stops and returns the error on the first failure.
-func normalizeJSONFiles(afs afero.Fs, storePath string) store_err.StoreErrorIF {
+func normalizeJSONFiles(afs afero.Fs, storePath string, progress ProgressFunc) store_err.StoreErrorIF {
+	tracker := startPhase(afs, storePath, progress, PhaseNormalizeJSON, isJSONFile, true)
+
 	normalizeErr := afero.Walk(
 		afs,
 		storePath,
@@ -76,13 +131,16 @@ func normalizeJSONFiles(afs afero.Fs, storePath string) store_err.StoreErrorIF {
 				)
 			}
 
-			if info.IsDir() || !strings.HasSuffix(path, ".json") {
+			if !isJSONFile(path, info) {
 				return nil
 			}
 
+			// Taken before the file is rewritten, as some file systems update info in place.
+			size := info.Size()
 			if e := normalizeJSONFile(afs, path, info.Mode()); e != nil {
 				return e
 			}
+			tracker.add(size)
 
 			return nil
 		},
@@ -184,12 +242,26 @@ func removeCheckedAt(v any) any {
 	}
 }
 
+// normalizedPerm returns the permission setPermissions assigns to an entry.
+func normalizedPerm(name string, isDir bool) fs.FileMode {
+	switch {
+	case isDir:
+		return 0o555
+	case strings.HasSuffix(name, "-exec"):
+		return 0o555
+	default:
+		return 0o444
+	}
+}
+
 // setPermissions walks storePath and sets fixed permissions on all entries so that
 // nix hash produces the same result regardless of the build environment.
 //   - directories:        0555 (r-xr-xr-x)
 //   - files named *-exec: 0555 (r-xr-xr-x)
 //   - other files:        0444 (r--r--r--)
-func setPermissions(afs afero.Fs, storePath string) store_err.StoreErrorIF {
+func setPermissions(afs afero.Fs, storePath string, progress ProgressFunc) store_err.StoreErrorIF {
+	tracker := startPhase(afs, storePath, progress, PhaseSetPermissions, nil, false)
+
 	walkErr := afero.Walk(afs, storePath, func(path string, info fs.FileInfo, err error) error {
 		if err != nil {
 			return store_err.NewStoreError(
@@ -199,15 +271,7 @@ func setPermissions(afs afero.Fs, storePath string) store_err.StoreErrorIF {
 			)
 		}
 
-		var mode fs.FileMode
-		switch {
-		case info.IsDir():
-			mode = 0o555
-		case strings.HasSuffix(info.Name(), "-exec"):
-			mode = 0o555
-		default:
-			mode = 0o444
-		}
+		mode := normalizedPerm(info.Name(), info.IsDir())
 
 		if chmodErr := afs.Chmod(path, mode); chmodErr != nil {
 			return store_err.NewStoreError(
@@ -217,6 +281,10 @@ func setPermissions(afs afero.Fs, storePath string) store_err.StoreErrorIF {
 			)
 		}
 
+		if !info.IsDir() {
+			tracker.add(0)
+		}
+
 		return nil
 	})
 
@@ -235,3 +303,37 @@ func setPermissions(afs afero.Fs, storePath string) store_err.StoreErrorIF {
 
 	return nil
 }
+
+// FindPermissionMismatches walks storePath and returns the regular files whose
+// executable bit disagrees with the "-exec" file name suffix pnpm uses for executables.
+// A store written by pnpm has no mismatches; any mismatch means its permissions
+// were not preserved, so a fetcher v1 output cannot be converted losslessly.
+func FindPermissionMismatches(afs afero.Fs, storePath string) ([]string, store_err.StoreErrorIF) {
+	var mismatches []string
+
+	walkErr := afero.Walk(afs, storePath, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		wantExec := normalizedPerm(info.Name(), false)&0o111 != 0
+		if gotExec := info.Mode()&0o111 != 0; gotExec != wantExec {
+			mismatches = append(mismatches, path)
+		}
+
+		return nil
+	})
+	if walkErr != nil {
+		return nil, store_err.NewStoreError(
+			&store_err.FailedToSetPermissionsError{},
+			storePath,
+			walkErr,
+		)
+	}
+
+	return mismatches, nil
+}
@@ -75,7 +75,7 @@ func Test_Normalize(t *testing.T) {
 				fs.MkdirAll("/store/v10/projects", 0o755)
 				return fs
 			},
-			opts: store.NormalizeOptions{StorePath: "/store", FetcherVersion: 1},
+			opts: store.NormalizeOptions{StorePath: "/store"},
 			verify: func(t *testing.T, afs afero.Fs) {
 				t.Helper()
 				verifyDeleted(t, afs, []string{
@@ -99,7 +99,7 @@ func Test_Normalize(t *testing.T) {
 				)
 				return fs
 			},
-			opts: store.NormalizeOptions{StorePath: "/store", FetcherVersion: 1},
+			opts: store.NormalizeOptions{StorePath: "/store"},
 			verify: func(t *testing.T, afs afero.Fs) {
 				t.Helper()
 				verifyFileContent(t, afs, "/store/v3/pkg.json",
@@ -119,7 +119,7 @@ func Test_Normalize(t *testing.T) {
 				)
 				return fs
 			},
-			opts: store.NormalizeOptions{StorePath: "/store", FetcherVersion: 1},
+			opts: store.NormalizeOptions{StorePath: "/store"},
 			verify: func(t *testing.T, afs afero.Fs) {
 				t.Helper()
 				verifyFileContent(
@@ -131,7 +131,7 @@ func Test_Normalize(t *testing.T) {
 			},
 		},
 		{
-			name: "[正常系] fetcherVersion 2以上でパーミッションが設定される",
+			name: "[正常系] SetPermissionsが有効ならパーミッションが設定される",
 			setupFs: func() afero.Fs {
 				fs := afero.NewMemMapFs()
 				fs.MkdirAll("/store/v10/files", 0o755)
@@ -139,7 +139,7 @@ func Test_Normalize(t *testing.T) {
 				afero.WriteFile(fs, "/store/v10/files/run-exec", []byte("exec"), 0o755)
 				return fs
 			},
-			opts: store.NormalizeOptions{StorePath: "/store", FetcherVersion: 2},
+			opts: store.NormalizeOptions{StorePath: "/store", SetPermissions: true},
 			verify: func(t *testing.T, afs afero.Fs) {
 				t.Helper()
 				verifyPermissions(t, afs, []permCheck{
@@ -150,14 +150,14 @@ func Test_Normalize(t *testing.T) {
 			},
 		},
 		{
-			name: "[正常系] fetcherVersion 1ではパーミッション設定がスキップされる",
+			name: "[正常系] SetPermissionsが無効ならパーミッション設定がスキップされる",
 			setupFs: func() afero.Fs {
 				fs := afero.NewMemMapFs()
 				fs.MkdirAll("/store/v3", 0o755)
 				afero.WriteFile(fs, "/store/v3/data.txt", []byte("data"), 0o644)
 				return fs
 			},
-			opts: store.NormalizeOptions{StorePath: "/store", FetcherVersion: 1},
+			opts: store.NormalizeOptions{StorePath: "/store"},
 			verify: func(t *testing.T, afs afero.Fs) {
 				t.Helper()
 				info, err := afs.Stat("/store/v3/data.txt")
@@ -176,7 +176,7 @@ func Test_Normalize(t *testing.T) {
 				fs.MkdirAll("/store", 0o755)
 				return fs
 			},
-			opts: store.NormalizeOptions{StorePath: "/store", FetcherVersion: 1},
+			opts: store.NormalizeOptions{StorePath: "/store"},
 		},
 		{
 			name: "[異常系] 不正なJSONファイルがある場合",
@@ -186,9 +186,26 @@ func Test_Normalize(t *testing.T) {
 				afero.WriteFile(fs, "/store/v3/invalid.json", []byte("{invalid"), 0o644)
 				return fs
 			},
-			opts:    store.NormalizeOptions{StorePath: "/store", FetcherVersion: 1},
+			opts:    store.NormalizeOptions{StorePath: "/store"},
 			wantErr: &store_err.FailedToNormalizeJSONError{},
 		},
+		{
+			name: "[異常系] 未知のストアレイアウトがある場合",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				fs.MkdirAll("/store/v10/files", 0o755)
+				fs.MkdirAll("/store/v11/tmp", 0o755)
+				return fs
+			},
+			opts:    store.NormalizeOptions{StorePath: "/store"},
+			wantErr: &store_err.UnsupportedStoreLayoutError{},
+			verify: func(t *testing.T, afs afero.Fs) {
+				t.Helper()
+				if exists, _ := afero.DirExists(afs, "/store/v11/tmp"); !exists {
+					t.Errorf("expected /store/v11/tmp to be left untouched")
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -209,3 +226,51 @@ func Test_Normalize(t *testing.T) {
 		})
 	}
 }
+
+func Test_FindPermissionMismatches(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		setupFs func() afero.Fs
+		want    []string
+	}{
+		{
+			name: "[正常系] 実行ビットとファイル名が一致する",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				fs.MkdirAll("/store/v10/files/ab", 0o755)
+				afero.WriteFile(fs, "/store/v10/files/ab/cdef", []byte("a"), 0o644)
+				afero.WriteFile(fs, "/store/v10/files/ab/0123-exec", []byte("b"), 0o755)
+				return fs
+			},
+			want: nil,
+		},
+		{
+			name: "[正常系] 実行ビットが失われたファイルと付与されたファイルが報告される",
+			setupFs: func() afero.Fs {
+				fs := afero.NewMemMapFs()
+				fs.MkdirAll("/store/v10/files/ab", 0o755)
+				afero.WriteFile(fs, "/store/v10/files/ab/cdef", []byte("a"), 0o755)
+				afero.WriteFile(fs, "/store/v10/files/ab/0123-exec", []byte("b"), 0o444)
+				return fs
+			},
+			want: []string{"/store/v10/files/ab/0123-exec", "/store/v10/files/ab/cdef"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := store.FindPermissionMismatches(tt.setupFs(), "/store")
+			if err != nil {
+				t.Fatalf("FindPermissionMismatches() error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindPermissionMismatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"io/fs"
+
+	"github.com/spf13/afero"
+)
+
+// Phases reported in Progress.
+const (
+	PhaseNormalizeJSON  = "normalize JSON files"
+	PhaseSetPermissions = "set permissions"
+	PhaseWriteTarball   = "write tarball"
+	PhaseHash           = "hash"
+)
+
+// Progress is the progress of a phase of a long-running store operation.
+type Progress struct {
+	Phase      string
+	Files      int   // files processed
+	TotalFiles int   // files to process
+	Bytes      int64 // bytes processed
+	TotalBytes int64 // bytes to process
+}
+
+// ProgressFunc receives the progress of a store operation. It is called once when
+// a phase starts and after every processed file.
+type ProgressFunc func(Progress)
+
+// progressTracker counts the processed files and bytes of a phase.
+// A nil tracker ignores all updates, so operations without a ProgressFunc
+// do not need to check for one.
+type progressTracker struct {
+	report   ProgressFunc
+	progress Progress
+}
+
+// newProgressTracker starts a phase with the given totals. It returns nil if report is nil.
+func newProgressTracker(report ProgressFunc, phase string, totalFiles int, totalBytes int64) *progressTracker {
+	if report == nil {
+		return nil
+	}
+
+	t := &progressTracker{
+		report: report,
+		progress: Progress{
+			Phase:      phase,
+			TotalFiles: totalFiles,
+			TotalBytes: totalBytes,
+		},
+	}
+	report(t.progress)
+
+	return t
+}
+
+// add records a processed file of the given size.
+func (t *progressTracker) add(size int64) {
+	if t == nil {
+		return
+	}
+
+	t.progress.Files++
+	t.progress.Bytes += size
+	t.report(t.progress)
+}
+
+// countFiles returns the number and total size of the files below root accepted by match.
+// Directories are not counted; all other entries are if match is nil.
+func countFiles(afs afero.Fs, root string, match func(path string, info fs.FileInfo) bool) (int, int64, error) {
+	var (
+		files int
+		bytes int64
+	)
+
+	err := afero.Walk(afs, root, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || (match != nil && !match(path, info)) {
+			return nil
+		}
+
+		files++
+		if info.Mode().IsRegular() {
+			bytes += info.Size()
+		}
+
+		return nil
+	})
+
+	return files, bytes, err
+}
+
+// startPhase counts the files of a phase and starts tracking it. Counting is skipped
+// without a ProgressFunc; if it fails, the phase is tracked without totals.
+// Phases that do not read file contents pass countBytes = false.
+func startPhase(
+	afs afero.Fs,
+	root string,
+	report ProgressFunc,
+	phase string,
+	match func(path string, info fs.FileInfo) bool,
+	countBytes bool,
+) *progressTracker {
+	if report == nil {
+		return nil
+	}
+
+	files, bytes, err := countFiles(afs, root, match)
+	if err != nil || !countBytes {
+		bytes = 0
+	}
+
+	if err != nil {
+		files = 0
+	}
+
+	return newProgressTracker(report, phase, files, bytes)
+}
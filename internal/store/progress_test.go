@@ -0,0 +1,177 @@
+package store_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+// setupProgressStore creates a small raw store with two JSON index files and
+// one content file.
+func setupProgressStore(t *testing.T) (afero.Fs, string) {
+	t.Helper()
+
+	afs := afero.NewMemMapFs()
+	files := map[string]string{
+		"/store/v10/files/00/abc":           "hello",
+		"/store/v10/index/00/abc-pkg.json":  `{"checkedAt":1}`,
+		"/store/v10/index/01/def-pkg.json":  `{"checkedAt":2}`,
+		"/store/v10/projects/ab/node_mods":  "x",
+		"/store/v10/tmp/_tmp_1234/leftover": "y",
+	}
+
+	for path, content := range files {
+		if err := afero.WriteFile(afs, path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	return afs, "/store"
+}
+
+// recordProgress returns a ProgressFunc that appends every update to got.
+func recordProgress(got *[]store.Progress) store.ProgressFunc {
+	return func(p store.Progress) {
+		*got = append(*got, p)
+	}
+}
+
+func Test_Normalize_progress(t *testing.T) {
+	t.Parallel()
+
+	afs, storePath := setupProgressStore(t)
+
+	var got []store.Progress
+	err := store.Normalize(afs, store.NormalizeOptions{
+		StorePath:      storePath,
+		SetPermissions: true,
+		Progress:       recordProgress(&got),
+	})
+	if err != nil {
+		t.Fatalf("Normalize() error: %v", err)
+	}
+
+	// The JSON phase reports the bytes it rewrites; permissions only count files.
+	want := []store.Progress{
+		{Phase: store.PhaseNormalizeJSON, TotalFiles: 2, TotalBytes: 30},
+		{Phase: store.PhaseNormalizeJSON, Files: 1, TotalFiles: 2, Bytes: 15, TotalBytes: 30},
+		{Phase: store.PhaseNormalizeJSON, Files: 2, TotalFiles: 2, Bytes: 30, TotalBytes: 30},
+		{Phase: store.PhaseSetPermissions, TotalFiles: 3},
+		{Phase: store.PhaseSetPermissions, Files: 1, TotalFiles: 3},
+		{Phase: store.PhaseSetPermissions, Files: 2, TotalFiles: 3},
+		{Phase: store.PhaseSetPermissions, Files: 3, TotalFiles: 3},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("progress = %+v, want %+v", got, want)
+	}
+}
+
+func Test_HashTree_progress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		opts         func(*[]store.Progress) store.HashOptions
+		wantManifest bool
+		wantUpdates  int
+	}{
+		{
+			name: "[正常系] 進捗とマニフェストを同時に取得できる",
+			opts: func(got *[]store.Progress) store.HashOptions {
+				return store.HashOptions{Manifest: true, Progress: recordProgress(got)}
+			},
+			wantManifest: true,
+			wantUpdates:  5,
+		},
+		{
+			name: "[正常系] 進捗なしでもハッシュは変わらない",
+			opts: func(_ *[]store.Progress) store.HashOptions {
+				return store.HashOptions{}
+			},
+			wantManifest: false,
+			wantUpdates:  0,
+		},
+	}
+
+	refFs, refPath := setupProgressStore(t)
+	wantHash, err := store.Hash(refFs, refPath)
+	if err != nil {
+		t.Fatalf("Hash() error: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			afs, storePath := setupProgressStore(t)
+
+			var got []store.Progress
+			hash, manifest, hashErr := store.HashTree(afs, storePath, tt.opts(&got))
+			if hashErr != nil {
+				t.Fatalf("HashTree() error: %v", hashErr)
+			}
+
+			if hash != wantHash {
+				t.Errorf("HashTree() = %s, want %s", hash, wantHash)
+			}
+
+			if (manifest != nil) != tt.wantManifest {
+				t.Errorf("HashTree() manifest = %v, want manifest %v", manifest, tt.wantManifest)
+			}
+
+			// One update when the phase starts and one per file.
+			if tt.wantUpdates == 0 {
+				if len(got) != 0 {
+					t.Errorf("got %d progress updates, want none", len(got))
+				}
+				return
+			}
+
+			if len(got) != tt.wantUpdates+1 {
+				t.Fatalf("got %d progress updates, want %d", len(got), tt.wantUpdates+1)
+			}
+
+			last := got[len(got)-1]
+			want := store.Progress{
+				Phase:      store.PhaseHash,
+				Files:      tt.wantUpdates,
+				TotalFiles: tt.wantUpdates,
+				Bytes:      37,
+				TotalBytes: 37,
+			}
+			if last != want {
+				t.Errorf("last progress = %+v, want %+v", last, want)
+			}
+		})
+	}
+}
+
+func Test_CreateTarball_progress(t *testing.T) {
+	t.Parallel()
+
+	afs, storePath := setupProgressStore(t)
+
+	var got []store.Progress
+	if err := store.CreateTarball(afs, storePath, "/out.tar.zst", recordProgress(&got)); err != nil {
+		t.Fatalf("CreateTarball() error: %v", err)
+	}
+
+	if len(got) != 6 {
+		t.Fatalf("got %d progress updates, want 6", len(got))
+	}
+
+	want := store.Progress{
+		Phase:      store.PhaseWriteTarball,
+		Files:      5,
+		TotalFiles: 5,
+		Bytes:      37,
+		TotalBytes: 37,
+	}
+	if last := got[len(got)-1]; last != want {
+		t.Errorf("last progress = %+v, want %+v", last, want)
+	}
+}
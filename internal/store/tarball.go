@@ -189,6 +189,7 @@ func writeTarball(
 	entries []fileEntry,
 	outputPath string,
 	outFile afero.File,
+	progress ProgressFunc,
 ) store_err.StoreErrorIF {
 	// Use CGo-linked C zstd library with CLI-compatible parameters
 	// (compression level 3, content checksum enabled) for byte-identical output.
@@ -203,12 +204,22 @@ func writeTarball(
 
 	tw := newGNUTarWriter(zw)
 
+	var tracker *progressTracker
+	if progress != nil {
+		files, bytes := countEntries(entries)
+		tracker = newProgressTracker(progress, PhaseWriteTarball, files, bytes)
+	}
+
 	for _, entry := range entries {
 		if err := writeStoreEntry(afs, tw, entry); err != nil {
 			_ = zw.Close()
 
 			return err
 		}
+
+		if !entry.info.IsDir() {
+			tracker.add(regularFileSize(entry.info))
+		}
 	}
 
 	if closeErr := tw.close(); closeErr != nil {
@@ -232,6 +243,32 @@ func writeTarball(
 	return nil
 }
 
+// countEntries returns the number of non-directory entries and the total size of regular files.
+func countEntries(entries []fileEntry) (int, int64) {
+	var (
+		files int
+		bytes int64
+	)
+
+	for _, entry := range entries {
+		if !entry.info.IsDir() {
+			files++
+			bytes += regularFileSize(entry.info)
+		}
+	}
+
+	return files, bytes
+}
+
+// regularFileSize returns the size of regular files and 0 for other entries.
+func regularFileSize(info fs.FileInfo) int64 {
+	if !info.Mode().IsRegular() {
+		return 0
+	}
+
+	return info.Size()
+}
+
 // CreateTarball creates a reproducible zstd-compressed tarball from storePath.
 // Produces output byte-identical to GNU tar with:
 //
@@ -240,7 +277,8 @@ func writeTarball(
 //	  --zstd -cf - -C storePath .
 //
 // Uses CGo-linked C zstd library for compression compatibility.
-func CreateTarball(afs afero.Fs, storePath string, outputPath string) store_err.StoreErrorIF {
+// If progress is not nil, it receives the progress of writing the entries.
+func CreateTarball(afs afero.Fs, storePath string, outputPath string, progress ProgressFunc) store_err.StoreErrorIF {
 	entries, collectErr := collectSortedEntries(afs, storePath)
 	if collectErr != nil {
 		return collectErr
@@ -256,5 +294,5 @@ func CreateTarball(afs afero.Fs, storePath string, outputPath string) store_err.
 	}
 	defer outFile.Close()
 
-	return writeTarball(afs, entries, outputPath, outFile)
+	return writeTarball(afs, entries, outputPath, outFile, progress)
 }
@@ -0,0 +1,310 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxPAXHeaderSize limits the size of PAX extended headers, which are read into memory.
+// Like archive/tar's limit for special files, it is far above what path and linkpath
+// records need and rejects hostile sizes before allocating.
+const maxPAXHeaderSize = 1 << 20
+
+// tarReaderEntry holds the metadata of a tar entry read by gnuTarReader.
+// PAX "path" and "linkpath" records are already applied to name and linkname.
+type tarReaderEntry struct {
+	name     string
+	linkname string
+	mode     int64
+	uid      int64
+	gid      int64
+	uname    string
+	gname    string
+	size     int64
+	mtime    int64
+	typeflag byte
+	pax      map[string]string
+}
+
+// gnuTarReader reads tar archives in the subset of the PAX/USTAR format written by gnuTarWriter.
+// Global PAX headers, GNU long name extensions and device entries are rejected.
+type gnuTarReader struct {
+	r       io.Reader
+	remain  int64 // unread data bytes of the current entry
+	padding int64 // padding bytes following the current entry's data
+}
+
+func newGNUTarReader(r io.Reader) *gnuTarReader {
+	return &gnuTarReader{r: r}
+}
+
+// next advances to the next entry, skipping any unread data of the current one.
+// It returns io.EOF at the end-of-archive marker.
+func (tr *gnuTarReader) next() (*tarReaderEntry, error) {
+	if err := tr.skip(tr.remain + tr.padding); err != nil {
+		return nil, err
+	}
+
+	tr.remain, tr.padding = 0, 0
+
+	var pax map[string]string
+
+	for {
+		h, err := tr.readHeader()
+		if err != nil {
+			return nil, err
+		}
+
+		if h == nil {
+			return nil, io.EOF
+		}
+
+		switch h.typeflag {
+		case tarTypePAX:
+			pax, err = tr.readPAXRecords(h.size)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", h.name, err)
+			}
+
+			continue
+		case tarTypeFile, tarTypeDir, tarTypeSymlink:
+		default:
+			return nil, fmt.Errorf("%s: unsupported tar entry type %q", h.name, h.typeflag)
+		}
+
+		if p, ok := pax["path"]; ok {
+			h.name = p
+		}
+
+		if p, ok := pax["linkpath"]; ok {
+			h.linkname = p
+		}
+
+		h.pax = pax
+
+		if h.typeflag == tarTypeFile {
+			tr.remain = h.size
+			tr.padding = blockPadding(h.size)
+		}
+
+		return h, nil
+	}
+}
+
+// Read reads the data of the current entry.
+func (tr *gnuTarReader) Read(p []byte) (int, error) {
+	if tr.remain <= 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > tr.remain {
+		p = p[:tr.remain]
+	}
+
+	n, err := tr.r.Read(p)
+	tr.remain -= int64(n)
+
+	if errors.Is(err, io.EOF) && tr.remain > 0 {
+		return n, io.ErrUnexpectedEOF
+	}
+
+	return n, err
+}
+
+// readHeader reads and decodes a single 512-byte header block.
+// It returns nil without error when the end-of-archive marker is reached.
+func (tr *gnuTarReader) readHeader() (*tarReaderEntry, error) {
+	var block [tarBlockSize]byte
+	if _, err := io.ReadFull(tr.r, block[:]); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	if isZeroBlock(block[:]) {
+		// The end-of-archive marker is two zero blocks.
+		if _, err := io.ReadFull(tr.r, block[:]); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+
+		if !isZeroBlock(block[:]) {
+			return nil, errors.New("malformed end-of-archive marker")
+		}
+
+		return nil, nil
+	}
+
+	if err := verifyChecksum(block[:]); err != nil {
+		return nil, err
+	}
+
+	if string(block[257:263]) != "ustar\x00" {
+		return nil, fmt.Errorf("unsupported tar header magic %q", block[257:263])
+	}
+
+	var octErr error
+	parse := func(b []byte) int64 {
+		v, err := parseOctal(b)
+		if err != nil && octErr == nil {
+			octErr = err
+		}
+
+		return v
+	}
+
+	h := &tarReaderEntry{
+		name:     parseString(block[0:100]),
+		mode:     parse(block[100:108]),
+		uid:      parse(block[108:116]),
+		gid:      parse(block[116:124]),
+		size:     parse(block[124:136]),
+		mtime:    parse(block[136:148]),
+		typeflag: block[156],
+		linkname: parseString(block[157:257]),
+		uname:    parseString(block[265:297]),
+		gname:    parseString(block[297:329]),
+	}
+	if octErr != nil {
+		return nil, fmt.Errorf("%s: %w", h.name, octErr)
+	}
+
+	if prefix := parseString(block[345:500]); prefix != "" {
+		h.name = prefix + "/" + h.name
+	}
+
+	// Old-style regular files use NUL as type flag.
+	if h.typeflag == 0 {
+		h.typeflag = tarTypeFile
+	}
+
+	return h, nil
+}
+
+// readPAXRecords reads the data of a PAX extended header and parses its records.
+func (tr *gnuTarReader) readPAXRecords(size int64) (map[string]string, error) {
+	if size > maxPAXHeaderSize {
+		return nil, fmt.Errorf("PAX header of %d bytes exceeds the limit of %d bytes", size, maxPAXHeaderSize)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(tr.r, data); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	if err := tr.skip(blockPadding(size)); err != nil {
+		return nil, err
+	}
+
+	records := make(map[string]string)
+
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		if sp <= 0 {
+			return nil, errors.New("malformed PAX record")
+		}
+
+		length, err := strconv.Atoi(string(data[:sp]))
+		if err != nil || length <= sp+1 || length > len(data) || data[length-1] != '\n' {
+			return nil, errors.New("malformed PAX record length")
+		}
+
+		key, value, ok := strings.Cut(string(data[sp+1:length-1]), "=")
+		if !ok {
+			return nil, errors.New("malformed PAX record")
+		}
+
+		records[key] = value
+		data = data[length:]
+	}
+
+	return records, nil
+}
+
+// skip discards n bytes from the underlying reader.
+func (tr *gnuTarReader) skip(n int64) error {
+	if n <= 0 {
+		return nil
+	}
+
+	if _, err := io.CopyN(io.Discard, tr.r, n); err != nil {
+		return unexpectedEOF(err)
+	}
+
+	return nil
+}
+
+// verifyChecksum checks the header checksum computed with the chksum field as spaces.
+func verifyChecksum(block []byte) error {
+	want, err := parseOctal(block[148:156])
+	if err != nil {
+		return fmt.Errorf("invalid header checksum: %w", err)
+	}
+
+	var got int64
+	for i, b := range block {
+		if i >= 148 && i < 156 {
+			b = ' '
+		}
+
+		got += int64(b)
+	}
+
+	if got != want {
+		return fmt.Errorf("header checksum mismatch: want %o, got %o", want, got)
+	}
+
+	return nil
+}
+
+// parseOctal parses a NUL or space terminated octal number field.
+func parseOctal(b []byte) (int64, error) {
+	s := strings.Trim(string(b), " \x00")
+	if s == "" {
+		return 0, nil
+	}
+
+	v, err := strconv.ParseInt(s, 8, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid octal field %q", s)
+	}
+
+	return v, nil
+}
+
+// parseString returns the NUL-terminated string stored in a header field.
+func parseString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+
+	return string(b)
+}
+
+func isZeroBlock(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// blockPadding returns the number of padding bytes following size bytes of data.
+func blockPadding(size int64) int64 {
+	if r := size % tarBlockSize; r != 0 {
+		return tarBlockSize - r
+	}
+
+	return 0
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
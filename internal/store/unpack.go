@@ -0,0 +1,376 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+// TarballFileName is the name of the store tarball in fetcher v3+ output directories.
+const TarballFileName = "pnpm-store.tar.zst"
+
+// TarballReport describes the result of VerifyTarball.
+type TarballReport struct {
+	Entries    int      // number of entries in the tarball
+	Violations []string // deviations from the output of CreateTarball
+	Hash       string   // NAR hash of the extracted tree in SRI format
+}
+
+// extractedEntry records the final permissions of an extracted entry.
+// Permissions are applied after all entries are written because
+// normalized directories are read-only.
+type extractedEntry struct {
+	path string
+	mode fs.FileMode
+}
+
+// Unpack extracts a zstd-compressed tarball created by CreateTarball into outputPath,
+// restoring the permissions recorded in the tarball.
+// outputPath must not exist or must be an empty directory.
+func Unpack(afs afero.Fs, tarballPath string, outputPath string) store_err.StoreErrorIF {
+	if entries, err := afero.ReadDir(afs, outputPath); err == nil && len(entries) > 0 {
+		return store_err.NewStoreError(&store_err.OutputNotEmptyError{}, outputPath, nil)
+	}
+
+	return extractTarball(afs, tarballPath, outputPath, nil)
+}
+
+// VerifyTarball reads a zstd-compressed tarball and checks that it matches what
+// CreateTarball produces: entries sorted by path, mtime equal to SOURCE_DATE_EPOCH,
+// numeric root ownership and normalized permissions.
+// The tarball is extracted into a temporary directory to compute the NAR hash of the tree.
+// Violations are reported in the returned TarballReport, not as an error.
+func VerifyTarball(afs afero.Fs, tarballPath string) (*TarballReport, store_err.StoreErrorIF) {
+	tmpDir, err := afero.TempDir(afs, "", "nix-prefetch-pnpm-verify-")
+	if err != nil {
+		return nil, store_err.NewStoreError(
+			&store_err.FailedToReadTarballError{},
+			"failed to create temporary directory",
+			err,
+		)
+	}
+	defer func() { _ = RemoveAll(afs, tmpDir) }()
+
+	report := &TarballReport{}
+	prevKey := ""
+
+	treePath := filepath.Join(tmpDir, "store")
+	visit := func(h *tarReaderEntry) {
+		key := tarEntryKey(h.name)
+		if report.Entries > 0 && key <= prevKey {
+			report.Violations = append(report.Violations,
+				fmt.Sprintf("%s: not sorted by name (follows %s)", h.name, prevKey))
+		}
+
+		prevKey = key
+		report.Entries++
+		report.Violations = append(report.Violations, checkTarEntry(h)...)
+	}
+
+	if extractErr := extractTarball(afs, tarballPath, treePath, visit); extractErr != nil {
+		return nil, extractErr
+	}
+
+	hash, hashErr := Hash(afs, treePath)
+	if hashErr != nil {
+		return nil, hashErr
+	}
+
+	report.Hash = hash
+
+	return report, nil
+}
+
+// checkTarEntry returns the reproducibility violations of a single tar entry.
+func checkTarEntry(h *tarReaderEntry) []string {
+	var violations []string
+
+	if h.mtime != sourceDateEpoch {
+		violations = append(violations,
+			fmt.Sprintf("%s: mtime is %d, want %d", h.name, h.mtime, sourceDateEpoch))
+	}
+
+	if h.uid != 0 || h.gid != 0 || h.uname != "" || h.gname != "" {
+		violations = append(violations,
+			fmt.Sprintf("%s: owner is %d:%d (%q:%q), want numeric 0:0",
+				h.name, h.uid, h.gid, h.uname, h.gname))
+	}
+
+	if want, ok := normalizedMode(h); ok && h.mode != want {
+		violations = append(violations,
+			fmt.Sprintf("%s: mode is %04o, want %04o", h.name, h.mode, want))
+	}
+
+	for key := range h.pax {
+		if key != "path" && key != "linkpath" {
+			violations = append(violations,
+				fmt.Sprintf("%s: unexpected PAX record %q", h.name, key))
+		}
+	}
+
+	return violations
+}
+
+// normalizedMode returns the permission setPermissions assigns to the entry.
+// Symlinks have no meaningful permissions and are not checked.
+func normalizedMode(h *tarReaderEntry) (int64, bool) {
+	switch h.typeflag {
+	case tarTypeDir, tarTypeFile:
+		return int64(normalizedPerm(path.Base(tarEntryKey(h.name)), h.typeflag == tarTypeDir)), true
+	default:
+		return 0, false
+	}
+}
+
+// tarEntryKey converts a tar path back to the relative path used for sorting in CreateTarball.
+func tarEntryKey(name string) string {
+	key := strings.TrimSuffix(strings.TrimPrefix(name, "./"), "/")
+	if key == "" {
+		return "."
+	}
+
+	return key
+}
+
+// extractTarball extracts tarballPath into outputPath and applies the recorded permissions.
+// visit, if not nil, is called for every entry before it is extracted.
+func extractTarball(
+	afs afero.Fs,
+	tarballPath string,
+	outputPath string,
+	visit func(*tarReaderEntry),
+) store_err.StoreErrorIF {
+	f, err := afs.Open(tarballPath)
+	if err != nil {
+		return store_err.NewStoreError(
+			&store_err.FailedToReadTarballError{},
+			tarballPath,
+			err,
+		)
+	}
+	defer f.Close()
+
+	zr, err := newZstdReader(f)
+	if err != nil {
+		return store_err.NewStoreError(
+			&store_err.FailedToReadTarballError{},
+			tarballPath,
+			err,
+		)
+	}
+	defer zr.Close()
+
+	//nolint:mnd // extracted directories stay writable until permissions are applied
+	if mkdirErr := afs.MkdirAll(outputPath, 0o755); mkdirErr != nil {
+		return store_err.NewStoreError(
+			&store_err.FailedToReadTarballError{},
+			outputPath,
+			mkdirErr,
+		)
+	}
+
+	tr := newGNUTarReader(zr)
+
+	var extracted []extractedEntry
+
+	for {
+		h, nextErr := tr.next()
+		if errors.Is(nextErr, io.EOF) {
+			break
+		}
+
+		if nextErr != nil {
+			return store_err.NewStoreError(
+				&store_err.FailedToReadTarballError{},
+				tarballPath,
+				nextErr,
+			)
+		}
+
+		if visit != nil {
+			visit(h)
+		}
+
+		entry, entryErr := extractEntry(afs, tr, h, outputPath)
+		if entryErr != nil {
+			return entryErr
+		}
+
+		if entry != nil {
+			extracted = append(extracted, *entry)
+		}
+	}
+
+	return applyModes(afs, extracted)
+}
+
+// extractEntry writes a single tar entry below outputPath.
+// It returns the entry whose permissions must be applied afterwards, or nil for symlinks.
+func extractEntry(
+	afs afero.Fs,
+	tr *gnuTarReader,
+	h *tarReaderEntry,
+	outputPath string,
+) (*extractedEntry, store_err.StoreErrorIF) {
+	key := tarEntryKey(h.name)
+	if key != "." && !filepath.IsLocal(key) {
+		return nil, store_err.NewStoreError(
+			&store_err.InvalidTarballError{},
+			"entry escapes the output directory: "+h.name,
+			nil,
+		)
+	}
+
+	key = path.Clean(key)
+	if err := checkEntryPath(afs, outputPath, key); err != nil {
+		return nil, err
+	}
+
+	target := filepath.Join(outputPath, filepath.FromSlash(key))
+	mode := fs.FileMode(h.mode).Perm()
+
+	switch h.typeflag {
+	case tarTypeDir:
+		//nolint:mnd // extracted directories stay writable until permissions are applied
+		if err := afs.MkdirAll(target, 0o755); err != nil {
+			return nil, store_err.NewStoreError(&store_err.FailedToReadTarballError{}, target, err)
+		}
+	case tarTypeSymlink:
+		if key == "." || !isLocalLinkTarget(key, h.linkname) {
+			return nil, store_err.NewStoreError(
+				&store_err.InvalidTarballError{},
+				fmt.Sprintf("symlink points outside the output directory: %s -> %s", h.name, h.linkname),
+				nil,
+			)
+		}
+
+		linker, ok := afs.(afero.Linker)
+		if !ok {
+			return nil, store_err.NewStoreError(
+				&store_err.FailedToReadTarballError{},
+				target,
+				fs.ErrInvalid,
+			)
+		}
+
+		if err := linker.SymlinkIfPossible(h.linkname, target); err != nil {
+			return nil, store_err.NewStoreError(&store_err.FailedToReadTarballError{}, target, err)
+		}
+
+		return nil, nil
+	default:
+		if err := writeExtractedFile(afs, tr, target); err != nil {
+			return nil, err
+		}
+	}
+
+	return &extractedEntry{path: target, mode: mode}, nil
+}
+
+// checkEntryPath rejects an entry whose path goes through or replaces a symlink
+// extracted before, which would write outside outputPath or overwrite the link target.
+func checkEntryPath(afs afero.Fs, outputPath string, key string) store_err.StoreErrorIF {
+	if key == "." {
+		return nil
+	}
+
+	current := outputPath
+	for part := range strings.SplitSeq(key, "/") {
+		current = filepath.Join(current, part)
+
+		fi, err := lstat(afs, current)
+		if err != nil {
+			// Nothing below a missing path exists either.
+			return nil
+		}
+
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return store_err.NewStoreError(
+				&store_err.InvalidTarballError{},
+				"entry goes through or replaces a symlink: "+key,
+				nil,
+			)
+		}
+	}
+
+	return nil
+}
+
+// isLocalLinkTarget reports whether the target of the symlink at key stays inside the
+// output directory. The target must be relative and clean, so ".." only appears at
+// its start and climbs the real parent directories of the symlink: checkEntryPath
+// ensures that no symlink is created below another one. Targets of symlinks it goes
+// through are checked the same way, so it cannot be led outside by them either.
+func isLocalLinkTarget(key string, linkname string) bool {
+	if linkname == "" || path.IsAbs(linkname) || filepath.IsAbs(linkname) || path.Clean(linkname) != linkname {
+		return false
+	}
+
+	depth := strings.Count(key, "/")
+	for part := range strings.SplitSeq(linkname, "/") {
+		if part != ".." {
+			break
+		}
+		depth--
+	}
+
+	return depth >= 0
+}
+
+// lstat returns the FileInfo of name without following a symlink if afs supports it.
+func lstat(afs afero.Fs, name string) (fs.FileInfo, error) {
+	if lstater, ok := afs.(afero.Lstater); ok {
+		fi, _, err := lstater.LstatIfPossible(name)
+		return fi, err
+	}
+
+	return afs.Stat(name)
+}
+
+// writeExtractedFile copies the data of the current tar entry to target.
+func writeExtractedFile(afs afero.Fs, tr *gnuTarReader, target string) store_err.StoreErrorIF {
+	//nolint:mnd // extracted files stay writable until permissions are applied
+	out, err := afs.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return store_err.NewStoreError(&store_err.FailedToReadTarballError{}, target, err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, tr); err != nil {
+		return store_err.NewStoreError(&store_err.FailedToReadTarballError{}, target, err)
+	}
+
+	return nil
+}
+
+// applyModes sets the recorded permissions in reverse order so that
+// children are handled before their (possibly read-only) parent directories.
+func applyModes(afs afero.Fs, entries []extractedEntry) store_err.StoreErrorIF {
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+
+		// Chmod follows symlinks; extracted entries are never replaced by one.
+		if fi, err := lstat(afs, e.path); err == nil && fi.Mode()&fs.ModeSymlink != 0 {
+			return store_err.NewStoreError(
+				&store_err.InvalidTarballError{},
+				"extracted entry was replaced by a symlink: "+e.path,
+				nil,
+			)
+		}
+
+		if err := afs.Chmod(e.path, e.mode); err != nil {
+			return store_err.NewStoreError(&store_err.FailedToReadTarballError{}, e.path, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,374 @@
+package store_test
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+// setupTarball creates a store in afs, optionally normalizes its permissions,
+// and writes it as /out/pnpm-store.tar.zst.
+func setupTarball(t *testing.T, afs afero.Fs, normalize bool) {
+	t.Helper()
+
+	afs.MkdirAll("/store/v10/files/ab", 0o755)
+	afero.WriteFile(afs, "/store/v10/files/ab/cdef", []byte("content"), 0o644)
+	afero.WriteFile(afs, "/store/v10/files/ab/0123-exec", []byte("#!/bin/sh"), 0o755)
+	afero.WriteFile(afs, "/store/v10/index.json", []byte(`{"a":1}`), 0o644)
+
+	if normalize {
+		if err := store.Normalize(afs, store.NormalizeOptions{
+			StorePath:      "/store",
+			SetPermissions: true,
+		}); err != nil {
+			t.Fatalf("Normalize() error: %v", err)
+		}
+	}
+
+	afs.MkdirAll("/out", 0o755)
+
+	if err := store.CreateTarball(afs, "/store", "/out/"+store.TarballFileName, nil); err != nil {
+		t.Fatalf("CreateTarball() error: %v", err)
+	}
+}
+
+func Test_VerifyTarball(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		setupFs        func(t *testing.T) afero.Fs
+		path           string
+		wantViolations bool
+		wantErr        store_err.StoreErrorIF
+	}{
+		{
+			name: "[正常系] 正規化されたストアのtarballは違反なし",
+			setupFs: func(t *testing.T) afero.Fs {
+				t.Helper()
+				fs := afero.NewMemMapFs()
+				setupTarball(t, fs, true)
+				return fs
+			},
+			path: "/out/" + store.TarballFileName,
+		},
+		{
+			name: "[正常系] 権限が正規化されていないtarballは違反が報告される",
+			setupFs: func(t *testing.T) afero.Fs {
+				t.Helper()
+				fs := afero.NewMemMapFs()
+				setupTarball(t, fs, false)
+				return fs
+			},
+			path:           "/out/" + store.TarballFileName,
+			wantViolations: true,
+		},
+		{
+			name: "[異常系] tarballが存在しない",
+			setupFs: func(t *testing.T) afero.Fs {
+				t.Helper()
+				return afero.NewMemMapFs()
+			},
+			path:    "/out/" + store.TarballFileName,
+			wantErr: &store_err.FailedToReadTarballError{},
+		},
+		{
+			name: "[異常系] zstd形式でないファイル",
+			setupFs: func(t *testing.T) afero.Fs {
+				t.Helper()
+				fs := afero.NewMemMapFs()
+				afero.WriteFile(fs, "/out/"+store.TarballFileName, []byte("not zstd"), 0o644)
+				return fs
+			},
+			path:    "/out/" + store.TarballFileName,
+			wantErr: &store_err.FailedToReadTarballError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			afs := tt.setupFs(t)
+
+			got, gotErr := store.VerifyTarball(afs, tt.path)
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("VerifyTarball() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+
+			if gotErr != nil {
+				return
+			}
+
+			if (len(got.Violations) > 0) != tt.wantViolations {
+				t.Errorf("VerifyTarball() violations = %v, wantViolations %v",
+					got.Violations, tt.wantViolations)
+			}
+
+			want, hashErr := store.Hash(afs, "/store")
+			if hashErr != nil {
+				t.Fatalf("Hash() error: %v", hashErr)
+			}
+
+			if got.Hash != want {
+				t.Errorf("VerifyTarball() hash = %s, want %s", got.Hash, want)
+			}
+		})
+	}
+}
+
+func Test_Unpack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		setupFs func(t *testing.T) afero.Fs
+		output  string
+		wantErr store_err.StoreErrorIF
+		verify  func(t *testing.T, afs afero.Fs)
+	}{
+		{
+			name: "[正常系] 内容と権限が復元される",
+			setupFs: func(t *testing.T) afero.Fs {
+				t.Helper()
+				fs := afero.NewMemMapFs()
+				setupTarball(t, fs, true)
+				return fs
+			},
+			output: "/unpacked",
+			verify: func(t *testing.T, afs afero.Fs) {
+				t.Helper()
+				verifyFileContent(t, afs, "/unpacked/v10/files/ab/cdef", "content")
+				verifyFileContent(t, afs, "/unpacked/v10/index.json", "{\n  \"a\": 1\n}\n")
+				verifyPermissions(t, afs, []permCheck{
+					{path: "/unpacked", wantPerm: 0o555},
+					{path: "/unpacked/v10/files/ab", wantPerm: 0o555},
+					{path: "/unpacked/v10/files/ab/cdef", wantPerm: 0o444},
+					{path: "/unpacked/v10/files/ab/0123-exec", wantPerm: 0o555},
+				})
+			},
+		},
+		{
+			name: "[異常系] 出力先が空でない",
+			setupFs: func(t *testing.T) afero.Fs {
+				t.Helper()
+				fs := afero.NewMemMapFs()
+				setupTarball(t, fs, true)
+				afero.WriteFile(fs, "/unpacked/existing", []byte{}, 0o644)
+				return fs
+			},
+			output:  "/unpacked",
+			wantErr: &store_err.OutputNotEmptyError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			afs := tt.setupFs(t)
+
+			gotErr := store.Unpack(afs, "/out/"+store.TarballFileName, tt.output)
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("Unpack() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+
+			if tt.verify != nil {
+				tt.verify(t, afs)
+			}
+		})
+	}
+}
+
+func Test_Unpack_unsafeEntries(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		entries func(outside string) []store.RawTarEntry
+		wantErr store_err.StoreErrorIF
+	}{
+		{
+			name: "[正常系] 出力先内を指す相対シンボリックリンク",
+			entries: func(string) []store.RawTarEntry {
+				return []store.RawTarEntry{
+					{Name: "./", Typeflag: store.TarTypeDir},
+					{Name: "./d/", Typeflag: store.TarTypeDir},
+					{Name: "./d/l", Typeflag: store.TarTypeSymlink, Linkname: "../f"},
+					{Name: "./f", Typeflag: store.TarTypeFile, Data: "content"},
+				}
+			},
+		},
+		{
+			name: "[異常系] 絶対パスを指すシンボリックリンク",
+			entries: func(outside string) []store.RawTarEntry {
+				return []store.RawTarEntry{
+					{Name: "./a", Typeflag: store.TarTypeSymlink, Linkname: outside},
+					{Name: "./a/x", Typeflag: store.TarTypeFile, Data: "pwned"},
+				}
+			},
+			wantErr: &store_err.InvalidTarballError{},
+		},
+		{
+			name: "[異常系] 出力先の外を指す相対シンボリックリンク",
+			entries: func(string) []store.RawTarEntry {
+				return []store.RawTarEntry{
+					{Name: "./d/", Typeflag: store.TarTypeDir},
+					{Name: "./d/a", Typeflag: store.TarTypeSymlink, Linkname: "../../outside"},
+				}
+			},
+			wantErr: &store_err.InvalidTarballError{},
+		},
+		{
+			name: "[異常系] 正規化されていないシンボリックリンクのターゲット",
+			entries: func(string) []store.RawTarEntry {
+				return []store.RawTarEntry{
+					{Name: "./s", Typeflag: store.TarTypeSymlink, Linkname: "."},
+					{Name: "./a", Typeflag: store.TarTypeSymlink, Linkname: "s/.."},
+				}
+			},
+			wantErr: &store_err.InvalidTarballError{},
+		},
+		{
+			name: "[異常系] シンボリックリンクを経由するエントリ",
+			entries: func(string) []store.RawTarEntry {
+				return []store.RawTarEntry{
+					{Name: "./d/", Typeflag: store.TarTypeDir},
+					{Name: "./a", Typeflag: store.TarTypeSymlink, Linkname: "d"},
+					{Name: "./a/x", Typeflag: store.TarTypeFile, Data: "pwned"},
+				}
+			},
+			wantErr: &store_err.InvalidTarballError{},
+		},
+		{
+			name: "[異常系] シンボリックリンクを上書きするファイル",
+			entries: func(string) []store.RawTarEntry {
+				return []store.RawTarEntry{
+					{Name: "./f", Typeflag: store.TarTypeFile, Data: "content"},
+					{Name: "./a", Typeflag: store.TarTypeSymlink, Linkname: "f"},
+					{Name: "./a", Typeflag: store.TarTypeFile, Data: "pwned"},
+				}
+			},
+			wantErr: &store_err.InvalidTarballError{},
+		},
+		{
+			name: "[異常系] シンボリックリンクを上書きするディレクトリ",
+			entries: func(string) []store.RawTarEntry {
+				return []store.RawTarEntry{
+					{Name: "./d/", Typeflag: store.TarTypeDir},
+					{Name: "./a", Typeflag: store.TarTypeSymlink, Linkname: "d"},
+					{Name: "./a/", Typeflag: store.TarTypeDir},
+				}
+			},
+			wantErr: &store_err.InvalidTarballError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			afs := afero.NewOsFs()
+			dir := t.TempDir()
+			outside := filepath.Join(dir, "outside")
+			output := filepath.Join(dir, "unpacked")
+			tarballPath := filepath.Join(dir, store.TarballFileName)
+
+			if err := afs.Mkdir(outside, 0o755); err != nil {
+				t.Fatalf("Mkdir() error: %v", err)
+			}
+
+			if err := afero.WriteFile(afs, filepath.Join(dir, "f"), []byte("content"), 0o644); err != nil {
+				t.Fatalf("WriteFile() error: %v", err)
+			}
+
+			if err := store.WriteRawTarball(afs, tarballPath, tt.entries(outside)); err != nil {
+				t.Fatalf("WriteRawTarball() error: %v", err)
+			}
+
+			gotErr := store.Unpack(afs, tarballPath, output)
+			t.Cleanup(func() { _ = store.RemoveAll(afs, output) })
+
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("Unpack() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+
+			if entries, _ := afero.ReadDir(afs, outside); len(entries) > 0 {
+				t.Errorf("Unpack() wrote outside the output directory: %v", entries)
+			}
+
+			verifyFileContent(t, afs, filepath.Join(dir, "f"), "content")
+		})
+	}
+}
+
+// rawTarHeader returns a ustar header block with the given name, type flag and size field.
+func rawTarHeader(name string, typeflag byte, size string) []byte {
+	block := make([]byte, 512)
+	copy(block[0:100], name)
+	copy(block[100:108], "0000644\x00")
+	copy(block[108:116], "0000000\x00")
+	copy(block[116:124], "0000000\x00")
+	copy(block[124:136], size)
+	copy(block[136:148], "00000000000\x00")
+	block[156] = typeflag
+	copy(block[257:265], "ustar\x0000")
+
+	copy(block[148:156], "        ")
+
+	var sum int
+	for _, b := range block {
+		sum += int(b)
+	}
+
+	copy(block[148:156], fmt.Sprintf("%06o\x00 ", sum))
+
+	return block
+}
+
+func Test_Unpack_hostileHeaders(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		blocks  [][]byte
+		wantErr store_err.StoreErrorIF
+		wantMsg string
+	}{
+		{
+			name: "[異常系] 巨大なPAXヘッダ",
+			// The size field declares 8 GiB; the data itself is never written.
+			blocks:  [][]byte{rawTarHeader("PaxHeaders/x", 'x', "77777777777\x00")},
+			wantErr: &store_err.FailedToReadTarballError{},
+			wantMsg: "exceeds the limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			afs := afero.NewMemMapFs()
+			if err := store.WriteZstdFile(afs, "/"+store.TarballFileName, bytes.Join(tt.blocks, nil)); err != nil {
+				t.Fatalf("WriteZstdFile() error: %v", err)
+			}
+
+			gotErr := store.Unpack(afs, "/"+store.TarballFileName, "/unpacked")
+			if reflect.TypeOf(gotErr) != reflect.TypeOf(tt.wantErr) {
+				t.Fatalf("Unpack() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+
+			if !strings.Contains(gotErr.Error(), tt.wantMsg) {
+				t.Errorf("Unpack() error = %v, want to contain %q", gotErr, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package store
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	store_err "github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store/errors"
+)
+
+// IntegrityProblem describes a package whose files in the store failed verification.
+type IntegrityProblem struct {
+	Package string // name@version, or the index file path if the index does not record it
+	Path    string // path of the offending file, relative to the store root
+	Reason  string
+	Missing bool // no index file is stored under the integrity of the package
+}
+
+func (p IntegrityProblem) String() string {
+	return p.Package + ": " + p.Path + ": " + p.Reason
+}
+
+// Verify re-hashes every content file referenced by the store index files and checks it
+// against the integrity recorded in the index. Every package in packageIntegrities
+// (name@version to integrity, e.g. from the lockfile) must have an index file stored under
+// its sha512 integrity as well; packages without one are reported as Missing, or at the
+// index file stored for them under another integrity. The returned problems are sorted by
+// package and path.
+func Verify(
+	afs afero.Fs,
+	storePath string,
+	packageIntegrities map[string]string,
+) ([]IntegrityProblem, store_err.StoreErrorIF) {
+	versionDirs, dirsErr := detectStoreVersionDirs(afs, storePath)
+	if dirsErr != nil {
+		return nil, dirsErr
+	}
+
+	var (
+		problems []IntegrityProblem
+		indexed  []IndexedPackage
+	)
+
+	walkErr := walkIndexFiles(afs, storePath, func(indexPath string) error {
+		pkg, found, verifyErr := verifyIndexFile(afs, storePath, indexPath)
+		if verifyErr != nil {
+			return verifyErr
+		}
+
+		problems = append(problems, found...)
+		indexed = append(indexed, IndexedPackage{
+			ID:           pkg,
+			IndexPath:    indexPath,
+			IntegrityHex: indexIntegrityHex(indexPath),
+		})
+
+		return nil
+	})
+	if walkErr != nil {
+		return nil, walkErr
+	}
+
+	problems = append(problems, checkPackageIntegrities(versionDirs, indexed, packageIntegrities)...)
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Package != problems[j].Package {
+			return problems[i].Package < problems[j].Package
+		}
+
+		return problems[i].Path < problems[j].Path
+	})
+
+	return problems, nil
+}
+
+// verifyIndexFile verifies the content files of a single index file and returns the
+// package it describes.
+func verifyIndexFile(afs afero.Fs, storePath string, indexPath string) (string, []IntegrityProblem, error) {
+	data, err := afero.ReadFile(afs, filepath.Join(storePath, filepath.FromSlash(indexPath)))
+	if err != nil {
+		return "", nil, err
+	}
+
+	var index PackageIndexFile
+	if decodeErr := json.Unmarshal(data, &index); decodeErr != nil {
+		return packageID(indexPath, &index), []IntegrityProblem{{
+			Package: indexPath,
+			Path:    indexPath,
+			Reason:  "invalid index file: " + decodeErr.Error(),
+		}}, nil
+	}
+
+	pkg := packageID(indexPath, &index)
+
+	var problems []IntegrityProblem
+
+	versionDir, _, _ := strings.Cut(indexPath, "/")
+
+	names := make([]string, 0, len(index.Files))
+	for name := range index.Files {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		entry := index.Files[name]
+
+		contentPath, pathErr := ContentPath(versionDir, entry)
+		if pathErr != nil {
+			problems = append(problems, IntegrityProblem{Package: pkg, Path: indexPath, Reason: name + ": " + pathErr.Error()})
+			continue
+		}
+
+		reason, checkErr := checkContentFile(afs, filepath.Join(storePath, filepath.FromSlash(contentPath)), entry)
+		if checkErr != nil {
+			return "", nil, checkErr
+		}
+
+		if reason != "" {
+			problems = append(problems, IntegrityProblem{Package: pkg, Path: contentPath, Reason: name + ": " + reason})
+		}
+	}
+
+	return pkg, problems, nil
+}
+
+// checkPackageIntegrities checks that every package with a sha512 integrity has an index
+// file stored under it. Index files of other store versions (e.g. v3) do not always record
+// the package, so they are looked up by the path derived from the integrity; a package
+// found under another integrity by name@version is reported at that index file.
+func checkPackageIntegrities(
+	versionDirs []string,
+	indexed []IndexedPackage,
+	packageIntegrities map[string]string,
+) []IntegrityProblem {
+	byHex := make(map[string]bool, len(indexed))
+	byID := make(map[string][]string)
+
+	for _, pkg := range indexed {
+		byHex[pkg.IntegrityHex] = true
+		byID[pkg.ID] = append(byID[pkg.ID], pkg.IndexPath)
+	}
+
+	var problems []IntegrityProblem
+
+	for pkg, integrity := range packageIntegrities {
+		h := integrityHex(integrity)
+		if h == "" || byHex[h] || byHex[h[:min(len(h), storeV10IndexHexLen)]] {
+			continue
+		}
+
+		if indexPaths, ok := byID[pkg]; ok {
+			for _, indexPath := range indexPaths {
+				problems = append(problems, IntegrityProblem{
+					Package: pkg,
+					Path:    indexPath,
+					Reason:  checkIndexIntegrity(indexPath, integrity),
+				})
+			}
+
+			continue
+		}
+
+		for _, dir := range versionDirs {
+			problems = append(problems, IntegrityProblem{
+				Package: pkg,
+				Path:    expectedIndexPath(dir, h, pkg),
+				Reason:  "no index file is stored under lockfile integrity " + integrity,
+				Missing: true,
+			})
+		}
+	}
+
+	return problems
+}
+
+// expectedIndexPath returns the path, relative to the store root, of the index file of pkg
+// stored under the hex digest h in the store version directory versionDir.
+func expectedIndexPath(versionDir string, h string, pkg string) string {
+	if versionDir == "v3" {
+		return path.Join(versionDir, "files", h[:2], h[2:]+"-index.json")
+	}
+
+	name := strings.Replace(pkg, "/", "+", 1)
+
+	return path.Join(versionDir, "index", h[:2], h[2:min(len(h), storeV10IndexHexLen)]+"-"+name+".json")
+}
+
+// checkContentFile re-hashes a content file and describes how it differs from entry.
+// An empty reason means the file is intact.
+func checkContentFile(afs afero.Fs, contentPath string, entry PackageIndexEntry) (string, error) {
+	f, err := afs.Open(contentPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "content file is missing", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha512.New()
+
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	if got := "sha512-" + base64.StdEncoding.EncodeToString(h.Sum(nil)); got != entry.Integrity {
+		return fmt.Sprintf("content has integrity %s, index records %s", got, entry.Integrity), nil
+	}
+
+	if entry.Size != 0 && n != entry.Size {
+		return fmt.Sprintf("content has size %d, index records %d", n, entry.Size), nil
+	}
+
+	return "", nil
+}
+
+// checkIndexIntegrity checks that an index file is stored under the given package integrity.
+// Index file names start with the hex digest of the package integrity (truncated to
+// 64 characters by store v10). An empty reason means they match.
+func checkIndexIntegrity(indexPath string, integrity string) string {
+	expectedHex := integrityHex(integrity)
+	if expectedHex == "" {
+		return ""
+	}
+
+	if indexHex := indexIntegrityHex(indexPath); !strings.HasPrefix(expectedHex, indexHex) {
+		return fmt.Sprintf("index is stored under integrity %s..., lockfile records %s", indexHex, integrity)
+	}
+
+	return ""
+}
+
+// indexIntegrityHex returns the (possibly truncated) hex digest of the package integrity
+// encoded in an index file path.
+func indexIntegrityHex(indexPath string) string {
+	prefix := path.Base(path.Dir(indexPath))
+	base := path.Base(indexPath)
+
+	if strings.HasSuffix(base, "-index.json") {
+		return prefix + strings.TrimSuffix(base, "-index.json")
+	}
+
+	rest, _, _ := strings.Cut(base, "-")
+
+	return prefix + rest
+}
@@ -0,0 +1,184 @@
+package store_test
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/cffnpwr/nix-prefetch-pnpm-deps/internal/store"
+)
+
+// sha512Integrity returns the integrity and hex digest of content.
+func sha512Integrity(content string) (string, string) {
+	sum := sha512.Sum512([]byte(content))
+	return "sha512-" + base64.StdEncoding.EncodeToString(sum[:]), hex.EncodeToString(sum[:])
+}
+
+// writeStorePackage writes a v10 store package (name@version) with a single index.js file and
+// returns the path of its content file. pkgIntegrity is the integrity the index file is stored under.
+func writeStorePackage(t *testing.T, afs afero.Fs, name, version, pkgIntegrity, content string) string {
+	t.Helper()
+
+	fileIntegrity, fileHex := sha512Integrity(content)
+	raw, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(pkgIntegrity, "sha512-"))
+	pkgHex := hex.EncodeToString(raw)
+
+	indexPath := path.Join("/store/v10/index", pkgHex[:2], pkgHex[2:64]+"-"+name+"@"+version+".json")
+	index := `{"name":"` + name + `","version":"` + version + `","files":{"index.js":{"integrity":"` + fileIntegrity +
+		`","mode":420,"size":` + strconv.Itoa(len(content)) + `}}}`
+	contentPath := path.Join("/store/v10/files", fileHex[:2], fileHex[2:])
+
+	afs.MkdirAll(path.Dir(indexPath), 0o755)
+	afs.MkdirAll(path.Dir(contentPath), 0o755)
+	afero.WriteFile(afs, indexPath, []byte(index), 0o644)
+	afero.WriteFile(afs, contentPath, []byte(content), 0o644)
+
+	return strings.TrimPrefix(contentPath, "/store/")
+}
+
+// writeStoreV3Package writes a v3 store package with a single index.js file. Like older pnpm
+// versions, the index file does not record the package name and version.
+func writeStoreV3Package(t *testing.T, afs afero.Fs, pkgIntegrity, content string) {
+	t.Helper()
+
+	fileIntegrity, fileHex := sha512Integrity(content)
+	raw, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(pkgIntegrity, "sha512-"))
+	pkgHex := hex.EncodeToString(raw)
+
+	indexPath := path.Join("/store/v3/files", pkgHex[:2], pkgHex[2:]+"-index.json")
+	index := `{"files":{"index.js":{"integrity":"` + fileIntegrity +
+		`","mode":420,"size":` + strconv.Itoa(len(content)) + `}}}`
+	contentPath := path.Join("/store/v3/files", fileHex[:2], fileHex[2:])
+
+	afs.MkdirAll(path.Dir(indexPath), 0o755)
+	afs.MkdirAll(path.Dir(contentPath), 0o755)
+	afero.WriteFile(afs, indexPath, []byte(index), 0o644)
+	afero.WriteFile(afs, contentPath, []byte(content), 0o644)
+}
+
+func Test_Verify(t *testing.T) {
+	t.Parallel()
+
+	pkgIntegrity, _ := sha512Integrity("foo-1.0.0.tgz")
+	otherIntegrity, _ := sha512Integrity("foo-1.0.1.tgz")
+
+	tests := []struct {
+		name         string
+		setup        func(t *testing.T, afs afero.Fs) string
+		integrities  map[string]string
+		wantPackages []string
+		wantReason   string
+		wantMissing  bool
+	}{
+		{
+			name: "[正常系] 破損がない",
+			setup: func(t *testing.T, afs afero.Fs) string {
+				t.Helper()
+				return writeStorePackage(t, afs, "foo", "1.0.0", pkgIntegrity, "module.exports = 1")
+			},
+			integrities: map[string]string{"foo@1.0.0": pkgIntegrity},
+		},
+		{
+			name: "[異常系] 内容ファイルが破損している",
+			setup: func(t *testing.T, afs afero.Fs) string {
+				t.Helper()
+				contentPath := writeStorePackage(t, afs, "foo", "1.0.0", pkgIntegrity, "module.exports = 1")
+				afero.WriteFile(afs, "/store/"+contentPath, []byte("module.exports = 2"), 0o644)
+				return contentPath
+			},
+			wantPackages: []string{"foo@1.0.0"},
+			wantReason:   "index.js: content has integrity",
+		},
+		{
+			name: "[異常系] 内容ファイルが存在しない",
+			setup: func(t *testing.T, afs afero.Fs) string {
+				t.Helper()
+				contentPath := writeStorePackage(t, afs, "foo", "1.0.0", pkgIntegrity, "module.exports = 1")
+				afs.Remove("/store/" + contentPath)
+				return contentPath
+			},
+			wantPackages: []string{"foo@1.0.0"},
+			wantReason:   "index.js: content file is missing",
+		},
+		{
+			name: "[異常系] indexのintegrityがlockfileと一致しない",
+			setup: func(t *testing.T, afs afero.Fs) string {
+				t.Helper()
+				return writeStorePackage(t, afs, "foo", "1.0.0", pkgIntegrity, "module.exports = 1")
+			},
+			integrities:  map[string]string{"foo@1.0.0": otherIntegrity},
+			wantPackages: []string{"foo@1.0.0"},
+			wantReason:   "lockfile records " + otherIntegrity,
+		},
+		{
+			name: "[正常系] v3のindexがlockfileのintegrityで保存されている",
+			setup: func(t *testing.T, afs afero.Fs) string {
+				t.Helper()
+				writeStoreV3Package(t, afs, pkgIntegrity, "module.exports = 1")
+				return ""
+			},
+			integrities: map[string]string{"foo@1.0.0": pkgIntegrity},
+		},
+		{
+			name: "[異常系] v3のindexがlockfileのintegrityで保存されていない",
+			setup: func(t *testing.T, afs afero.Fs) string {
+				t.Helper()
+				writeStoreV3Package(t, afs, pkgIntegrity, "module.exports = 1")
+				return ""
+			},
+			integrities:  map[string]string{"foo@1.0.0": otherIntegrity},
+			wantPackages: []string{"foo@1.0.0"},
+			wantReason:   "no index file is stored under lockfile integrity " + otherIntegrity,
+			wantMissing:  true,
+		},
+		{
+			name: "[異常系] lockfileのパッケージのindexが存在しない",
+			setup: func(t *testing.T, afs afero.Fs) string {
+				t.Helper()
+				return writeStorePackage(t, afs, "foo", "1.0.0", pkgIntegrity, "module.exports = 1")
+			},
+			integrities:  map[string]string{"foo@1.0.0": pkgIntegrity, "bar@2.0.0": otherIntegrity},
+			wantPackages: []string{"bar@2.0.0"},
+			wantReason:   "no index file is stored under lockfile integrity " + otherIntegrity,
+			wantMissing:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			afs := afero.NewMemMapFs()
+			tt.setup(t, afs)
+
+			got, gotErr := store.Verify(afs, "/store", tt.integrities)
+			if gotErr != nil {
+				t.Fatalf("Verify() error = %v", gotErr)
+			}
+
+			var gotPackages []string
+			for _, p := range got {
+				gotPackages = append(gotPackages, p.Package)
+			}
+
+			if !reflect.DeepEqual(gotPackages, tt.wantPackages) {
+				t.Fatalf("Verify() packages = %v, want %v (problems: %v)", gotPackages, tt.wantPackages, got)
+			}
+
+			if tt.wantReason != "" && !strings.Contains(got[0].Reason, tt.wantReason) {
+				t.Errorf("Verify() reason = %q, want to contain %q", got[0].Reason, tt.wantReason)
+			}
+
+			if len(got) > 0 && got[0].Missing != tt.wantMissing {
+				t.Errorf("Verify() missing = %t, want %t", got[0].Missing, tt.wantMissing)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package store
+
+/*
+#cgo pkg-config: libzstd
+#include <zstd.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"unsafe"
+)
+
+// zstdReader decompresses a zstd stream using the C zstd library.
+// It is the counterpart of zstdWriter and accepts any sequence of zstd frames.
+type zstdReader struct {
+	r      io.Reader
+	dctx   *C.ZSTD_DCtx
+	inBuf  []byte // compressed input buffer (ZSTD_DStreamInSize)
+	inPos  int    // bytes of inBuf already consumed by the decompressor
+	inLen  int    // bytes of inBuf filled from r
+	outBuf []byte // decompressed output buffer (ZSTD_DStreamOutSize)
+	outPos int    // bytes of outBuf already returned to the caller
+	outLen int    // bytes of outBuf filled by the decompressor
+	srcEOF bool   // whether r has been fully read
+	inited bool   // whether at least one byte of input has been seen
+	frame  bool   // whether the decompressor is in the middle of a frame
+	flush  bool   // whether the decompressor may still hold output to flush
+}
+
+// newZstdReader creates a zstd decompressor reading compressed data from r.
+func newZstdReader(r io.Reader) (*zstdReader, error) {
+	dctx := C.ZSTD_createDCtx()
+	if dctx == nil {
+		return nil, errors.New("failed to create zstd decompression context")
+	}
+
+	return &zstdReader{
+		r:      r,
+		dctx:   dctx,
+		inBuf:  make([]byte, int(C.ZSTD_DStreamInSize())),
+		outBuf: make([]byte, int(C.ZSTD_DStreamOutSize())),
+	}, nil
+}
+
+func (z *zstdReader) Read(p []byte) (int, error) {
+	if z.dctx == nil {
+		return 0, errors.New("zstd reader is closed")
+	}
+
+	for z.outPos == z.outLen {
+		if err := z.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, z.outBuf[z.outPos:z.outLen])
+	z.outPos += n
+
+	return n, nil
+}
+
+func (z *zstdReader) Close() error {
+	if z.dctx == nil {
+		return nil
+	}
+
+	C.ZSTD_freeDCtx(z.dctx)
+	z.dctx = nil
+
+	return nil
+}
+
+// fill reads more compressed input if needed and runs one decompression step.
+// It returns io.EOF once the source is exhausted and the last frame is complete.
+func (z *zstdReader) fill() error {
+	if z.inPos == z.inLen && !z.flush {
+		if z.srcEOF {
+			if z.frame || !z.inited {
+				return io.ErrUnexpectedEOF
+			}
+
+			return io.EOF
+		}
+
+		n, err := z.r.Read(z.inBuf)
+		z.inPos = 0
+		z.inLen = n
+
+		if n > 0 {
+			z.inited = true
+		}
+
+		if errors.Is(err, io.EOF) {
+			z.srcEOF = true
+		} else if err != nil {
+			return err
+		}
+
+		if n == 0 {
+			return nil
+		}
+	}
+
+	return z.decompressStep()
+}
+
+// decompressStep feeds the buffered input to ZSTD_decompressStream once.
+func (z *zstdReader) decompressStep() error {
+	var pinner runtime.Pinner
+
+	pinner.Pin(&z.inBuf[0])
+	pinner.Pin(&z.outBuf[0])
+
+	defer pinner.Unpin()
+
+	input := C.ZSTD_inBuffer{
+		src:  unsafe.Pointer(&z.inBuf[0]),
+		size: C.size_t(z.inLen),
+		pos:  C.size_t(z.inPos),
+	}
+	output := C.ZSTD_outBuffer{
+		dst:  unsafe.Pointer(&z.outBuf[0]),
+		size: C.size_t(len(z.outBuf)),
+		pos:  0,
+	}
+
+	ret := C.ZSTD_decompressStream(z.dctx, &output, &input)
+	if C.ZSTD_isError(ret) != 0 {
+		return fmt.Errorf(
+			"zstd decompress: %s",
+			C.GoString(C.ZSTD_getErrorName(ret)),
+		)
+	}
+
+	// A return value of 0 means a frame has been fully decoded and flushed.
+	z.frame = ret != 0
+	// A full output buffer may leave decoded data inside the context.
+	z.flush = int(output.pos) == len(z.outBuf)
+	z.inPos = int(input.pos)
+	z.outPos = 0
+	z.outLen = int(output.pos)
+
+	return nil
+}
@@ -41,6 +41,12 @@ type zstdWriter struct {
 	initialized bool   // whether initStream has been called
 }
 
+// ZstdVersion returns the version of the linked libzstd. Compressed outputs, and so the
+// hashes of fetcher v3 outputs, are only byte-identical with the same libzstd version.
+func ZstdVersion() string {
+	return C.GoString(C.ZSTD_versionString())
+}
+
 // newZstdWriter creates a zstd compressor matching CLI zstd defaults:
 // compression level 3, content checksum enabled.
 func newZstdWriter(w io.Writer) (*zstdWriter, error) {